@@ -0,0 +1,120 @@
+// Package decimal does exact arithmetic on decimal numbers such as prices
+// and tax rates, which floats can't hold: 0.1 + 0.2 != 0.3.
+package decimal
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimal is an exact decimal number. The zero value is 0.
+type Decimal struct {
+	r *big.Rat
+}
+
+var ErrSyntax = errors.New("not a decimal number")
+
+// Parse reads numbers like "12", "-4.50" or "1e3".
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	// big.Rat also takes fractions like "1/3", which have no exact
+	// decimal form, and hex or binary numbers.
+	if s == "" || strings.Trim(s, "0123456789+-.eE") != "" {
+		return Decimal{}, fmt.Errorf("%q: %w", s, ErrSyntax)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%q: %w", s, ErrSyntax)
+	}
+	return Decimal{r}, nil
+}
+
+// MustParse is Parse for constants, it panics on error.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func FromInt(i int64) Decimal {
+	return Decimal{new(big.Rat).SetInt64(i)}
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+	return d.r
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	return Decimal{new(big.Rat).Add(d.rat(), o.rat())}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{new(big.Rat).Mul(d.rat(), o.rat())}
+}
+
+func (d Decimal) Sign() int {
+	return d.rat().Sign()
+}
+
+func (d Decimal) Cmp(o Decimal) int {
+	return d.rat().Cmp(o.rat())
+}
+
+// Round rounds to places decimals, halves away from zero like math.Round.
+func (d Decimal) Round(places int) Decimal {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+
+	scaled := new(big.Rat).Mul(d.rat(), new(big.Rat).SetInt(scale))
+	num := new(big.Int).Abs(scaled.Num())
+	den := scaled.Denom()
+
+	// floor(|x| + 1/2) = (2|num| + den) / 2den
+	q := new(big.Int).Mul(num, big.NewInt(2))
+	q.Add(q, den)
+	q.Quo(q, new(big.Int).Mul(den, big.NewInt(2)))
+	if scaled.Sign() < 0 {
+		q.Neg(q)
+	}
+
+	return Decimal{new(big.Rat).SetFrac(q, scale)}
+}
+
+// StringFixed formats d rounded to places decimals, "12.50" for 2.
+func (d Decimal) StringFixed(places int) string {
+	return d.Round(places).rat().FloatString(places)
+}
+
+// String formats d with as many decimals as it needs, "0.075" or "12".
+// Numbers without a finite decimal form are cut at 16 decimals.
+func (d Decimal) String() string {
+	s := d.rat().FloatString(16)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// MarshalJSON writes d as a JSON number, without going through float64.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	v, err := Parse(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
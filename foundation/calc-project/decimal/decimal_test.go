@@ -0,0 +1,83 @@
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"", "abc", "1/3", "1.2.3", "0x10"} {
+		if _, err := Parse(s); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) = %v, want ErrSyntax", s, err)
+		}
+	}
+
+	tests := map[string]string{
+		"12":      "12",
+		" 4.50 ":  "4.5",
+		"-0.075":  "-0.075",
+		"1e3":     "1000",
+		"0.10000": "0.1",
+	}
+	for in, want := range tests {
+		d, err := Parse(in)
+		if err != nil || d.String() != want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", in, d, err, want)
+		}
+	}
+}
+
+func TestExact(t *testing.T) {
+	// 0.1 + 0.2 is 0.30000000000000004 in float64.
+	if got := MustParse("0.1").Add(MustParse("0.2")); got.Cmp(MustParse("0.3")) != 0 {
+		t.Errorf("0.1 + 0.2 = %s", got)
+	}
+
+	// 1.005 is 1.00499999999999989... in float64, so math.Round gives 1.
+	if got := MustParse("1.005").Round(2).String(); got != "1.01" {
+		t.Errorf("Round(1.005, 2) = %s, want 1.01", got)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int
+		want   string
+	}{
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"2.4999", 0, "2"},
+		{"124.745", 2, "124.75"},
+		{"-124.745", 2, "-124.75"},
+		{"124.7449", 2, "124.74"},
+		{"0.004", 2, "0"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.in).Round(tt.places).String(); got != tt.want {
+			t.Errorf("Round(%s, %d) = %s, want %s", tt.in, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestStringFixed(t *testing.T) {
+	if got := MustParse("275.2").StringFixed(2); got != "275.20" {
+		t.Errorf("StringFixed = %s", got)
+	}
+	if got := (Decimal{}).StringFixed(2); got != "0.00" {
+		t.Errorf("zero value StringFixed = %s", got)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(map[string]Decimal{"v": MustParse("124.74")})
+	if err != nil || string(data) != `{"v":124.74}` {
+		t.Fatalf("Marshal = %s, %v", data, err)
+	}
+
+	var v map[string]Decimal
+	if err := json.Unmarshal(data, &v); err != nil || v["v"].String() != "124.74" {
+		t.Errorf("Unmarshal = %v, %v", v, err)
+	}
+}
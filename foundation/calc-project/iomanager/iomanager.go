@@ -0,0 +1,191 @@
+// Package iomanager separates where the calculator reads prices and writes
+// results from the calculation: files, stdin/stdout, or memory in tests.
+package iomanager
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type IOManager interface {
+	// ReadLines returns the input, one entry per line.
+	ReadLines() ([]string, error)
+	// WriteResult stores the result of a job.
+	WriteResult(data any) error
+}
+
+// CSVEncoder is implemented by results that can be written as CSV. The
+// first record is the header.
+type CSVEncoder interface {
+	CSVRecords() [][]string
+}
+
+var ErrNoCSV = errors.New("result can't be written as CSV")
+
+// FileManager reads from one file and writes to another. The result is
+// written as CSV when OutputPath ends in .csv, as JSON otherwise.
+type FileManager struct {
+	InputPath  string
+	OutputPath string
+}
+
+func NewFileManager(inputPath, outputPath string) FileManager {
+	return FileManager{InputPath: inputPath, OutputPath: outputPath}
+}
+
+func (fm FileManager) ReadLines() ([]string, error) {
+	file, err := os.Open(fm.InputPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", fm.InputPath, err)
+	}
+	defer file.Close()
+
+	lines, err := readLines(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", fm.InputPath, err)
+	}
+	return lines, nil
+}
+
+func (fm FileManager) WriteResult(data any) error {
+	if dir := filepath.Dir(fm.OutputPath); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(fm.OutputPath)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", fm.OutputPath, err)
+	}
+
+	if strings.EqualFold(filepath.Ext(fm.OutputPath), ".csv") {
+		var records [][]string
+		if records, err = csvRecords(data); err == nil {
+			err = writeCSV(file, records)
+		}
+	} else {
+		err = writeJSON(file, data)
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write %s: %w", fm.OutputPath, err)
+	}
+	return nil
+}
+
+// CmdManager reads the input once from In (stdin) and writes results as
+// JSON, or as CSV when CSV is set, to Out (stdout). It can be shared by jobs
+// running concurrently. CSV results after the first leave out their header,
+// so Out holds one table.
+type CmdManager struct {
+	In  io.Reader
+	Out io.Writer
+	CSV bool
+
+	readOnce sync.Once
+	lines    []string
+	err      error
+
+	mu          sync.Mutex
+	wroteHeader bool
+}
+
+func NewCmdManager(csv bool) *CmdManager {
+	return &CmdManager{In: os.Stdin, Out: os.Stdout, CSV: csv}
+}
+
+func (cm *CmdManager) ReadLines() ([]string, error) {
+	cm.readOnce.Do(func() {
+		cm.lines, cm.err = readLines(cm.In)
+	})
+	return cm.lines, cm.err
+}
+
+func (cm *CmdManager) WriteResult(data any) error {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	if !cm.CSV {
+		return writeJSON(cm.Out, data)
+	}
+
+	records, err := csvRecords(data)
+	if err != nil {
+		return err
+	}
+	if cm.wroteHeader && len(records) > 0 {
+		records = records[1:]
+	}
+	cm.wroteHeader = true
+
+	return writeCSV(cm.Out, records)
+}
+
+// MemoryManager keeps input and results in memory, for tests.
+type MemoryManager struct {
+	Lines []string
+	Err   error
+
+	mu      sync.Mutex
+	results []any
+}
+
+func (mm *MemoryManager) ReadLines() ([]string, error) {
+	return mm.Lines, mm.Err
+}
+
+func (mm *MemoryManager) WriteResult(data any) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.results = append(mm.results, data)
+	return nil
+}
+
+// Results returns what was written, in order.
+func (mm *MemoryManager) Results() []any {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return append([]any(nil), mm.results...)
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+func writeJSON(w io.Writer, data any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func csvRecords(data any) ([][]string, error) {
+	enc, ok := data.(CSVEncoder)
+	if !ok {
+		return nil, fmt.Errorf("%T: %w", data, ErrNoCSV)
+	}
+	return enc.CSVRecords(), nil
+}
+
+func writeCSV(w io.Writer, records [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.WriteAll(records)
+	return cw.Error()
+}
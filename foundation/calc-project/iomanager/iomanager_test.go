@@ -0,0 +1,96 @@
+package iomanager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type table struct{}
+
+func (table) CSVRecords() [][]string {
+	return [][]string{{"price", "total"}, {"10", "12.60"}}
+}
+
+func TestFileManager(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "prices.txt")
+	os.WriteFile(input, []byte("99\n135\n"), 0o644)
+
+	fm := NewFileManager(input, filepath.Join(dir, "out", "result.csv"))
+	lines, err := fm.ReadLines()
+	if err != nil || strings.Join(lines, ",") != "99,135" {
+		t.Fatalf("ReadLines() = %v, %v", lines, err)
+	}
+
+	if err := fm.WriteResult(table{}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(fm.OutputPath)
+	if string(data) != "price,total\n10,12.60\n" {
+		t.Errorf("CSV = %q", data)
+	}
+
+	if err := fm.WriteResult(map[string]int{"a": 1}); !errors.Is(err, ErrNoCSV) {
+		t.Errorf("WriteResult(map) = %v, want ErrNoCSV", err)
+	}
+
+	fm.OutputPath = filepath.Join(dir, "result.json")
+	if err := fm.WriteResult(map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(fm.OutputPath)
+	if !strings.Contains(string(data), `"a": 1`) {
+		t.Errorf("JSON = %q", data)
+	}
+
+	if _, err := NewFileManager(filepath.Join(dir, "missing.txt"), "").ReadLines(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ReadLines(missing) = %v", err)
+	}
+}
+
+func TestCmdManagerReadsOnce(t *testing.T) {
+	var out bytes.Buffer
+	cm := &CmdManager{In: strings.NewReader("1\n2\n"), Out: &out}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lines, err := cm.ReadLines()
+			if err != nil || len(lines) != 2 {
+				t.Errorf("ReadLines() = %v, %v", lines, err)
+			}
+			cm.WriteResult(lines)
+		}()
+	}
+	wg.Wait()
+
+	if n := strings.Count(out.String(), `"1"`); n != 4 {
+		t.Errorf("wrote %d results, want 4:\n%s", n, out.String())
+	}
+}
+
+func TestCmdManagerWritesCSV(t *testing.T) {
+	var out bytes.Buffer
+	cm := &CmdManager{Out: &out, CSV: true}
+
+	for i := 0; i < 2; i++ {
+		if err := cm.WriteResult(table{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := "price,total\n10,12.60\n10,12.60\n"; out.String() != want {
+		t.Errorf("got %q, want one table %q", out.String(), want)
+	}
+
+	if err := cm.WriteResult([]string{"1"}); !errors.Is(err, ErrNoCSV) {
+		t.Errorf("WriteResult(non CSV) = %v, want ErrNoCSV", err)
+	}
+}
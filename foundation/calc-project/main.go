@@ -1,14 +1,97 @@
 package main
 
-import "project/calculator/prices"
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"project/calculator/decimal"
+	"project/calculator/iomanager"
+	"project/calculator/prices"
+)
 
 func main() {
-	taxrates := []float64{.26, .36}
+	input := flag.String("input", "prices.txt", "file with one price per line, - for stdin")
+	rates := flag.String("rates", "0.26,0.36", "comma separated tax rates")
+	format := flag.String("format", "json", "result format: json or csv")
+	outDir := flag.String("out", ".", "directory for the result files")
+	flag.Parse()
+
+	if *format != "json" && *format != "csv" {
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
+
+	taxRates, err := parseRates(*rates)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// stdin can only be read once, so the jobs share one CmdManager.
+	var stdio iomanager.IOManager
+	if *input == "-" {
+		stdio = iomanager.NewCmdManager(*format == "csv")
+	}
+
+	doneChans := make([]chan bool, len(taxRates))
+	errorChans := make([]chan error, len(taxRates))
+
+	for i, taxRate := range taxRates {
+		doneChans[i] = make(chan bool, 1)
+		errorChans[i] = make(chan error, 1)
 
-	for _, taxrate := range taxrates {
-		obj := prices.NewTaxRate(taxrate)
-		obj.LoadData()
-		obj.Process()
+		io := stdio
+		if io == nil {
+			output := filepath.Join(*outDir, fmt.Sprintf("result_%s.%s", taxRate.Mul(decimal.FromInt(100)), *format))
+			io = iomanager.NewFileManager(*input, output)
+		}
+
+		job := prices.NewTaxRate(io, taxRate)
+		go func(done chan<- bool, errc chan<- error) {
+			if err := job.Process(); err != nil {
+				errc <- err
+				return
+			}
+			done <- true
+		}(doneChans[i], errorChans[i])
+	}
+
+	failed := false
+	for i, taxRate := range taxRates {
+		select {
+		case err := <-errorChans[i]:
+			failed = true
+			fmt.Fprintf(os.Stderr, "tax rate %s:\n%v\n", taxRate, indent(err.Error()))
+		case <-doneChans[i]:
+			if stdio == nil {
+				fmt.Fprintf(os.Stderr, "tax rate %s: done\n", taxRate)
+			}
+		}
+	}
+
+	if failed {
+		os.Exit(1)
 	}
+}
+
+func parseRates(s string) ([]decimal.Decimal, error) {
+	var rates []decimal.Decimal
+	for _, field := range strings.Split(s, ",") {
+		rate, err := decimal.Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("tax rate: %w", err)
+		}
+		if rate.Sign() < 0 {
+			return nil, fmt.Errorf("tax rate %s is negative", rate)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
 
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(s, "\n", "\n  ")
 }
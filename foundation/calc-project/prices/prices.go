@@ -1,56 +1,109 @@
 package prices
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"math"
-	"os"
-	"strconv"
+	"strings"
+
+	"project/calculator/decimal"
+	"project/calculator/iomanager"
 )
 
+var one = decimal.FromInt(1)
+
+// TaxRatePrice applies one tax rate to every price of its input.
 type TaxRatePrice struct {
-	Prices     []float64
-	TaxRate    float64
-	TaxApplied map[string]float64
+	IOManager  iomanager.IOManager `json:"-"`
+	TaxRate    decimal.Decimal     `json:"tax_rate"`
+	Prices     []decimal.Decimal   `json:"prices"`
+	TaxApplied []TaxedPrice        `json:"tax_applied"`
+}
+
+// TaxedPrice is a price and the price with tax, rounded to cents.
+type TaxedPrice struct {
+	Price decimal.Decimal `json:"price"`
+	Total decimal.Decimal `json:"tax_included_price"`
+}
+
+// LineError reports an input line that is not a valid price.
+type LineError struct {
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
 }
 
-func (job *TaxRatePrice) LoadData() {
-	file, err := os.Open("prices.txt")
+func (e *LineError) Unwrap() error { return e.Err }
 
+var ErrNegative = errors.New("price is negative")
+
+// LoadData reads the prices, one per line. Blank lines are skipped; every
+// invalid line is reported, as a *LineError joined in the returned error.
+func (job *TaxRatePrice) LoadData() error {
+	lines, err := job.IOManager.ReadLines()
 	if err != nil {
-		fmt.Println("Cloud not open file")
-		fmt.Println(err)
-		return
+		return err
 	}
 
-	scanner := bufio.NewScanner(file)
-
-	var lines []float64
+	var (
+		prices []decimal.Decimal
+		errs   []error
+	)
+	for i, line := range lines {
+		text := strings.TrimSpace(line)
+		if text == "" {
+			continue
+		}
 
-	for scanner.Scan() {
-		converted, _ := strconv.ParseFloat(scanner.Text(), 64)
-		lines = append(lines, converted)
+		price, err := decimal.Parse(text)
+		if err == nil && price.Sign() < 0 {
+			err = fmt.Errorf("%q: %w", text, ErrNegative)
+		}
+		if err != nil {
+			errs = append(errs, &LineError{Line: i + 1, Text: text, Err: err})
+			continue
+		}
+		prices = append(prices, price)
 	}
 
-	job.Prices = lines
-	file.Close()
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	job.Prices = prices
+	return nil
 }
 
-func (job *TaxRatePrice) Process() {
-	job.LoadData()
+// Process loads the prices, applies the tax rate and writes the result.
+func (job *TaxRatePrice) Process() error {
+	if err := job.LoadData(); err != nil {
+		return err
+	}
 
-	results := make(map[string]float64)
+	factor := one.Add(job.TaxRate)
 
-	for _, price := range job.Prices {
-		results[fmt.Sprintf("%.2f", price)] = math.Round(price * (1 + job.TaxRate))
+	job.TaxApplied = make([]TaxedPrice, len(job.Prices))
+	for i, price := range job.Prices {
+		job.TaxApplied[i] = TaxedPrice{Price: price, Total: price.Mul(factor).Round(2)}
 	}
 
-	fmt.Println(results)
+	return job.IOManager.WriteResult(job)
+}
+
+// CSVRecords writes the result as price,tax_rate,tax_included_price rows.
+func (job *TaxRatePrice) CSVRecords() [][]string {
+	records := [][]string{{"price", "tax_rate", "tax_included_price"}}
+	for _, tp := range job.TaxApplied {
+		records = append(records, []string{tp.Price.String(), job.TaxRate.String(), tp.Total.StringFixed(2)})
+	}
+	return records
 }
 
-func NewTaxRate(taxrate float64) TaxRatePrice {
-	return TaxRatePrice{
-		Prices:  []float64{100, 12, 45},
-		TaxRate: taxrate,
+func NewTaxRate(io iomanager.IOManager, taxRate decimal.Decimal) *TaxRatePrice {
+	return &TaxRatePrice{
+		IOManager: io,
+		TaxRate:   taxRate,
 	}
 }
@@ -0,0 +1,81 @@
+package prices
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"project/calculator/decimal"
+	"project/calculator/iomanager"
+)
+
+func TestProcess(t *testing.T) {
+	io := &iomanager.MemoryManager{Lines: []string{"99", "", " 12.50 ", "1.005"}}
+	job := NewTaxRate(io, decimal.MustParse("0.26"))
+
+	if err := job.Process(); err != nil {
+		t.Fatal(err)
+	}
+
+	results := io.Results()
+	if len(results) != 1 || results[0] != job {
+		t.Fatalf("results = %v, want the job", results)
+	}
+
+	var totals []string
+	for _, tp := range job.TaxApplied {
+		totals = append(totals, tp.Total.StringFixed(2))
+	}
+	// 1.005 * 1.26 = 1.2663
+	want := []string{"124.74", "15.75", "1.27"}
+	if !reflect.DeepEqual(totals, want) {
+		t.Errorf("totals = %v, want %v", totals, want)
+	}
+
+	records := job.CSVRecords()
+	if len(records) != 4 || !reflect.DeepEqual(records[2], []string{"12.5", "0.26", "15.75"}) {
+		t.Errorf("CSV records = %v", records)
+	}
+}
+
+func TestLoadDataReportsLines(t *testing.T) {
+	io := &iomanager.MemoryManager{Lines: []string{"10", "ten", "", "-3", "20"}}
+	job := NewTaxRate(io, decimal.MustParse("0.1"))
+
+	err := job.Process()
+	if err == nil {
+		t.Fatal("invalid lines were accepted")
+	}
+
+	var lines []int
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var le *LineError
+		if !errors.As(e, &le) {
+			t.Fatalf("%v is not a *LineError", e)
+		}
+		lines = append(lines, le.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{2, 4}) {
+		t.Errorf("reported lines %v, want [2 4]", lines)
+	}
+	if !errors.Is(err, decimal.ErrSyntax) || !errors.Is(err, ErrNegative) {
+		t.Errorf("err = %v", err)
+	}
+	if !strings.Contains(err.Error(), "line 4:") {
+		t.Errorf("err = %q", err)
+	}
+
+	if len(io.Results()) != 0 {
+		t.Error("a result was written for invalid input")
+	}
+}
+
+func TestReadError(t *testing.T) {
+	errRead := errors.New("no such file")
+	job := NewTaxRate(&iomanager.MemoryManager{Err: errRead}, decimal.MustParse("0.1"))
+
+	if err := job.Process(); !errors.Is(err, errRead) {
+		t.Errorf("Process() = %v, want %v", err, errRead)
+	}
+}
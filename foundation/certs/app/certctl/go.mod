@@ -0,0 +1,3 @@
+module certctl
+
+go 1.21
@@ -0,0 +1,498 @@
+// certctl bootstraps and maintains the PKI of the mTLS demo.
+//
+//	certctl bootstrap                     root CA, intermediate CA, server and client certificates
+//	certctl init                          root and intermediate CA only
+//	certctl issue server -name api -cn api.local -dns localhost -ip 127.0.0.1
+//	certctl issue client -name billing -cn billing -uri spiffe://mtls-demo.local/ns/billing
+//	certctl renew -name server            new serial, validity and key, same subject and SANs
+//	certctl pin ../certs/server.crt       SPKI SHA-256 pin for the client
+//
+// Every command works on -dir, ../certs by default.
+package main
+
+import (
+	"crypto"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"certctl/pki"
+)
+
+const (
+	defaultDir   = "../certs"
+	rootName     = "ca"
+	interName    = "intermediate-ca"
+	day          = 24 * time.Hour
+	defaultOrg   = "mTLS Demo"
+	usageMessage = `usage: certctl <command> [flags]
+
+commands:
+  bootstrap   create root CA, intermediate CA, server and client certificates
+  init        create root and intermediate CA
+  issue       issue a server or client certificate: certctl issue server|client [flags]
+  renew       re-issue an existing certificate with a new validity period
+  pin         print the SPKI pins of certificate files
+
+run certctl <command> -h for the flags of a command
+`
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usageMessage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "bootstrap":
+		err = bootstrap(args)
+	case "init":
+		err = initCA(args)
+	case "issue":
+		err = issue(args)
+	case "renew":
+		err = renew(args)
+	case "pin":
+		err = pin(args)
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usageMessage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usageMessage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// keyFlags are shared by every command that creates keys.
+type keyFlags struct {
+	keyType string
+	rsaBits int
+}
+
+func (k *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.keyType, "key", string(pki.ECDSA), "key type: ecdsa, ed25519 or rsa")
+	fs.IntVar(&k.rsaBits, "rsa-bits", pki.DefaultRSABits, "RSA key size")
+}
+
+func (k *keyFlags) generate() (crypto.Signer, error) {
+	return pki.GenerateKey(pki.KeyType(k.keyType), k.rsaBits)
+}
+
+type caOptions struct {
+	dir       string
+	org       string
+	rootDays  int
+	interDays int
+	force     bool
+	keys      keyFlags
+}
+
+func (o *caOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.dir, "dir", defaultDir, "output directory")
+	fs.StringVar(&o.org, "org", defaultOrg, "organization of the CAs")
+	fs.IntVar(&o.rootDays, "root-days", 3650, "root CA validity in days")
+	fs.IntVar(&o.interDays, "intermediate-days", 1825, "intermediate CA validity in days")
+	fs.BoolVar(&o.force, "force", false, "overwrite existing files")
+	o.keys.register(fs)
+}
+
+func initCA(args []string) error {
+	var o caOptions
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	o.register(fs)
+	fs.Parse(args)
+
+	_, err := createCAs(o)
+	return err
+}
+
+func createCAs(o caOptions) (*pki.Bundle, error) {
+	if err := checkOverwrite(o.dir, o.force, rootName, interName); err != nil {
+		return nil, err
+	}
+
+	rootKey, err := o.keys.generate()
+	if err != nil {
+		return nil, err
+	}
+	root, err := pki.NewRootCA(pki.Request{
+		CommonName:         "mTLS-Root-CA",
+		Organization:       []string{o.org + " CA"},
+		OrganizationalUnit: []string{"Security"},
+		Validity:           time.Duration(o.rootDays) * day,
+	}, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("create root CA: %w", err)
+	}
+
+	interKey, err := o.keys.generate()
+	if err != nil {
+		return nil, err
+	}
+	inter, err := root.NewIntermediate(pki.Request{
+		CommonName:         "mTLS-Intermediate-CA",
+		Organization:       []string{o.org + " CA"},
+		OrganizationalUnit: []string{"Security"},
+		Validity:           time.Duration(o.interDays) * day,
+	}, interKey)
+	if err != nil {
+		return nil, fmt.Errorf("create intermediate CA: %w", err)
+	}
+
+	if err := root.Save(o.dir, rootName); err != nil {
+		return nil, err
+	}
+	if err := inter.Save(o.dir, interName); err != nil {
+		return nil, err
+	}
+
+	printCert("🏛️  Root CA", filepath.Join(o.dir, rootName+".crt"), root)
+	printCert("🏢 Intermediate CA", filepath.Join(o.dir, interName+".crt"), inter)
+	return inter, nil
+}
+
+type leafOptions struct {
+	dir    string
+	ca     string
+	name   string
+	cn     string
+	org    string
+	ou     string
+	dns    string
+	ips    string
+	uris   string
+	emails string
+	days   int
+	force  bool
+	keys   keyFlags
+}
+
+func (o *leafOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.dir, "dir", defaultDir, "certificate directory")
+	fs.StringVar(&o.ca, "ca", interName, "name of the issuing CA in -dir")
+	fs.StringVar(&o.name, "name", "", "output file name without extension, defaults to the certificate type")
+	fs.StringVar(&o.cn, "cn", "", "common name")
+	fs.StringVar(&o.org, "org", defaultOrg, "organization")
+	fs.StringVar(&o.ou, "ou", "", "comma separated organizational units")
+	fs.StringVar(&o.dns, "dns", "", "comma separated DNS SANs")
+	fs.StringVar(&o.ips, "ip", "", "comma separated IP SANs")
+	fs.StringVar(&o.uris, "uri", "", "comma separated URI SANs, e.g. SPIFFE IDs")
+	fs.StringVar(&o.emails, "email", "", "comma separated email SANs")
+	fs.IntVar(&o.days, "days", 365, "validity in days")
+	fs.BoolVar(&o.force, "force", false, "overwrite existing files")
+	o.keys.register(fs)
+}
+
+func (o *leafOptions) request() (pki.Request, error) {
+	req := pki.Request{
+		CommonName:         o.cn,
+		Organization:       split(o.org),
+		OrganizationalUnit: split(o.ou),
+		DNSNames:           split(o.dns),
+		EmailAddresses:     split(o.emails),
+		Validity:           time.Duration(o.days) * day,
+	}
+	if req.CommonName == "" {
+		return req, fmt.Errorf("-cn is required")
+	}
+
+	for _, s := range split(o.ips) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return req, fmt.Errorf("invalid IP %q", s)
+		}
+		req.IPAddresses = append(req.IPAddresses, ip)
+	}
+	for _, s := range split(o.uris) {
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" {
+			return req, fmt.Errorf("invalid URI %q", s)
+		}
+		req.URIs = append(req.URIs, u)
+	}
+	return req, nil
+}
+
+func issue(args []string) error {
+	if len(args) == 0 || (args[0] != "server" && args[0] != "client") {
+		return fmt.Errorf("usage: certctl issue server|client [flags]")
+	}
+	kind := args[0]
+
+	var o leafOptions
+	fs := flag.NewFlagSet("issue "+kind, flag.ExitOnError)
+	o.register(fs)
+	fs.Parse(args[1:])
+
+	if o.name == "" {
+		o.name = kind
+	}
+
+	ca, err := pki.Load(o.dir, o.ca)
+	if err != nil {
+		return fmt.Errorf("load CA: %w", err)
+	}
+	return issueLeaf(ca, kind, o)
+}
+
+func issueLeaf(ca *pki.Bundle, kind string, o leafOptions) error {
+	if err := checkOverwrite(o.dir, o.force, o.name); err != nil {
+		return err
+	}
+
+	req, err := o.request()
+	if err != nil {
+		return err
+	}
+	key, err := o.keys.generate()
+	if err != nil {
+		return err
+	}
+
+	var leaf *pki.Bundle
+	if kind == "server" {
+		leaf, err = ca.IssueServer(req, key)
+	} else {
+		leaf, err = ca.IssueClient(req, key)
+	}
+	if err != nil {
+		return fmt.Errorf("issue %s certificate: %w", kind, err)
+	}
+
+	if err := leaf.Save(o.dir, o.name); err != nil {
+		return err
+	}
+
+	icon := "👤 Client"
+	if kind == "server" {
+		icon = "🖥️  Server"
+	}
+	printCert(icon, filepath.Join(o.dir, o.name+".crt"), leaf)
+	return nil
+}
+
+func bootstrap(args []string) error {
+	var o caOptions
+	fs := flag.NewFlagSet("bootstrap", flag.ExitOnError)
+	o.register(fs)
+	days := fs.Int("days", 365, "server and client validity in days")
+	fs.Parse(args)
+
+	if err := checkOverwrite(o.dir, o.force, "server", "client"); err != nil {
+		return err
+	}
+
+	inter, err := createCAs(o)
+	if err != nil {
+		return err
+	}
+
+	// Same identities as generate-certs.sh, so policy.yaml and the client
+	// keep working.
+	server := leafOptions{
+		dir: o.dir, name: "server", cn: "mtls-server", org: o.org, ou: "Server",
+		dns: "localhost", ips: "127.0.0.1,::1", days: *days, force: true, keys: o.keys,
+	}
+	if err := issueLeaf(inter, "server", server); err != nil {
+		return err
+	}
+
+	client := leafOptions{
+		dir: o.dir, name: "client", cn: "mtls-client", org: o.org, ou: "Client",
+		days: *days, force: true, keys: o.keys,
+	}
+	if err := issueLeaf(inter, "client", client); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("🎉 PKI ready. Pin for the client:")
+	return pin([]string{filepath.Join(o.dir, "server.crt")})
+}
+
+func renew(args []string) error {
+	fs := flag.NewFlagSet("renew", flag.ExitOnError)
+	dir := fs.String("dir", defaultDir, "certificate directory")
+	name := fs.String("name", "", "certificate to renew, file name without extension")
+	caName := fs.String("ca", "", "name of the issuing CA, found by signature if empty")
+	days := fs.Int("days", 0, "validity in days, the current validity period if 0")
+	reuseKey := fs.Bool("reuse-key", false, "keep the current key, which also keeps the pin; the default for CAs")
+	var keys keyFlags
+	keys.register(fs)
+	fs.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+
+	old, err := pki.Load(*dir, *name)
+	if err != nil {
+		return err
+	}
+
+	issuer, err := findIssuer(*dir, *caName, old)
+	if err != nil {
+		return err
+	}
+
+	// A CA with a new key would no longer verify the certificates it
+	// issued, so CAs keep their key unless -key asks for a new one.
+	if old.Cert.IsCA && !flagSet(fs, "key") {
+		*reuseKey = true
+	}
+
+	key := old.Key
+	if !*reuseKey {
+		// Keep the key type unless -key was given.
+		kt, bits := pki.KeyTypeOf(old.Key.Public())
+		if !flagSet(fs, "key") {
+			keys.keyType = string(kt)
+		}
+		if !flagSet(fs, "rsa-bits") && bits > 0 {
+			keys.rsaBits = bits
+		}
+		if key, err = keys.generate(); err != nil {
+			return err
+		}
+	}
+
+	renewed, err := issuer.Renew(old.Cert, key, time.Duration(*days)*day)
+	if err != nil {
+		return err
+	}
+	if err := renewed.Save(*dir, *name); err != nil {
+		return err
+	}
+
+	printCert("🔄 Renewed", filepath.Join(*dir, *name+".crt"), renewed)
+	if !*reuseKey && !renewed.Cert.IsCA {
+		fmt.Println("⚠️  New key, clients pinning this certificate need the new pin above")
+	}
+	return nil
+}
+
+// findIssuer loads the CA that signed cert, named by caName or found among
+// the intermediate and root CA in dir.
+func findIssuer(dir, caName string, cert *pki.Bundle) (*pki.Bundle, error) {
+	names := []string{interName, rootName}
+	if caName != "" {
+		names = []string{caName}
+	}
+
+	for _, n := range names {
+		ca, err := pki.Load(dir, n)
+		if err != nil {
+			if caName != "" {
+				return nil, err
+			}
+			continue
+		}
+		if cert.Cert.CheckSignatureFrom(ca.Cert) == nil {
+			return ca, nil
+		}
+	}
+
+	// A self-signed root renews itself.
+	if cert.Cert.CheckSignatureFrom(cert.Cert) == nil {
+		return cert, nil
+	}
+	return nil, fmt.Errorf("no CA in %s signed %q, use -ca", dir, cert.Cert.Subject.CommonName)
+}
+
+func pin(args []string) error {
+	fs := flag.NewFlagSet("pin", flag.ExitOnError)
+	chain := fs.Bool("chain", false, "also print pins of the intermediates in each file")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: certctl pin [-chain] file.crt...")
+	}
+
+	for _, file := range fs.Args() {
+		certs, err := pki.LoadCerts(file)
+		if err != nil {
+			return err
+		}
+		if !*chain {
+			certs = certs[:1]
+		}
+		for _, c := range certs {
+			fmt.Printf("%s  %s (%s)\n", pki.Pin(c), file, c.Subject.CommonName)
+		}
+	}
+	return nil
+}
+
+func printCert(label, file string, b *pki.Bundle) {
+	kt, bits := pki.KeyTypeOf(b.Cert.PublicKey)
+	key := string(kt)
+	if bits > 0 {
+		key = fmt.Sprintf("%s %d", kt, bits)
+	}
+
+	fmt.Printf("%s: %s\n", label, file)
+	fmt.Printf("   subject:  %s\n", b.Cert.Subject)
+	fmt.Printf("   key:      %s\n", key)
+	fmt.Printf("   valid:    %s - %s\n", b.Cert.NotBefore.Format(time.DateOnly), b.Cert.NotAfter.Format(time.DateOnly))
+	if sans := sanList(b); sans != "" {
+		fmt.Printf("   SANs:     %s\n", sans)
+	}
+	fmt.Printf("   pin:      %s\n", pki.Pin(b.Cert))
+}
+
+func sanList(b *pki.Bundle) string {
+	var sans []string
+	sans = append(sans, b.Cert.DNSNames...)
+	for _, ip := range b.Cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, u := range b.Cert.URIs {
+		sans = append(sans, u.String())
+	}
+	sans = append(sans, b.Cert.EmailAddresses...)
+	return strings.Join(sans, ", ")
+}
+
+func checkOverwrite(dir string, force bool, names ...string) error {
+	if force {
+		return nil
+	}
+	for _, n := range names {
+		file := filepath.Join(dir, n+".key")
+		if _, err := os.Stat(file); err == nil {
+			return fmt.Errorf("%s already exists, use -force to overwrite", file)
+		}
+	}
+	return nil
+}
+
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func split(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
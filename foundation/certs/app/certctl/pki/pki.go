@@ -0,0 +1,369 @@
+// Package pki creates the certificate authorities and certificates used by
+// the mTLS demo: a root CA, an intermediate CA that signs leaves, and
+// server and client certificates with SANs.
+//
+// Certificates are saved as PEM, a leaf file holds the leaf followed by the
+// intermediates so tls.LoadX509KeyPair sends the whole chain. Keys are saved
+// as PKCS#8.
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type KeyType string
+
+const (
+	ECDSA   KeyType = "ecdsa"
+	Ed25519 KeyType = "ed25519"
+	RSA     KeyType = "rsa"
+)
+
+const DefaultRSABits = 3072
+
+// GenerateKey returns a P-256 ECDSA, Ed25519 or RSA key. bits only applies
+// to RSA, 0 means DefaultRSABits.
+func GenerateKey(kt KeyType, bits int) (crypto.Signer, error) {
+	switch kt {
+	case ECDSA, "":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case Ed25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	case RSA:
+		if bits == 0 {
+			bits = DefaultRSABits
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	default:
+		return nil, fmt.Errorf("unknown key type %q, want ecdsa, ed25519 or rsa", kt)
+	}
+}
+
+// KeyTypeOf returns the type of key.
+func KeyTypeOf(key crypto.PublicKey) (KeyType, int) {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		return ECDSA, 0
+	case ed25519.PublicKey:
+		return Ed25519, 0
+	case *rsa.PublicKey:
+		return RSA, k.N.BitLen()
+	default:
+		return "", 0
+	}
+}
+
+// Request describes the certificate to issue.
+type Request struct {
+	CommonName         string
+	Organization       []string
+	OrganizationalUnit []string
+
+	DNSNames       []string
+	IPAddresses    []net.IP
+	URIs           []*url.URL
+	EmailAddresses []string
+
+	Validity time.Duration
+}
+
+func (r Request) subject() pkix.Name {
+	return pkix.Name{
+		CommonName:         r.CommonName,
+		Organization:       r.Organization,
+		OrganizationalUnit: r.OrganizationalUnit,
+	}
+}
+
+// Bundle is a certificate with its key and the intermediates between it
+// and the root, excluding the root.
+type Bundle struct {
+	Cert  *x509.Certificate
+	Key   crypto.Signer
+	Chain []*x509.Certificate
+}
+
+// NewRootCA creates a self-signed root CA that may sign one level of
+// intermediates.
+func NewRootCA(req Request, key crypto.Signer) (*Bundle, error) {
+	tmpl := caTemplate(req)
+	tmpl.MaxPathLen = 1
+
+	return sign(tmpl, tmpl, key.Public(), key, key, nil)
+}
+
+// NewIntermediate creates a CA signed by ca that can only sign leaves.
+func (ca *Bundle) NewIntermediate(req Request, key crypto.Signer) (*Bundle, error) {
+	if ca.Cert.MaxPathLenZero {
+		return nil, fmt.Errorf("%q may only sign leaf certificates", ca.Cert.Subject.CommonName)
+	}
+
+	tmpl := caTemplate(req)
+	tmpl.MaxPathLen = 0
+	tmpl.MaxPathLenZero = true
+
+	return ca.issue(tmpl, key)
+}
+
+// IssueServer issues a certificate for TLS servers. The common name is
+// added to the DNS names when it is not there already, as clients only
+// check SANs.
+func (ca *Bundle) IssueServer(req Request, key crypto.Signer) (*Bundle, error) {
+	if req.CommonName != "" && !contains(req.DNSNames, req.CommonName) && net.ParseIP(req.CommonName) == nil {
+		req.DNSNames = append([]string{req.CommonName}, req.DNSNames...)
+	}
+	return ca.issue(leafTemplate(req, key, x509.ExtKeyUsageServerAuth), key)
+}
+
+// IssueClient issues a certificate for mTLS clients.
+func (ca *Bundle) IssueClient(req Request, key crypto.Signer) (*Bundle, error) {
+	return ca.issue(leafTemplate(req, key, x509.ExtKeyUsageClientAuth), key)
+}
+
+// Renew issues a copy of old with a new serial and validity period, signed
+// by ca. Subject, SANs and usages are kept. The validity period is the one
+// of old unless validity is set.
+func (ca *Bundle) Renew(old *x509.Certificate, key crypto.Signer, validity time.Duration) (*Bundle, error) {
+	if validity <= 0 {
+		validity = old.NotAfter.Sub(old.NotBefore)
+	}
+
+	tmpl := &x509.Certificate{
+		Subject:               old.Subject,
+		DNSNames:              old.DNSNames,
+		IPAddresses:           old.IPAddresses,
+		URIs:                  old.URIs,
+		EmailAddresses:        old.EmailAddresses,
+		KeyUsage:              old.KeyUsage,
+		ExtKeyUsage:           old.ExtKeyUsage,
+		BasicConstraintsValid: old.BasicConstraintsValid,
+		IsCA:                  old.IsCA,
+		MaxPathLen:            old.MaxPathLen,
+		MaxPathLenZero:        old.MaxPathLenZero,
+		NotBefore:             time.Now().Add(-5 * time.Minute),
+		NotAfter:              time.Now().Add(validity),
+	}
+	if !old.IsCA {
+		tmpl.KeyUsage = leafKeyUsage(key)
+	}
+
+	// A root renews itself.
+	if ca.Cert.Equal(old) && isSelfSigned(old) {
+		return sign(tmpl, tmpl, key.Public(), key, key, nil)
+	}
+	return ca.issue(tmpl, key)
+}
+
+func (ca *Bundle) issue(tmpl *x509.Certificate, key crypto.Signer) (*Bundle, error) {
+	if !ca.Cert.IsCA {
+		return nil, fmt.Errorf("%q is not a CA", ca.Cert.Subject.CommonName)
+	}
+	if tmpl.NotAfter.After(ca.Cert.NotAfter) {
+		return nil, fmt.Errorf("certificate would outlive its issuer %q (expires %s), renew the CA first",
+			ca.Cert.Subject.CommonName, ca.Cert.NotAfter.Format(time.RFC3339))
+	}
+
+	var chain []*x509.Certificate
+	if !isSelfSigned(ca.Cert) {
+		chain = append([]*x509.Certificate{ca.Cert}, ca.Chain...)
+	}
+	return sign(tmpl, ca.Cert, key.Public(), ca.Key, key, chain)
+}
+
+func sign(tmpl, parent *x509.Certificate, pub crypto.PublicKey, signer, key crypto.Signer, chain []*x509.Certificate) (*Bundle, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	tmpl.SerialNumber = serial
+
+	// CreateCertificate derives the SubjectKeyId of CAs from their key and
+	// sets the AuthorityKeyId of children from the parent.
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{Cert: cert, Key: key, Chain: chain}, nil
+}
+
+func caTemplate(req Request) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               req.subject(),
+		NotBefore:             time.Now().Add(-5 * time.Minute),
+		NotAfter:              time.Now().Add(req.Validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+}
+
+func leafTemplate(req Request, key crypto.Signer, usage x509.ExtKeyUsage) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:               req.subject(),
+		DNSNames:              req.DNSNames,
+		IPAddresses:           req.IPAddresses,
+		URIs:                  req.URIs,
+		EmailAddresses:        req.EmailAddresses,
+		NotBefore:             time.Now().Add(-5 * time.Minute),
+		NotAfter:              time.Now().Add(req.Validity),
+		KeyUsage:              leafKeyUsage(key),
+		ExtKeyUsage:           []x509.ExtKeyUsage{usage},
+		BasicConstraintsValid: true,
+	}
+}
+
+// leafKeyUsage is digital signature, plus key encipherment for RSA keys
+// which TLS 1.2 RSA key exchange needs.
+func leafKeyUsage(key crypto.Signer) x509.KeyUsage {
+	if _, ok := key.Public().(*rsa.PublicKey); ok {
+		return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+	return x509.KeyUsageDigitalSignature
+}
+
+// Pin returns the hex SHA-256 of the certificate's SubjectPublicKeyInfo,
+// the format the client's pinning check compares against.
+func Pin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// Save writes dir/name.crt with the certificate and its chain and
+// dir/name.key. Keys are written with 0600 permissions.
+func (b *Bundle) Save(dir, name string) error {
+	var certPEM []byte
+	for _, c := range append([]*x509.Certificate{b.Cert}, b.Chain...) {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(b.Key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, name+".key"), keyPEM, 0o600); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, name+".crt"), certPEM, 0o644)
+}
+
+// Load reads dir/name.crt and dir/name.key as written by Save.
+func Load(dir, name string) (*Bundle, error) {
+	certs, err := LoadCerts(filepath.Join(dir, name+".crt"))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, name+".key"))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s.key: no PEM data", name)
+	}
+	parsed, err := parseKey(block)
+	if err != nil {
+		return nil, fmt.Errorf("%s.key: %w", name, err)
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s.key: unsupported key type %T", name, parsed)
+	}
+
+	return &Bundle{Cert: certs[0], Key: key, Chain: certs[1:]}, nil
+}
+
+// LoadCerts reads every certificate of a PEM file.
+func LoadCerts(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s: no certificates found", file)
+	}
+	return certs, nil
+}
+
+func parseKey(block *pem.Block) (any, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+}
+
+// writeFile replaces file through a rename, so a server watching it never
+// reads a half written certificate.
+func writeFile(file string, data []byte, perm os.FileMode) error {
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return cert.CheckSignatureFrom(cert) == nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
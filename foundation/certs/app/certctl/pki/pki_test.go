@@ -0,0 +1,203 @@
+package pki
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newPKI(t *testing.T, kt KeyType) (root, inter *Bundle) {
+	t.Helper()
+
+	gen := func() Bundle {
+		key, err := GenerateKey(kt, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Bundle{Key: key}
+	}
+
+	rk := gen()
+	root, err := NewRootCA(Request{CommonName: "root", Validity: 10 * 24 * time.Hour}, rk.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ik := gen()
+	inter, err = root.NewIntermediate(Request{CommonName: "intermediate", Validity: 5 * 24 * time.Hour}, ik.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, inter
+}
+
+func verify(t *testing.T, root *Bundle, leaf *Bundle, usage x509.ExtKeyUsage) {
+	t.Helper()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.Cert)
+	inters := x509.NewCertPool()
+	for _, c := range leaf.Chain {
+		inters.AddCert(c)
+	}
+
+	if _, err := leaf.Cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: inters,
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}); err != nil {
+		t.Fatalf("chain of %q does not verify: %v", leaf.Cert.Subject.CommonName, err)
+	}
+}
+
+func TestIssueWithEveryKeyType(t *testing.T) {
+	for _, kt := range []KeyType{ECDSA, Ed25519, RSA} {
+		t.Run(string(kt), func(t *testing.T) {
+			root, inter := newPKI(t, kt)
+
+			key, _ := GenerateKey(kt, 2048)
+			server, err := inter.IssueServer(Request{
+				CommonName:  "mtls-server",
+				DNSNames:    []string{"localhost"},
+				IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+				Validity:    24 * time.Hour,
+			}, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify(t, root, server, x509.ExtKeyUsageServerAuth)
+
+			if err := server.Cert.VerifyHostname("mtls-server"); err != nil {
+				t.Errorf("expected the common name as a DNS SAN: %v", err)
+			}
+			if err := server.Cert.VerifyHostname("127.0.0.1"); err != nil {
+				t.Error(err)
+			}
+
+			spiffe, _ := url.Parse("spiffe://mtls-demo.local/ns/billing")
+			key, _ = GenerateKey(kt, 2048)
+			client, err := inter.IssueClient(Request{CommonName: "billing", URIs: []*url.URL{spiffe}, Validity: 24 * time.Hour}, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verify(t, root, client, x509.ExtKeyUsageClientAuth)
+
+			if got := client.Cert.URIs; len(got) != 1 || got[0].String() != spiffe.String() {
+				t.Errorf("expected SPIFFE URI SAN, got %v", got)
+			}
+		})
+	}
+}
+
+func TestIntermediateCannotSignCAs(t *testing.T) {
+	_, inter := newPKI(t, ECDSA)
+
+	key, _ := GenerateKey(ECDSA, 0)
+	if _, err := inter.NewIntermediate(Request{CommonName: "sub", Validity: time.Hour}, key); err == nil {
+		t.Error("expected the intermediate to refuse signing another CA")
+	}
+}
+
+func TestLeafCannotOutliveIssuer(t *testing.T) {
+	_, inter := newPKI(t, ECDSA)
+
+	key, _ := GenerateKey(ECDSA, 0)
+	if _, err := inter.IssueServer(Request{CommonName: "server", Validity: 365 * 24 * time.Hour}, key); err == nil {
+		t.Error("expected a leaf valid past its intermediate to be refused")
+	}
+}
+
+func TestSaveLoadAndTLS(t *testing.T) {
+	dir := t.TempDir()
+	_, inter := newPKI(t, Ed25519)
+
+	key, _ := GenerateKey(Ed25519, 0)
+	server, err := inter.IssueServer(Request{CommonName: "server", Validity: time.Hour}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.Save(dir, "server"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(dir, "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Cert.Equal(server.Cert) || len(loaded.Chain) != 1 {
+		t.Errorf("expected certificate and intermediate back, got %d chain certs", len(loaded.Chain))
+	}
+
+	// The files are what the server loads.
+	pair, err := tls.LoadX509KeyPair(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pair.Certificate) != 2 {
+		t.Errorf("expected leaf and intermediate in the key pair, got %d", len(pair.Certificate))
+	}
+}
+
+func TestRenewKeepsIdentity(t *testing.T) {
+	root, inter := newPKI(t, ECDSA)
+
+	key, _ := GenerateKey(ECDSA, 0)
+	old, err := inter.IssueServer(Request{
+		CommonName:         "server",
+		OrganizationalUnit: []string{"Server"},
+		DNSNames:           []string{"localhost"},
+		Validity:           time.Hour,
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey, _ := GenerateKey(ECDSA, 0)
+	renewed, err := inter.Renew(old.Cert, newKey, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verify(t, root, renewed, x509.ExtKeyUsageServerAuth)
+
+	if renewed.Cert.SerialNumber.Cmp(old.Cert.SerialNumber) == 0 {
+		t.Error("expected a new serial")
+	}
+	if renewed.Cert.Subject.String() != old.Cert.Subject.String() || len(renewed.Cert.DNSNames) != len(old.Cert.DNSNames) {
+		t.Errorf("expected subject and SANs to be kept, got %s %v", renewed.Cert.Subject, renewed.Cert.DNSNames)
+	}
+	if Pin(renewed.Cert) == Pin(old.Cert) {
+		t.Error("expected a new key to change the pin")
+	}
+
+	renewedRoot, err := root.Renew(root.Cert, root.Key, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renewedRoot.Cert.CheckSignatureFrom(renewedRoot.Cert); err != nil {
+		t.Errorf("expected renewed root to be self-signed: %v", err)
+	}
+	if err := inter.Cert.CheckSignatureFrom(renewedRoot.Cert); err != nil {
+		t.Errorf("expected renewed root with the same key to still verify the intermediate: %v", err)
+	}
+}
+
+func TestPinMatchesClientCheck(t *testing.T) {
+	_, inter := newPKI(t, RSA)
+
+	// The client hashes the re-marshalled public key.
+	der, err := x509.MarshalPKIXPublicKey(inter.Cert.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(der)
+
+	if got, want := Pin(inter.Cert), hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("Pin = %s, want %s", got, want)
+	}
+}
@@ -1,10 +1,9 @@
 package main
 
 import (
-	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/hex"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,16 +11,33 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"client/pinning"
 )
 
 const (
 	serverAddress = "localhost:8443"
-	// This will be the expected server certificate's public key hash
-	// Generated from: openssl x509 -in certs/server.crt -pubkey -noout | openssl pkey -pubin -outform DER | openssl dgst -sha256 -hex
-	expectedServerPubKeyHash = "ccce7dfa888af9f9fdb088476fe5c9c96d14d49ab1d5276f4f17977f0914aeef"
+	// Pins of the server chain, print new ones with: cd ../certctl && go run . pin -chain ../certs/server.crt
+	pinsFile = "pins.json"
 )
 
 func main() {
+	pinsPath := flag.String("pins", pinsFile, "JSON file with the server certificate pins")
+	reportOnly := flag.Bool("pin-report-only", false, "log pin violations instead of failing the connection")
+	flag.Parse()
+
+	// Load the certificate pins
+	pinConfig, err := pinning.Load(*pinsPath)
+	if err != nil {
+		log.Fatalf("Failed to load certificate pins: %v", err)
+	}
+	pinConfig.ReportOnly = pinConfig.ReportOnly || *reportOnly
+
+	pinVerifier, err := pinning.New(*pinConfig)
+	if err != nil {
+		log.Fatalf("Invalid certificate pins: %v", err)
+	}
+
 	// Load client certificate and key
 	clientCert, err := tls.LoadX509KeyPair("../certs/client.crt", "../certs/client.key")
 	if err != nil {
@@ -46,9 +62,7 @@ func main() {
 		RootCAs:      caCertPool,                    // Custom CA for server verification
 		ServerName:   "mtls-server",                 // Must match server certificate CN
 		// Custom certificate verification with pinning
-		VerifyPeerCertificate: func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-			return verifyServerCertificateWithPinning(rawCerts, verifiedChains)
-		},
+		VerifyPeerCertificate: pinVerifier.VerifyPeerCertificate,
 		// We still want to verify the certificate chain normally
 		InsecureSkipVerify: false,
 	}
@@ -72,46 +86,6 @@ func main() {
 	testPostRequest(client)
 }
 
-// verifyServerCertificateWithPinning implements certificate pinning
-func verifyServerCertificateWithPinning(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-	if len(rawCerts) == 0 {
-		return fmt.Errorf("no certificates provided")
-	}
-
-	// Parse the server certificate
-	cert, err := x509.ParseCertificate(rawCerts[0])
-	if err != nil {
-		return fmt.Errorf("failed to parse server certificate: %v", err)
-	}
-
-	// Calculate the SHA-256 hash of the server's public key
-	pubKeyDER, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
-	if err != nil {
-		return fmt.Errorf("failed to marshal server public key: %v", err)
-	}
-
-	hash := sha256.Sum256(pubKeyDER)
-	actualHash := hex.EncodeToString(hash[:])
-
-	fmt.Printf("🔑 Server certificate public key hash: %s\n", actualHash)
-
-	// For demonstration, we'll print the hash so you can update the constant
-	// In production, you would have the expected hash hardcoded
-	if expectedServerPubKeyHash == "REPLACE_WITH_ACTUAL_HASH" {
-		fmt.Printf("⚠️  Update expectedServerPubKeyHash constant with: %s\n", actualHash)
-		fmt.Println("✅ Certificate pinning check passed (development mode)")
-		return nil
-	}
-
-	// Verify the pinned public key hash
-	if actualHash != expectedServerPubKeyHash {
-		return fmt.Errorf("🚨 CERTIFICATE PINNING FAILED: expected %s, got %s", expectedServerPubKeyHash, actualHash)
-	}
-
-	fmt.Println("✅ Certificate pinning verification passed!")
-	return nil
-}
-
 func testGetRequest(client *http.Client) {
 	fmt.Println("\n📥 Testing GET request...")
 
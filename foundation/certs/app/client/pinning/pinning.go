@@ -0,0 +1,240 @@
+// Package pinning checks the server's certificate chain against a set of
+// SPKI pins loaded from a config file, so server keys can rotate without a
+// new client build.
+//
+// A pin is the hex SHA-256 of a certificate's SubjectPublicKeyInfo, as
+// printed by `certctl pin`. It may be restricted to the leaf, an
+// intermediate or the CA of the verified chain, and may expire. Pins marked
+// as backup are accepted the same way but logged, so a rotation onto a
+// backup key is noticed. Once every pin has expired, handshakes fail unless
+// fail_open_when_expired is set.
+//
+//	{
+//	  "report_only": false,
+//	  "pins": [
+//	    {"sha256": "ccce7d...", "level": "leaf", "comment": "server key 2025"},
+//	    {"sha256": "9a41f0...", "level": "leaf", "backup": true, "expires": "2027-01-01"},
+//	    {"sha256": "d37c45...", "level": "ca"}
+//	  ]
+//	}
+package pinning
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+type Level string
+
+const (
+	// Any matches a certificate anywhere in the chain.
+	Any          Level = ""
+	Leaf         Level = "leaf"
+	Intermediate Level = "intermediate"
+	CA           Level = "ca"
+)
+
+type Pin struct {
+	SHA256 string `json:"sha256"`
+	Level  Level  `json:"level,omitempty"`
+	Backup bool   `json:"backup,omitempty"`
+	// Expires is a date (2006-01-02) or RFC 3339 time after which the pin
+	// is ignored.
+	Expires string `json:"expires,omitempty"`
+	Comment string `json:"comment,omitempty"`
+
+	expires time.Time
+}
+
+type Config struct {
+	// ReportOnly logs violations instead of failing the handshake.
+	ReportOnly bool `json:"report_only"`
+	// FailOpenWhenExpired turns pinning off once every pin has expired.
+	// By default such a config fails every handshake, so a forgotten
+	// rotation cannot silently remove the pinning.
+	FailOpenWhenExpired bool  `json:"fail_open_when_expired,omitempty"`
+	Pins                []Pin `json:"pins"`
+}
+
+// Load reads and validates a JSON pin config.
+func Load(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+	if err := cfg.parse(); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) parse() error {
+	if len(c.Pins) == 0 {
+		return errors.New("no pins configured")
+	}
+
+	for i := range c.Pins {
+		p := &c.Pins[i]
+		p.SHA256 = strings.ToLower(strings.ReplaceAll(p.SHA256, ":", ""))
+		if raw, err := hex.DecodeString(p.SHA256); err != nil || len(raw) != sha256.Size {
+			return fmt.Errorf("pin %d: %q is not a hex SHA-256", i+1, p.SHA256)
+		}
+
+		switch p.Level {
+		case Any, Leaf, Intermediate, CA:
+		default:
+			return fmt.Errorf("pin %d: unknown level %q, want leaf, intermediate or ca", i+1, p.Level)
+		}
+
+		if p.Expires != "" {
+			t, err := parseTime(p.Expires)
+			if err != nil {
+				return fmt.Errorf("pin %d: %w", i+1, err)
+			}
+			p.expires = t
+		}
+	}
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q, want 2006-01-02 or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// Hash returns the pin of cert.
+func Hash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// Verifier checks verified chains against a Config.
+type Verifier struct {
+	cfg Config
+	now func() time.Time
+}
+
+// New returns a Verifier for cfg. It warns when no backup pin is
+// configured, since losing the pinned key would then lock clients out.
+func New(cfg Config) (*Verifier, error) {
+	if err := cfg.parse(); err != nil {
+		return nil, err
+	}
+
+	v := &Verifier{cfg: cfg, now: time.Now}
+
+	active := v.active()
+	backup := false
+	for _, p := range active {
+		backup = backup || p.Backup
+	}
+	if len(active) == 0 {
+		log.Println("⚠️  Every certificate pin has expired, update the pin config")
+	} else if !backup {
+		log.Println("⚠️  No backup pin configured, a lost server key will lock clients out")
+	}
+	return v, nil
+}
+
+// active returns the pins that have not expired.
+func (v *Verifier) active() []Pin {
+	now := v.now()
+
+	var pins []Pin
+	for _, p := range v.cfg.Pins {
+		if p.expires.IsZero() || now.Before(p.expires) {
+			pins = append(pins, p)
+		}
+	}
+	return pins
+}
+
+// VerifyPeerCertificate implements tls.Config.VerifyPeerCertificate. The
+// standard chain verification must stay enabled, pins are only matched
+// against verified chains.
+func (v *Verifier) VerifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	pins := v.active()
+	if len(pins) == 0 {
+		if v.cfg.FailOpenWhenExpired {
+			log.Println("⚠️  All certificate pins have expired, pinning is disabled")
+			return nil
+		}
+		return v.violation(errors.New("🚨 CERTIFICATE PINNING FAILED: every pin has expired"))
+	}
+
+	if len(verifiedChains) == 0 {
+		return v.violation(errors.New("🚨 CERTIFICATE PINNING FAILED: no verified chain, chain verification must be enabled"))
+	}
+
+	for _, chain := range verifiedChains {
+		for i, cert := range chain {
+			if p, ok := match(pins, Hash(cert), levelOf(i, len(chain))); ok {
+				if p.Backup {
+					log.Printf("⚠️  Certificate pinning passed with backup pin %s (%s)", p.SHA256, p.Comment)
+				} else {
+					fmt.Println("✅ Certificate pinning verification passed!")
+				}
+				return nil
+			}
+		}
+	}
+
+	return v.violation(fmt.Errorf("🚨 CERTIFICATE PINNING FAILED: no pin matches chain %s", describe(verifiedChains[0])))
+}
+
+func (v *Verifier) violation(err error) error {
+	if v.cfg.ReportOnly {
+		log.Printf("%v (report-only, connection allowed)", err)
+		return nil
+	}
+	return err
+}
+
+func match(pins []Pin, hash string, level Level) (Pin, bool) {
+	for _, p := range pins {
+		if p.SHA256 == hash && (p.Level == Any || p.Level == level) {
+			return p, true
+		}
+	}
+	return Pin{}, false
+}
+
+// levelOf returns the level of the i-th certificate of a verified chain,
+// which always ends in the trusted root.
+func levelOf(i, n int) Level {
+	switch {
+	case i == 0:
+		return Leaf
+	case i == n-1:
+		return CA
+	default:
+		return Intermediate
+	}
+}
+
+func describe(chain []*x509.Certificate) string {
+	parts := make([]string, len(chain))
+	for i, cert := range chain {
+		parts[i] = fmt.Sprintf("%s %q %s", levelOf(i, len(chain)), cert.Subject.CommonName, Hash(cert))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
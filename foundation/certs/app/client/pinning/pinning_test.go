@@ -0,0 +1,171 @@
+package pinning
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newChain returns a verified chain leaf, intermediate, root.
+func newChain(t *testing.T) []*x509.Certificate {
+	t.Helper()
+
+	issue := func(cn string, serial int64, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tmpl := &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: cn},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			BasicConstraintsValid: true,
+			IsCA:                  isCA,
+			KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		}
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+
+	root, rootKey := issue("root", 1, true, nil, nil)
+	inter, interKey := issue("intermediate", 2, true, root, rootKey)
+	leaf, _ := issue("server", 3, false, inter, interKey)
+	return []*x509.Certificate{leaf, inter, root}
+}
+
+func verifier(t *testing.T, cfg Config) *Verifier {
+	t.Helper()
+	v, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestPinLevels(t *testing.T) {
+	chain := newChain(t)
+	leaf, inter, root := Hash(chain[0]), Hash(chain[1]), Hash(chain[2])
+	other := Hash(newChain(t)[0])
+
+	tests := []struct {
+		name string
+		pin  Pin
+		ok   bool
+	}{
+		{"leaf", Pin{SHA256: leaf, Level: Leaf}, true},
+		{"intermediate", Pin{SHA256: inter, Level: Intermediate}, true},
+		{"ca", Pin{SHA256: root, Level: CA}, true},
+		{"any level", Pin{SHA256: inter}, true},
+		{"wrong level", Pin{SHA256: leaf, Level: CA}, false},
+		{"unknown key", Pin{SHA256: other}, false},
+	}
+
+	for _, tt := range tests {
+		v := verifier(t, Config{Pins: []Pin{tt.pin}})
+		err := v.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got err %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestBackupPinAllowsRotation(t *testing.T) {
+	current := newChain(t)
+	rotated := newChain(t)
+
+	v := verifier(t, Config{Pins: []Pin{
+		{SHA256: Hash(current[0]), Level: Leaf},
+		{SHA256: Hash(rotated[0]), Level: Leaf, Backup: true},
+	}})
+
+	for _, chain := range [][]*x509.Certificate{current, rotated} {
+		if err := v.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}); err != nil {
+			t.Errorf("expected primary and backup pins to pass, got %v", err)
+		}
+	}
+}
+
+func TestExpiredPinsAreIgnored(t *testing.T) {
+	chain := newChain(t)
+	other := newChain(t)
+
+	v := verifier(t, Config{Pins: []Pin{
+		{SHA256: Hash(chain[0]), Expires: "2020-01-01"},
+		{SHA256: Hash(other[0])},
+	}})
+	if err := v.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}); err == nil {
+		t.Error("expected an expired pin not to match")
+	}
+
+	// With every pin expired, handshakes fail unless failing open is
+	// asked for.
+	expired := []Pin{{SHA256: Hash(other[0]), Expires: "2020-01-01T00:00:00Z"}}
+	v = verifier(t, Config{Pins: expired})
+	if err := v.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}); err == nil {
+		t.Error("expected all-expired pins to fail closed")
+	}
+
+	v = verifier(t, Config{FailOpenWhenExpired: true, Pins: expired})
+	if err := v.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}); err != nil {
+		t.Errorf("expected fail_open_when_expired to disable pinning, got %v", err)
+	}
+}
+
+func TestReportOnly(t *testing.T) {
+	chain := newChain(t)
+
+	v := verifier(t, Config{ReportOnly: true, Pins: []Pin{{SHA256: Hash(newChain(t)[0])}}})
+	if err := v.VerifyPeerCertificate(nil, [][]*x509.Certificate{chain}); err != nil {
+		t.Errorf("expected report-only to allow the connection, got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		file := filepath.Join(dir, "pins.json")
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	pin := Hash(newChain(t)[0])
+
+	cfg, err := Load(write(`{"report_only": true, "pins": [{"sha256": "` + pin + `", "level": "leaf", "expires": "2099-12-31"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.ReportOnly || len(cfg.Pins) != 1 || cfg.Pins[0].expires.Year() != 2099 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	for _, bad := range []string{
+		`{"pins": []}`,
+		`{"pins": [{"sha256": "abc"}]}`,
+		`{"pins": [{"sha256": "` + pin + `", "level": "root"}]}`,
+		`{"pins": [{"sha256": "` + pin + `", "expires": "next year"}]}`,
+	} {
+		if _, err := Load(write(bad)); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
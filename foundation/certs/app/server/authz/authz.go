@@ -0,0 +1,94 @@
+package authz
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// AuditEntry is one line of the audit log.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Decision   string    `json:"decision"`
+	Reason     string    `json:"reason"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	RemoteAddr string    `json:"remote_addr"`
+	Client     *Identity `json:"client,omitempty"`
+}
+
+// AuditLog writes entries as JSON lines. It is safe for concurrent use.
+type AuditLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{enc: json.NewEncoder(w)}
+}
+
+func (a *AuditLog) Write(e AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enc.Encode(e)
+}
+
+// Authorizer enforces a Policy on HTTP requests.
+type Authorizer struct {
+	mu     sync.RWMutex
+	policy *Policy
+
+	audit *AuditLog
+}
+
+func New(policy *Policy, audit *AuditLog) *Authorizer {
+	return &Authorizer{policy: policy, audit: audit}
+}
+
+// SetPolicy swaps the policy for new requests.
+func (a *Authorizer) SetPolicy(p *Policy) {
+	a.mu.Lock()
+	a.policy = p
+	a.mu.Unlock()
+}
+
+// Middleware lets a request through only if the policy allows the client
+// certificate's identity to call its method and path. Denied requests get
+// 403 and an audit log entry.
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			a.deny(w, r, nil, "no client certificate")
+			return
+		}
+
+		id := IdentityOf(r.TLS.PeerCertificates[0])
+
+		a.mu.RLock()
+		policy := a.policy
+		a.mu.RUnlock()
+
+		if _, ok := policy.Allowed(id, r.Method, r.URL.Path); !ok {
+			a.deny(w, r, &id, "no rule allows this route")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Authorizer) deny(w http.ResponseWriter, r *http.Request, id *Identity, reason string) {
+	if a.audit != nil {
+		a.audit.Write(AuditEntry{
+			Time:       time.Now().UTC(),
+			Decision:   "deny",
+			Reason:     reason,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			RemoteAddr: r.RemoteAddr,
+			Client:     id,
+		})
+	}
+	http.Error(w, "Forbidden", http.StatusForbidden)
+}
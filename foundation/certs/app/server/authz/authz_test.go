@@ -0,0 +1,160 @@
+package authz
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"server/internal/testpki"
+)
+
+const policyYAML = `
+rules:
+  - name: ops
+    match:
+      ou: [ops]
+    allow:
+      - path: /*
+  - name: billing
+    match:
+      uri: ["spiffe://example.org/ns/billing/*"]
+    allow:
+      - path: /echo
+        methods: [POST]
+  - name: monitoring
+    match:
+      cn: [prometheus]
+    allow:
+      - path: /info
+        methods: [GET]
+`
+
+func writePolicy(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func clientCert(t *testing.T, cn string, ous []string, uris ...string) *x509.Certificate {
+	ca := testpki.NewCA(t, "ca")
+	return ca.Client(t, cn, func(c *x509.Certificate) {
+		c.Subject.OrganizationalUnit = ous
+		for _, raw := range uris {
+			u, err := url.Parse(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.URIs = append(c.URIs, u)
+		}
+	}).Cert
+}
+
+func TestPolicyAllowed(t *testing.T) {
+	p, err := LoadPolicy(writePolicy(t, "policy.yaml", policyYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	operator := IdentityOf(clientCert(t, "alice", []string{"ops"}))
+	billing := IdentityOf(clientCert(t, "billing", nil, "spiffe://example.org/ns/billing/sa/api"))
+	prometheus := IdentityOf(clientCert(t, "prometheus", nil))
+	stranger := IdentityOf(clientCert(t, "stranger", []string{"sales"}))
+
+	tests := []struct {
+		id     Identity
+		method string
+		path   string
+		want   bool
+	}{
+		{operator, http.MethodGet, "/info", true},
+		{operator, http.MethodPost, "/echo", true},
+		{billing, http.MethodPost, "/echo", true},
+		{billing, http.MethodGet, "/echo", false},
+		{billing, http.MethodGet, "/info", false},
+		{prometheus, http.MethodGet, "/info", true},
+		{prometheus, http.MethodGet, "/hello", false},
+		{stranger, http.MethodGet, "/hello", false},
+	}
+	for _, tt := range tests {
+		if _, got := p.Allowed(tt.id, tt.method, tt.path); got != tt.want {
+			t.Errorf("%s %s %s: allowed = %v, want %v", tt.id.CN, tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadPolicyJSON(t *testing.T) {
+	file := writePolicy(t, "policy.json", `{"rules": [{"name": "all", "match": {"cn": ["*"]}, "allow": [{"path": "/hello", "methods": ["GET"]}]}]}`)
+
+	p, err := LoadPolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := p.Allowed(Identity{CN: "anyone"}, http.MethodGet, "/hello"); !ok {
+		t.Error("expected wildcard CN to be allowed")
+	}
+}
+
+func TestLoadPolicyRejectsMistakes(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": "rules:\n  - name: x\n    match: {cn: [a]}\n    allow: [{path: /}]\n    alow: []\n",
+		"empty match":   "rules:\n  - name: x\n    allow: [{path: /}]\n",
+		"no routes":     "rules:\n  - name: x\n    match: {cn: [a]}\n",
+		"relative path": "rules:\n  - name: x\n    match: {cn: [a]}\n    allow: [{path: info}]\n",
+	}
+	for name, content := range tests {
+		if _, err := LoadPolicy(writePolicy(t, "policy.yaml", content)); err == nil {
+			t.Errorf("%s: expected policy to be rejected", name)
+		}
+	}
+}
+
+func TestMiddlewareDeniesAndAudits(t *testing.T) {
+	p, err := LoadPolicy(writePolicy(t, "policy.yaml", policyYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var audit bytes.Buffer
+	h := New(p, NewAuditLog(&audit)).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	request := func(cert *x509.Certificate, method, path string) int {
+		r := httptest.NewRequest(method, path, nil)
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	prometheus := clientCert(t, "prometheus", nil)
+
+	if code := request(prometheus, http.MethodGet, "/info"); code != http.StatusOK {
+		t.Errorf("expected allowed request to pass, got %d", code)
+	}
+	if audit.Len() != 0 {
+		t.Errorf("expected nothing audited for allowed requests, got %s", audit.String())
+	}
+
+	if code := request(prometheus, http.MethodPost, "/echo"); code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", code)
+	}
+
+	var entry AuditEntry
+	if err := json.Unmarshal(audit.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid audit entry %q: %v", audit.String(), err)
+	}
+	if entry.Decision != "deny" || entry.Path != "/echo" || entry.Client == nil || entry.Client.CN != "prometheus" {
+		t.Errorf("unexpected audit entry %+v", entry)
+	}
+}
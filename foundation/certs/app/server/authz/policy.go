@@ -0,0 +1,196 @@
+// Package authz decides which routes an authenticated mTLS client may call,
+// based on the identity in its certificate.
+//
+// A policy file lists rules. A rule matches a client by common name,
+// organizational unit or SAN URI (e.g. a SPIFFE ID) and allows a set of
+// routes and methods:
+//
+//	rules:
+//	  - name: ops
+//	    match:
+//	      ou: [ops]
+//	    allow:
+//	      - path: /*
+//	  - name: billing
+//	    match:
+//	      uri: ["spiffe://example.org/ns/billing/*"]
+//	    allow:
+//	      - path: /echo
+//	        methods: [POST]
+//
+// Requests not allowed by any rule are denied with 403 and recorded in the
+// audit log.
+package authz
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Policy struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+type Rule struct {
+	Name  string  `json:"name" yaml:"name"`
+	Match Match   `json:"match" yaml:"match"`
+	Allow []Route `json:"allow" yaml:"allow"`
+}
+
+// Match selects clients. Every non-empty field must match, a field matches
+// when any of its patterns does. Patterns are exact, "*" for anything, or
+// end in "*" to match a prefix.
+type Match struct {
+	CN  []string `json:"cn,omitempty" yaml:"cn,omitempty"`
+	OU  []string `json:"ou,omitempty" yaml:"ou,omitempty"`
+	URI []string `json:"uri,omitempty" yaml:"uri,omitempty"`
+}
+
+// Route allows a path pattern, for the listed methods or all methods when
+// Methods is empty.
+type Route struct {
+	Path    string   `json:"path" yaml:"path"`
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+}
+
+// Identity is what the policy knows about a client.
+type Identity struct {
+	CN     string   `json:"cn"`
+	OUs    []string `json:"ou,omitempty"`
+	URIs   []string `json:"uri,omitempty"`
+	Serial string   `json:"serial"`
+}
+
+// IdentityOf extracts the identity of a client certificate.
+func IdentityOf(cert *x509.Certificate) Identity {
+	id := Identity{
+		CN:     cert.Subject.CommonName,
+		OUs:    cert.Subject.OrganizationalUnit,
+		Serial: cert.SerialNumber.String(),
+	}
+	for _, u := range cert.URIs {
+		id.URIs = append(id.URIs, u.String())
+	}
+	return id
+}
+
+// LoadPolicy reads a policy from a .json file, or YAML for any other
+// extension. Unknown fields are rejected so typos do not silently widen or
+// narrow access.
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&p)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse policy %s: %w", file, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", file, err)
+	}
+	return &p, nil
+}
+
+// Validate rejects rules that would match every client by accident or allow
+// nothing.
+func (p *Policy) Validate() error {
+	for i, r := range p.Rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(r.Match.CN)+len(r.Match.OU)+len(r.Match.URI) == 0 {
+			return fmt.Errorf("rule %s: match is empty, use cn: [\"*\"] to match every client", name)
+		}
+		if len(r.Allow) == 0 {
+			return fmt.Errorf("rule %s: allows no routes", name)
+		}
+		for _, route := range r.Allow {
+			if !strings.HasPrefix(route.Path, "/") && route.Path != "*" {
+				return fmt.Errorf("rule %s: path %q must start with /", name, route.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether id may call method on path, and the name of the
+// rule that allowed it.
+func (p *Policy) Allowed(id Identity, method, path string) (string, bool) {
+	for _, r := range p.Rules {
+		if !r.Match.matches(id) {
+			continue
+		}
+		for _, route := range r.Allow {
+			if route.allows(method, path) {
+				return r.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (m Match) matches(id Identity) bool {
+	if len(m.CN) > 0 && !matchAny(m.CN, []string{id.CN}) {
+		return false
+	}
+	if len(m.OU) > 0 && !matchAny(m.OU, id.OUs) {
+		return false
+	}
+	if len(m.URI) > 0 && !matchAny(m.URI, id.URIs) {
+		return false
+	}
+	return true
+}
+
+func (r Route) allows(method, path string) bool {
+	if !match(r.Path, path) {
+		return false
+	}
+	if len(r.Methods) == 0 {
+		return true
+	}
+	for _, m := range r.Methods {
+		if m == "*" || strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns, values []string) bool {
+	for _, p := range patterns {
+		for _, v := range values {
+			if match(p, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func match(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+	return pattern == value
+}
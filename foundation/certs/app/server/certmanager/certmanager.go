@@ -0,0 +1,296 @@
+// Package certmanager keeps the server certificate and the trusted client CA
+// bundles in memory and swaps them when the files on disk change, so a
+// certificate can be rotated without restarting the server.
+//
+// The tls.Config returned by TLSConfig looks the current certificate up on
+// every handshake through GetCertificate and GetConfigForClient. Connections
+// that are already established keep the certificate they negotiated.
+package certmanager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultPollInterval = 10 * time.Second
+	DefaultExpiryWarn   = 30 * 24 * time.Hour
+)
+
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFiles are all trusted at the same time. During a CA rotation
+	// list both the old and the new bundle, then drop the old one.
+	ClientCAFiles []string
+	// PollInterval is how often the files are checked for changes.
+	PollInterval time.Duration
+	// ExpiryWarn logs a warning for certificates expiring within it.
+	ExpiryWarn time.Duration
+}
+
+// CertInfo describes one loaded certificate.
+type CertInfo struct {
+	Source    string    `json:"source"`
+	Subject   string    `json:"subject"`
+	Serial    string    `json:"serial"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+type Manager struct {
+	cfg Config
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	caCerts   []*x509.Certificate
+	infos     []CertInfo
+	modTimes  map[string]time.Time
+}
+
+// New loads the certificate and the client CAs once. It fails if any of the
+// files cannot be loaded, later reloads keep the previous state instead.
+func New(cfg Config) (*Manager, error) {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.ExpiryWarn <= 0 {
+		cfg.ExpiryWarn = DefaultExpiryWarn
+	}
+
+	m := &Manager{cfg: cfg}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload reads every file again and swaps the new state in atomically. On
+// error the current certificate and CAs stay in use.
+func (m *Manager) Reload() error {
+	files := append([]string{m.cfg.CertFile, m.cfg.KeyFile}, m.cfg.ClientCAFiles...)
+	modTimes, err := statAll(files)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(m.cfg.CertFile, m.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load server certificate: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("parse server certificate: %w", err)
+	}
+	cert.Leaf = leaf
+
+	infos := []CertInfo{info(m.cfg.CertFile, leaf)}
+
+	pool := x509.NewCertPool()
+	var caCerts []*x509.Certificate
+	for _, file := range m.cfg.ClientCAFiles {
+		cas, err := loadCerts(file)
+		if err != nil {
+			return fmt.Errorf("load client CA bundle %s: %w", file, err)
+		}
+		for _, ca := range cas {
+			pool.AddCert(ca)
+			caCerts = append(caCerts, ca)
+			infos = append(infos, info(file, ca))
+		}
+	}
+
+	m.mu.Lock()
+	m.cert = &cert
+	m.clientCAs = pool
+	m.caCerts = caCerts
+	m.infos = infos
+	m.modTimes = modTimes
+	m.mu.Unlock()
+
+	m.logExpiry(infos)
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cert, nil
+}
+
+// ClientCAs returns the pool of all currently trusted client CAs.
+func (m *Manager) ClientCAs() *x509.CertPool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clientCAs
+}
+
+// ClientCACerts returns the currently trusted client CA certificates.
+func (m *Manager) ClientCACerts() []*x509.Certificate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]*x509.Certificate(nil), m.caCerts...)
+}
+
+// Certificates returns the server certificate followed by every client CA
+// with their validity periods.
+func (m *Manager) Certificates() []CertInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]CertInfo(nil), m.infos...)
+}
+
+// TLSConfig returns base with certificate and client CA lookups wired to the
+// manager. base is cloned and may be nil.
+func (m *Manager) TLSConfig(base *tls.Config) *tls.Config {
+	if base == nil {
+		base = &tls.Config{}
+	}
+
+	cfg := base.Clone()
+	cfg.Certificates = nil
+	cfg.GetCertificate = m.GetCertificate
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		// The client CAs can only be swapped per connection by returning
+		// a whole config. GetConfigForClient is cleared on the copy so it
+		// is not called again for it.
+		c := cfg.Clone()
+		c.GetConfigForClient = nil
+		c.ClientCAs = m.ClientCAs()
+		return c, nil
+	}
+	return cfg
+}
+
+// Watch reloads when one of the files changes or the process receives
+// SIGHUP, until ctx is done.
+func (m *Manager) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			m.reload("SIGHUP")
+		case <-ticker.C:
+			if m.changed() {
+				m.reload("file change")
+			}
+		}
+	}
+}
+
+func (m *Manager) reload(reason string) {
+	if err := m.Reload(); err != nil {
+		log.Printf("⚠️  Certificate reload (%s) failed, keeping current certificates: %v", reason, err)
+		return
+	}
+	log.Printf("🔄 Certificates reloaded (%s)", reason)
+}
+
+// changed reports whether any file's modification time differs from the
+// one seen at the last successful load. Files that are missing, e.g. half
+// way through a rename, count as unchanged.
+func (m *Manager) changed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for file, seen := range m.modTimes {
+		st, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !st.ModTime().Equal(seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) logExpiry(infos []CertInfo) {
+	sorted := append([]CertInfo(nil), infos...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].NotAfter.Before(sorted[j].NotAfter) })
+
+	for _, ci := range sorted {
+		left := time.Until(ci.NotAfter)
+		switch {
+		case left <= 0:
+			log.Printf("🚨 Certificate %q from %s expired on %s", ci.Subject, ci.Source, ci.NotAfter.Format(time.RFC3339))
+		case left <= m.cfg.ExpiryWarn:
+			log.Printf("⚠️  Certificate %q from %s expires on %s", ci.Subject, ci.Source, ci.NotAfter.Format(time.RFC3339))
+		default:
+			log.Printf("📜 Certificate %q from %s valid until %s", ci.Subject, ci.Source, ci.NotAfter.Format(time.RFC3339))
+		}
+	}
+}
+
+func info(source string, cert *x509.Certificate) CertInfo {
+	return CertInfo{
+		Source:    source,
+		Subject:   cert.Subject.CommonName,
+		Serial:    cert.SerialNumber.String(),
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+}
+
+func statAll(files []string) (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		st, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[file] = st.ModTime()
+	}
+	return modTimes, nil
+}
+
+// loadCerts reads every CERTIFICATE block of a PEM bundle.
+func loadCerts(file string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
@@ -0,0 +1,156 @@
+package certmanager
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"server/internal/testpki"
+)
+
+func handshake(t *testing.T, m *Manager, client tls.Certificate, roots *x509.Certificate) (*x509.Certificate, error) {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", m.TLSConfig(&tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("ok"))
+		conn.Close()
+	}()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(roots)
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+		RootCAs:      pool,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{client},
+		MaxVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// With TLS 1.3 a rejected client certificate only surfaces on read.
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestReloadRotatesCertificateAndClientCAs(t *testing.T) {
+	dir := t.TempDir()
+
+	oldCA := testpki.NewCA(t, "old-ca")
+	newCA := testpki.NewCA(t, "new-ca")
+
+	certFile, keyFile := oldCA.Server(t, "server-v1").WriteFiles(t, dir, "server")
+	caFile := filepath.Join(dir, "clients.crt")
+	testpki.WriteBundle(t, caFile, oldCA)
+
+	m, err := New(Config{CertFile: certFile, KeyFile: keyFile, ClientCAFiles: []string{caFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldClient := oldCA.Client(t, "old-client").TLS()
+	newClient := newCA.Client(t, "new-client").TLS()
+
+	peer, err := handshake(t, m, oldClient, oldCA.Cert)
+	if err != nil || peer.Subject.CommonName != "server-v1" {
+		t.Fatalf("expected server-v1, got %v, %v", peer, err)
+	}
+
+	if _, err := handshake(t, m, newClient, oldCA.Cert); err == nil {
+		t.Fatal("expected client from the new CA to be rejected before rotation")
+	}
+
+	// Rotation: new server certificate from the new CA, both CAs trusted.
+	newCA.Server(t, "server-v2").WriteFiles(t, dir, "server")
+	testpki.WriteBundle(t, caFile, oldCA, newCA)
+
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, client := range []tls.Certificate{oldClient, newClient} {
+		peer, err := handshake(t, m, client, newCA.Cert)
+		if err != nil || peer.Subject.CommonName != "server-v2" {
+			t.Fatalf("expected server-v2 for %s, got %v, %v", client.Leaf.Subject.CommonName, peer, err)
+		}
+	}
+
+	if got := len(m.Certificates()); got != 3 {
+		t.Errorf("expected server cert and two CAs, got %d", got)
+	}
+}
+
+func TestReloadKeepsStateOnError(t *testing.T) {
+	dir := t.TempDir()
+	ca := testpki.NewCA(t, "ca")
+
+	certFile, keyFile := ca.Server(t, "server").WriteFiles(t, dir, "server")
+	caFile := filepath.Join(dir, "ca.crt")
+	testpki.WriteBundle(t, caFile, ca)
+
+	m, err := New(Config{CertFile: certFile, KeyFile: keyFile, ClientCAFiles: []string{caFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := m.GetCertificate(nil)
+
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Reload(); err == nil {
+		t.Fatal("expected reload with a broken key to fail")
+	}
+
+	after, _ := m.GetCertificate(nil)
+	if before != after {
+		t.Error("expected the previous certificate to stay in use")
+	}
+}
+
+func TestChangedDetectsNewFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := testpki.NewCA(t, "ca")
+
+	certFile, keyFile := ca.Server(t, "server").WriteFiles(t, dir, "server")
+	caFile := filepath.Join(dir, "ca.crt")
+	testpki.WriteBundle(t, caFile, ca)
+
+	m, err := New(Config{CertFile: certFile, KeyFile: keyFile, ClientCAFiles: []string{caFile}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.changed() {
+		t.Fatal("expected no change right after loading")
+	}
+
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(certFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	if !m.changed() {
+		t.Error("expected a newer certificate file to be detected")
+	}
+}
@@ -1,3 +1,8 @@
 module server
 
 go 1.21
+
+require (
+	golang.org/x/crypto v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
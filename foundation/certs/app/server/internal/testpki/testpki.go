@@ -0,0 +1,208 @@
+// Package testpki creates throwaway certificate authorities and leaf
+// certificates for tests, so they do not depend on the files in certs/.
+package testpki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var serial atomic.Int64
+
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+type Leaf struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// NewCA returns a self-signed root CA valid for a day.
+func NewCA(t testing.TB, cn string) *CA {
+	return newCA(t, cn, nil)
+}
+
+// Intermediate issues an intermediate CA signed by ca.
+func (ca *CA) Intermediate(t testing.TB, cn string) *CA {
+	return newCA(t, cn, ca)
+}
+
+// CrossSign issues a certificate for the name and key of sub signed by ca,
+// so chains through sub also verify up to ca.
+func (ca *CA) CrossSign(t testing.TB, sub *CA) *CA {
+	t.Helper()
+	return signCA(t, sub.Cert.Subject.CommonName, sub.Key, ca)
+}
+
+func newCA(t testing.TB, cn string, parent *CA) *CA {
+	t.Helper()
+	return signCA(t, cn, newKey(t), parent)
+}
+
+func signCA(t testing.TB, cn string, key crypto.Signer, parent *CA) *CA {
+	t.Helper()
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          nextSerial(),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	issuer, issuerKey := tmpl, key
+	if parent != nil {
+		issuer, issuerKey = parent.Cert, parent.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, key.Public(), issuerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &CA{Cert: cert, Key: key}
+}
+
+// Server issues a server certificate for localhost and 127.0.0.1.
+func (ca *CA) Server(t testing.TB, cn string) *Leaf {
+	return ca.issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn},
+		DNSNames:    []string{cn, "localhost"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+}
+
+// Client issues a client certificate. modify, if set, can add OUs, URIs or
+// an OCSP server before signing.
+func (ca *CA) Client(t testing.TB, cn string, modify ...func(*x509.Certificate)) *Leaf {
+	tmpl := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: cn},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, m := range modify {
+		m(tmpl)
+	}
+	return ca.issue(t, tmpl)
+}
+
+func (ca *CA) issue(t testing.TB, tmpl *x509.Certificate) *Leaf {
+	t.Helper()
+
+	key := newKey(t)
+	tmpl.SerialNumber = nextSerial()
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(24 * time.Hour)
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.Cert, key.Public(), ca.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Leaf{Cert: cert, Key: key}
+}
+
+// TLS returns the leaf as a tls.Certificate.
+func (l *Leaf) TLS() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{l.Cert.Raw}, PrivateKey: l.Key, Leaf: l.Cert}
+}
+
+// WriteFiles writes the leaf certificate and key as PEM to dir/name.crt and
+// dir/name.key and returns both paths.
+func (l *Leaf) WriteFiles(t testing.TB, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(l.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	WritePEM(t, certFile, "CERTIFICATE", l.Cert.Raw)
+	WritePEM(t, keyFile, "PRIVATE KEY", keyDER)
+	return certFile, keyFile
+}
+
+// WriteBundle writes the CA certificates into one PEM file.
+func WriteBundle(t testing.TB, file string, cas ...*CA) {
+	t.Helper()
+
+	var data []byte
+	for _, ca := range cas {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Cert.Raw})...)
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func WritePEM(t testing.TB, file, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newKey(t testing.TB) crypto.Signer {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func nextSerial() *big.Int {
+	return big.NewInt(1000 + serial.Add(1))
+}
+
+// WriteCRL writes a PEM CRL signed by ca that revokes the given leaves.
+func (ca *CA) WriteCRL(t testing.TB, file string, revoked ...*Leaf) {
+	t.Helper()
+
+	tmpl := &x509.RevocationList{
+		Number:     nextSerial(),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+	for _, l := range revoked {
+		tmpl.RevokedCertificateEntries = append(tmpl.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   l.Cert.SerialNumber,
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, tmpl, ca.Cert, ca.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	WritePEM(t, file, "X509 CRL", der)
+}
@@ -0,0 +1,508 @@
+// Package revocation rejects client certificates that were revoked after
+// they were issued. A chain that verifies against ca.crt is still refused
+// when its leaf or any intermediate is
+//
+//   - listed in a local deny-list of serial numbers, optionally scoped to
+//     an issuer,
+//   - listed in a CRL from disk signed by its issuer, or
+//   - reported revoked by the OCSP responder named in the certificate.
+//
+// Checker.VerifyConnection plugs into tls.Config.VerifyConnection and runs
+// after the standard chain verification.
+package revocation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	DefaultRefreshInterval = 5 * time.Minute
+	DefaultOCSPTimeout     = 5 * time.Second
+	DefaultOCSPCacheTTL    = time.Hour
+	DefaultOCSPCacheSize   = 10000
+
+	// ocspClockSkew is how far the clock of a responder may be ahead of
+	// ours before its ThisUpdate is rejected.
+	ocspClockSkew = 5 * time.Minute
+)
+
+// ErrRevoked is returned, wrapped with the reason, for revoked certificates.
+var ErrRevoked = errors.New("certificate revoked")
+
+type Config struct {
+	// CRLFiles are DER or PEM encoded CRLs. A CRL only applies to
+	// certificates issued by the CA that signed it.
+	CRLFiles []string
+	// DenyListFile holds one serial number per line, in decimal or hex
+	// ("0x1f", "1F:0A"), optionally followed by the subject of the issuing
+	// CA as Go prints it ("0x1f CN=certctl Intermediate CA"). A serial
+	// without an issuer is denied for certificates of every CA. Blank lines
+	// and lines starting with # are ignored.
+	DenyListFile string
+	// RefreshInterval is how often the CRLs and the deny-list are reloaded.
+	RefreshInterval time.Duration
+
+	// OCSP enables online checks against the responder in the
+	// certificate's Authority Information Access extension.
+	OCSP bool
+	// OCSPFailOpen accepts the certificate when the responder cannot be
+	// reached or answers "unknown". By default such connections fail.
+	OCSPFailOpen bool
+	OCSPTimeout  time.Duration
+	// OCSPCacheTTL caps how long a response is reused. Responses are
+	// never reused past their NextUpdate.
+	OCSPCacheTTL time.Duration
+	// OCSPCacheSize caps the number of cached responses. Expired ones are
+	// evicted first, then the ones expiring soonest.
+	OCSPCacheSize int
+
+	// HTTPClient is used for OCSP requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+
+	// Issuers returns the trusted CAs a CRL may be signed by. It is called
+	// on every reload so CA rotations are picked up. CRLs of intermediate
+	// CAs, which are not in the trust store, are checked against the
+	// intermediates of verified client chains instead.
+	Issuers func() []*x509.Certificate
+}
+
+type crlEntry struct {
+	crl        *x509.RevocationList
+	revoked    map[string]bool
+	nextUpdate time.Time
+	source     string
+
+	// issuer signed the CRL. It is nil for a CRL of an intermediate until
+	// a verified chain presents that intermediate.
+	mu     sync.Mutex
+	issuer *x509.Certificate
+}
+
+// issuedBy reports whether the CRL applies to certificates of issuer, a
+// CA from a verified chain.
+func (e *crlEntry) issuedBy(issuer *x509.Certificate) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.issuer != nil {
+		return bytes.Equal(e.issuer.Raw, issuer.Raw)
+	}
+	if !bytes.Equal(e.crl.RawIssuer, issuer.RawSubject) || e.crl.CheckSignatureFrom(issuer) != nil {
+		return false
+	}
+	log.Printf("✅ CRL %s is signed by intermediate %q", e.source, issuer.Subject.CommonName)
+	e.issuer = issuer
+	return true
+}
+
+type ocspEntry struct {
+	status  int
+	expires time.Time
+}
+
+type Checker struct {
+	cfg Config
+
+	mu   sync.RWMutex
+	crls []*crlEntry
+	deny map[string]bool
+
+	ocspMu    sync.Mutex
+	ocspCache map[string]ocspEntry
+}
+
+// New loads the CRLs and the deny-list once. It fails if any of them cannot
+// be loaded, later reloads keep the previous data instead.
+func New(cfg Config) (*Checker, error) {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultRefreshInterval
+	}
+	if cfg.OCSPTimeout <= 0 {
+		cfg.OCSPTimeout = DefaultOCSPTimeout
+	}
+	if cfg.OCSPCacheTTL <= 0 {
+		cfg.OCSPCacheTTL = DefaultOCSPCacheTTL
+	}
+	if cfg.OCSPCacheSize <= 0 {
+		cfg.OCSPCacheSize = DefaultOCSPCacheSize
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	if len(cfg.CRLFiles) > 0 && cfg.Issuers == nil {
+		return nil, errors.New("revocation: CRLs need Issuers to verify their signature")
+	}
+
+	c := &Checker{
+		cfg:       cfg,
+		deny:      map[string]bool{},
+		ocspCache: map[string]ocspEntry{},
+	}
+
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the CRLs and the deny-list again. Nothing is replaced if any
+// file fails to load.
+func (c *Checker) Reload() error {
+	var crls []*crlEntry
+	for _, file := range c.cfg.CRLFiles {
+		entry, err := loadCRL(file, c.cfg.Issuers())
+		if err != nil {
+			return fmt.Errorf("load CRL %s: %w", file, err)
+		}
+		if !entry.nextUpdate.IsZero() && time.Now().After(entry.nextUpdate) {
+			log.Printf("⚠️  CRL %s is past its next update (%s)", file, entry.nextUpdate.Format(time.RFC3339))
+		}
+		crls = append(crls, entry)
+	}
+
+	deny := map[string]bool{}
+	if c.cfg.DenyListFile != "" {
+		var err error
+		if deny, err = loadDenyList(c.cfg.DenyListFile); err != nil {
+			return fmt.Errorf("load deny-list: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	c.crls = crls
+	c.deny = deny
+	c.mu.Unlock()
+	return nil
+}
+
+// Run reloads the CRLs and the deny-list every RefreshInterval until ctx is
+// done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Reload(); err != nil {
+				log.Printf("⚠️  Revocation data refresh failed, keeping previous data: %v", err)
+			}
+		}
+	}
+}
+
+// VerifyConnection implements tls.Config.VerifyConnection. It needs the
+// verified chains, so use it with tls.RequireAndVerifyClientCert.
+func (c *Checker) VerifyConnection(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+	if len(cs.VerifiedChains) == 0 {
+		return errors.New("revocation: no verified chain to check")
+	}
+
+	// With cross-signed or rotating CAs the chains differ in their
+	// intermediates and root. The connection is accepted if any of them
+	// is free of revoked certificates.
+	var errs []error
+	for _, chain := range cs.VerifiedChains {
+		err := c.CheckChain(chain)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// CheckChain checks every non-root certificate of chain against its issuer.
+func (c *Checker) CheckChain(chain []*x509.Certificate) error {
+	for i := 0; i+1 < len(chain); i++ {
+		if err := c.Check(chain[i], chain[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether cert, issued by issuer, is revoked.
+func (c *Checker) Check(cert, issuer *x509.Certificate) error {
+	serial := serialKey(cert.SerialNumber)
+
+	c.mu.RLock()
+	denied := c.deny[denyKey(serial, "")] || c.deny[denyKey(serial, issuer.Subject.String())]
+	crls := c.crls
+	c.mu.RUnlock()
+
+	if denied {
+		return fmt.Errorf("%w: serial %s of %q is on the deny-list", ErrRevoked, serial, cert.Subject.CommonName)
+	}
+
+	for _, crl := range crls {
+		if !crl.issuedBy(issuer) {
+			continue
+		}
+		if crl.revoked[serial] {
+			return fmt.Errorf("%w: serial %s of %q is listed in %s", ErrRevoked, serial, cert.Subject.CommonName, crl.source)
+		}
+	}
+
+	if c.cfg.OCSP && len(cert.OCSPServer) > 0 {
+		return c.checkOCSP(cert, issuer)
+	}
+	return nil
+}
+
+func (c *Checker) checkOCSP(cert, issuer *x509.Certificate) error {
+	key := string(issuer.RawSubject) + "/" + serialKey(cert.SerialNumber)
+
+	c.ocspMu.Lock()
+	entry, ok := c.ocspCache[key]
+	c.ocspMu.Unlock()
+
+	if !ok || time.Now().After(entry.expires) {
+		resp, err := c.queryOCSP(cert, issuer)
+		if err != nil {
+			if c.cfg.OCSPFailOpen {
+				log.Printf("⚠️  OCSP check for %q failed, accepting (fail-open): %v", cert.Subject.CommonName, err)
+				return nil
+			}
+			return fmt.Errorf("revocation: OCSP check for %q failed: %w", cert.Subject.CommonName, err)
+		}
+
+		entry = ocspEntry{status: resp.Status, expires: time.Now().Add(c.cfg.OCSPCacheTTL)}
+		if !resp.NextUpdate.IsZero() && resp.NextUpdate.Before(entry.expires) {
+			entry.expires = resp.NextUpdate
+		}
+
+		c.cacheOCSP(key, entry)
+	}
+
+	switch entry.status {
+	case ocsp.Good:
+		return nil
+	case ocsp.Revoked:
+		return fmt.Errorf("%w: OCSP responder reports %q revoked", ErrRevoked, cert.Subject.CommonName)
+	default:
+		if c.cfg.OCSPFailOpen {
+			return nil
+		}
+		return fmt.Errorf("revocation: OCSP status of %q is unknown", cert.Subject.CommonName)
+	}
+}
+
+// cacheOCSP stores entry, making room when the cache is full.
+func (c *Checker) cacheOCSP(key string, entry ocspEntry) {
+	c.ocspMu.Lock()
+	defer c.ocspMu.Unlock()
+
+	if _, ok := c.ocspCache[key]; !ok && len(c.ocspCache) >= c.cfg.OCSPCacheSize {
+		now := time.Now()
+		for k, e := range c.ocspCache {
+			if now.After(e.expires) {
+				delete(c.ocspCache, k)
+			}
+		}
+		for len(c.ocspCache) >= c.cfg.OCSPCacheSize {
+			var soonest string
+			for k, e := range c.ocspCache {
+				if soonest == "" || e.expires.Before(c.ocspCache[soonest].expires) {
+					soonest = k
+				}
+			}
+			delete(c.ocspCache, soonest)
+		}
+	}
+	c.ocspCache[key] = entry
+}
+
+func (c *Checker) queryOCSP(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA256})
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		ctx, cancel := context.WithTimeout(context.Background(), c.cfg.OCSPTimeout)
+		resp, err := c.postOCSP(ctx, server, req, cert, issuer)
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func (c *Checker) postOCSP(ctx context.Context, server string, body []byte, cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/ocsp-request")
+
+	httpResp, err := c.cfg.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("responder %s answered %s", server, httpResp.Status)
+	}
+
+	der, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	// ParseResponseForCert checks the signature against issuer and that
+	// the response is about cert, not that it is current.
+	resp, err := ocsp.ParseResponseForCert(der, cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFreshness(resp, time.Now()); err != nil {
+		return nil, fmt.Errorf("responder %s: %w", server, err)
+	}
+	return resp, nil
+}
+
+// checkFreshness rejects responses from the future and stale ones, a
+// replayed old "good" response is as valid a signature as a new one.
+func checkFreshness(resp *ocsp.Response, now time.Time) error {
+	if resp.ThisUpdate.After(now.Add(ocspClockSkew)) {
+		return fmt.Errorf("OCSP response is from the future (this update %s)", resp.ThisUpdate.Format(time.RFC3339))
+	}
+	if !resp.NextUpdate.IsZero() && resp.NextUpdate.Before(now) {
+		return fmt.Errorf("OCSP response is stale (next update %s)", resp.NextUpdate.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// loadCRL reads a CRL and checks its signature when its issuer is a
+// trusted CA. A CRL of another issuer is kept for the intermediates of
+// client chains, unless it claims to be from a trusted CA.
+func loadCRL(file string, issuers []*x509.Certificate) (*crlEntry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &crlEntry{
+		crl:        crl,
+		revoked:    make(map[string]bool, len(crl.RevokedCertificateEntries)),
+		nextUpdate: crl.NextUpdate,
+		source:     file,
+	}
+	for _, candidate := range issuers {
+		if !bytes.Equal(candidate.RawSubject, crl.RawIssuer) {
+			continue
+		}
+		if crl.CheckSignatureFrom(candidate) == nil {
+			entry.issuer = candidate
+			break
+		}
+	}
+	if entry.issuer == nil {
+		for _, candidate := range issuers {
+			if bytes.Equal(candidate.RawSubject, crl.RawIssuer) {
+				return nil, errors.New("not signed by the trusted CA it names as issuer")
+			}
+		}
+	}
+
+	for _, rc := range crl.RevokedCertificateEntries {
+		entry.revoked[serialKey(rc.SerialNumber)] = true
+	}
+	return entry, nil
+}
+
+func loadDenyList(file string) (map[string]bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	deny := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		value, issuer, _ := strings.Cut(line, " ")
+		serial, err := ParseSerial(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		deny[denyKey(serialKey(serial), strings.TrimSpace(issuer))] = true
+	}
+	return deny, scanner.Err()
+}
+
+// denyKey scopes a serial to the subject of its issuer, or to every issuer
+// when issuer is empty.
+func denyKey(serial, issuer string) string {
+	return issuer + "/" + serial
+}
+
+// ParseSerial accepts a decimal serial, or hex prefixed with 0x or written
+// with colons as openssl prints it.
+func ParseSerial(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") || strings.Contains(s, ":") {
+		digits := strings.ReplaceAll(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"), ":", "")
+		if len(digits)%2 == 1 {
+			digits = "0" + digits
+		}
+		raw, err := hex.DecodeString(digits)
+		if err != nil {
+			return nil, fmt.Errorf("invalid serial %q", s)
+		}
+		return new(big.Int).SetBytes(raw), nil
+	}
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid serial %q", s)
+	}
+	return n, nil
+}
+
+func serialKey(n *big.Int) string {
+	return n.Text(16)
+}
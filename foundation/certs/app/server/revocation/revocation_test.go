@@ -0,0 +1,466 @@
+package revocation
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+
+	"server/internal/testpki"
+)
+
+// responder is an in-process OCSP responder for one CA. Serials in revoked
+// are answered Revoked, every other serial Good.
+type responder struct {
+	ca       *testpki.CA
+	requests atomic.Int32
+
+	mu      sync.Mutex
+	revoked map[string]bool
+	// thisUpdate and nextUpdate, when set, replace the times of the
+	// responses, for stale and replayed answers.
+	thisUpdate, nextUpdate time.Time
+}
+
+func newResponder(t *testing.T, ca *testpki.CA) (*responder, string) {
+	r := &responder{ca: ca, revoked: map[string]bool{}}
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+func (r *responder) revoke(l *testpki.Leaf) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revoked[serialKey(l.Cert.SerialNumber)] = true
+}
+
+func (r *responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.requests.Add(1)
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ocspReq, err := ocsp.ParseRequest(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	revoked := r.revoked[serialKey(ocspReq.SerialNumber)]
+	thisUpdate, nextUpdate := r.thisUpdate, r.nextUpdate
+	r.mu.Unlock()
+
+	tmpl := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: ocspReq.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}
+	if !thisUpdate.IsZero() {
+		tmpl.ThisUpdate, tmpl.NextUpdate = thisUpdate, nextUpdate
+	}
+	if revoked {
+		tmpl.Status = ocsp.Revoked
+		tmpl.RevokedAt = time.Now().Add(-time.Minute)
+	}
+
+	der, err := ocsp.CreateResponse(r.ca.Cert, r.ca.Cert, tmpl, r.ca.Key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(der)
+}
+
+func withOCSP(url string) func(*x509.Certificate) {
+	return func(c *x509.Certificate) { c.OCSPServer = []string{url} }
+}
+
+func issuers(cas ...*testpki.CA) func() []*x509.Certificate {
+	return func() []*x509.Certificate {
+		var certs []*x509.Certificate
+		for _, ca := range cas {
+			certs = append(certs, ca.Cert)
+		}
+		return certs
+	}
+}
+
+func TestCRL(t *testing.T) {
+	dir := t.TempDir()
+	ca := testpki.NewCA(t, "ca")
+	other := testpki.NewCA(t, "other-ca")
+
+	good := ca.Client(t, "good")
+	leaked := ca.Client(t, "leaked")
+
+	crlFile := filepath.Join(dir, "ca.crl")
+	ca.WriteCRL(t, crlFile, leaked)
+
+	c, err := New(Config{CRLFiles: []string{crlFile}, Issuers: issuers(ca, other)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Check(good.Cert, ca.Cert); err != nil {
+		t.Errorf("expected good certificate to pass, got %v", err)
+	}
+	if err := c.Check(leaked.Cert, ca.Cert); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected leaked certificate to be revoked, got %v", err)
+	}
+
+	// Revoke the good certificate too and refresh.
+	ca.WriteCRL(t, crlFile, leaked, good)
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Check(good.Cert, ca.Cert); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected reloaded CRL to revoke good, got %v", err)
+	}
+}
+
+func TestCRLFromUntrustedIssuerIsRejected(t *testing.T) {
+	dir := t.TempDir()
+	ca := testpki.NewCA(t, "ca")
+	client := ca.Client(t, "client")
+
+	// Same name as the trusted CA, another key
+	forged := testpki.NewCA(t, "ca")
+	crlFile := filepath.Join(dir, "forged.crl")
+	forged.WriteCRL(t, crlFile, client)
+
+	if _, err := New(Config{CRLFiles: []string{crlFile}, Issuers: issuers(ca)}); err == nil {
+		t.Fatal("expected a CRL signed by an untrusted CA to fail loading")
+	}
+
+	// A CRL of an unknown CA never applies to the chains of the trusted one
+	rogue := testpki.NewCA(t, "rogue")
+	rogue.WriteCRL(t, crlFile, client)
+
+	c, err := New(Config{CRLFiles: []string{crlFile}, Issuers: issuers(ca)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CheckChain([]*x509.Certificate{client.Cert, ca.Cert}); err != nil {
+		t.Errorf("rogue CRL revoked a certificate: %v", err)
+	}
+}
+
+func TestCRLOfIntermediate(t *testing.T) {
+	dir := t.TempDir()
+	root := testpki.NewCA(t, "root")
+	inter := root.Intermediate(t, "intermediate")
+	good := inter.Client(t, "good")
+	leaked := inter.Client(t, "leaked")
+
+	crlFile := filepath.Join(dir, "intermediate.crl")
+	inter.WriteCRL(t, crlFile, leaked)
+
+	// Only the root is trusted, as with certctl's ca.crt
+	c, err := New(Config{CRLFiles: []string{crlFile}, Issuers: issuers(root)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.CheckChain([]*x509.Certificate{good.Cert, inter.Cert, root.Cert}); err != nil {
+		t.Errorf("expected good certificate to pass, got %v", err)
+	}
+	if err := c.CheckChain([]*x509.Certificate{leaked.Cert, inter.Cert, root.Cert}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected leaked certificate to be revoked, got %v", err)
+	}
+
+	// An intermediate with the same name but another key is not the issuer
+	impostor := root.Intermediate(t, "intermediate")
+	if err := c.CheckChain([]*x509.Certificate{leaked.Cert, impostor.Cert, root.Cert}); err != nil {
+		t.Errorf("CRL applied to another intermediate: %v", err)
+	}
+}
+
+func TestDenyList(t *testing.T) {
+	dir := t.TempDir()
+	ca := testpki.NewCA(t, "ca")
+	leaked := ca.Client(t, "leaked")
+	good := ca.Client(t, "good")
+
+	denyFile := filepath.Join(dir, "deny.txt")
+	content := "# leaked laptop\n" + leaked.Cert.SerialNumber.String() + "\n\n0xdeadbeef\n"
+	if err := os.WriteFile(denyFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Config{DenyListFile: denyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Check(leaked.Cert, ca.Cert); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected denied serial to be revoked, got %v", err)
+	}
+	if err := c.Check(good.Cert, ca.Cert); err != nil {
+		t.Errorf("expected good certificate to pass, got %v", err)
+	}
+
+	// Scoped to another CA, the serial only applies to that CA's certificates.
+	other := testpki.NewCA(t, "other-ca")
+	content = "0x" + leaked.Cert.SerialNumber.Text(16) + " " + other.Cert.Subject.String() + "\n"
+	if err := os.WriteFile(denyFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Check(leaked.Cert, ca.Cert); err != nil {
+		t.Errorf("expected a serial scoped to another CA not to apply, got %v", err)
+	}
+	if err := c.Check(leaked.Cert, other.Cert); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected the scoped serial to be revoked for its CA, got %v", err)
+	}
+}
+
+func TestParseSerial(t *testing.T) {
+	tests := map[string]int64{
+		"1025":  1025,
+		"0x401": 1025,
+		"04:01": 1025,
+	}
+	for in, want := range tests {
+		got, err := ParseSerial(in)
+		if err != nil || got.Int64() != want {
+			t.Errorf("ParseSerial(%q) = %v, %v, want %d", in, got, err, want)
+		}
+	}
+
+	if _, err := ParseSerial("not-a-serial"); err == nil {
+		t.Error("expected invalid serial to fail")
+	}
+}
+
+func TestOCSPWithCache(t *testing.T) {
+	ca := testpki.NewCA(t, "ca")
+	resp, url := newResponder(t, ca)
+
+	good := ca.Client(t, "good", withOCSP(url))
+	leaked := ca.Client(t, "leaked", withOCSP(url))
+	resp.revoke(leaked)
+
+	c, err := New(Config{OCSP: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := c.Check(good.Cert, ca.Cert); err != nil {
+			t.Fatalf("expected good certificate to pass, got %v", err)
+		}
+	}
+	if got := resp.requests.Load(); got != 1 {
+		t.Errorf("expected cached response to be reused, responder saw %d requests", got)
+	}
+
+	if err := c.Check(leaked.Cert, ca.Cert); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected OCSP to report leaked as revoked, got %v", err)
+	}
+}
+
+func TestOCSPUnreachable(t *testing.T) {
+	ca := testpki.NewCA(t, "ca")
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	client := ca.Client(t, "client", withOCSP(url))
+
+	closed, err := New(Config{OCSP: true, OCSPTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := closed.Check(client.Cert, ca.Cert); err == nil {
+		t.Error("expected unreachable responder to fail closed")
+	}
+
+	open, err := New(Config{OCSP: true, OCSPTimeout: time.Second, OCSPFailOpen: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := open.Check(client.Cert, ca.Cert); err != nil {
+		t.Errorf("expected fail-open to accept, got %v", err)
+	}
+}
+
+func TestOCSPRejectsStaleResponses(t *testing.T) {
+	ca := testpki.NewCA(t, "ca")
+
+	for name, times := range map[string][2]time.Time{
+		"replayed": {time.Now().Add(-48 * time.Hour), time.Now().Add(-24 * time.Hour)},
+		"future":   {time.Now().Add(time.Hour), time.Now().Add(2 * time.Hour)},
+	} {
+		resp, url := newResponder(t, ca)
+		resp.thisUpdate, resp.nextUpdate = times[0], times[1]
+		client := ca.Client(t, "client", withOCSP(url))
+
+		closed, err := New(Config{OCSP: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := closed.Check(client.Cert, ca.Cert); err == nil {
+			t.Errorf("%s: response accepted", name)
+		}
+		if len(closed.ocspCache) != 0 {
+			t.Errorf("%s: response cached", name)
+		}
+
+		open, err := New(Config{OCSP: true, OCSPFailOpen: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := open.Check(client.Cert, ca.Cert); err != nil {
+			t.Errorf("%s: expected fail-open to accept, got %v", name, err)
+		}
+	}
+}
+
+func TestOCSPCacheIsBounded(t *testing.T) {
+	ca := testpki.NewCA(t, "ca")
+	resp, url := newResponder(t, ca)
+
+	c, err := New(Config{OCSP: true, OCSPCacheSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ocspCache["expired"] = ocspEntry{status: ocsp.Good, expires: time.Now().Add(-time.Minute)}
+
+	for i := 0; i < 4; i++ {
+		if err := c.Check(ca.Client(t, "client", withOCSP(url)).Cert, ca.Cert); err != nil {
+			t.Fatal(err)
+		}
+		if len(c.ocspCache) > 2 {
+			t.Fatalf("cache holds %d responses, cap is 2", len(c.ocspCache))
+		}
+	}
+	if _, ok := c.ocspCache["expired"]; ok {
+		t.Error("expired response not evicted")
+	}
+	if got := resp.requests.Load(); got != 4 {
+		t.Errorf("responder saw %d requests, want 4", got)
+	}
+}
+
+func TestVerifyConnectionChecksEveryChain(t *testing.T) {
+	dir := t.TempDir()
+	oldRoot := testpki.NewCA(t, "old-root")
+	newRoot := testpki.NewCA(t, "new-root")
+	inter := oldRoot.Intermediate(t, "intermediate")
+	crossed := newRoot.CrossSign(t, inter)
+	client := inter.Client(t, "client")
+
+	viaOld := []*x509.Certificate{client.Cert, inter.Cert, oldRoot.Cert}
+	viaNew := []*x509.Certificate{client.Cert, crossed.Cert, newRoot.Cert}
+
+	// The old root revoked its certificate for the intermediate, the
+	// cross-signed one from the new root is still good.
+	oldCRL := filepath.Join(dir, "old.crl")
+	oldRoot.WriteCRL(t, oldCRL, &testpki.Leaf{Cert: inter.Cert})
+
+	c, err := New(Config{CRLFiles: []string{oldCRL}, Issuers: issuers(oldRoot, newRoot)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: viaOld[:1], VerifiedChains: [][]*x509.Certificate{viaOld}}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected the revoked chain alone to fail, got %v", err)
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: viaOld[:1], VerifiedChains: [][]*x509.Certificate{viaOld, viaNew}}); err != nil {
+		t.Errorf("expected the cross-signed chain to pass, got %v", err)
+	}
+
+	// Revoked on the second chain too, nothing passes.
+	newCRL := filepath.Join(dir, "new.crl")
+	newRoot.WriteCRL(t, newCRL, &testpki.Leaf{Cert: crossed.Cert})
+
+	c, err = New(Config{CRLFiles: []string{oldCRL, newCRL}, Issuers: issuers(oldRoot, newRoot)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.VerifyConnection(tls.ConnectionState{PeerCertificates: viaOld[:1], VerifiedChains: [][]*x509.Certificate{viaOld, viaNew}}); !errors.Is(err, ErrRevoked) {
+		t.Errorf("expected every chain revoked to fail, got %v", err)
+	}
+}
+
+func TestVerifyConnectionRejectsRevokedClient(t *testing.T) {
+	dir := t.TempDir()
+	ca := testpki.NewCA(t, "ca")
+	server := ca.Server(t, "server")
+	good := ca.Client(t, "good")
+	leaked := ca.Client(t, "leaked")
+
+	crlFile := filepath.Join(dir, "ca.crl")
+	ca.WriteCRL(t, crlFile, leaked)
+
+	c, err := New(Config{CRLFiles: []string{crlFile}, Issuers: issuers(ca)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates:     []tls.Certificate{server.TLS()},
+		ClientCAs:        pool,
+		ClientAuth:       tls.RequireAndVerifyClientCert,
+		VerifyConnection: c.VerifyConnection,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("ok"))
+			conn.Close()
+		}
+	}()
+
+	dial := func(client *testpki.Leaf) error {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+			RootCAs:      pool,
+			ServerName:   "localhost",
+			Certificates: []tls.Certificate{client.TLS()},
+		})
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		// With TLS 1.3 a rejected client certificate only surfaces on read.
+		_, err = io.ReadFull(conn, make([]byte, 2))
+		return err
+	}
+
+	if err := dial(good); err != nil {
+		t.Errorf("expected good client to connect, got %v", err)
+	}
+	if err := dial(leaked); err == nil {
+		t.Error("expected revoked client to be rejected")
+	}
+}
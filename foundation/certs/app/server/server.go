@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 	"time"
+
+	"server/authz"
+	"server/certmanager"
+	"server/revocation"
 )
 
 const (
@@ -30,38 +37,92 @@ type EchoRequest struct {
 	Timestamp string `json:"timestamp"`
 }
 
+// certManager holds the current server certificate and client CAs
+var certManager *certmanager.Manager
+
 func main() {
-	// Load server certificate and key
-	serverCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	certFile := flag.String("cert", serverCertFile, "server certificate")
+	keyFile := flag.String("key", serverKeyFile, "server private key")
+	clientCAs := flag.String("client-ca", caCertFile, "comma separated client CA bundles, list old and new CA during a rotation")
+	pollInterval := flag.Duration("reload-interval", certmanager.DefaultPollInterval, "how often certificate files are checked for changes")
+	crlFiles := flag.String("crl", "", "comma separated CRL files of the client CAs")
+	denyList := flag.String("deny-list", "", "file of revoked client certificate serials, one per line")
+	useOCSP := flag.Bool("ocsp", false, "check client certificates with their OCSP responder")
+	ocspFailOpen := flag.Bool("ocsp-fail-open", false, "accept clients when the OCSP responder is unreachable")
+	revocationRefresh := flag.Duration("revocation-refresh", revocation.DefaultRefreshInterval, "how often CRLs and the deny-list are reloaded")
+	policyFile := flag.String("policy", "policy.yaml", "YAML or JSON policy of routes each client identity may call, empty allows every client")
+	auditFile := flag.String("audit-log", "", "file to append denied requests to as JSON lines, stderr if empty")
+	flag.Parse()
+
+	// Load server certificate, key and client CAs
+	var err error
+	certManager, err = certmanager.New(certmanager.Config{
+		CertFile:      *certFile,
+		KeyFile:       *keyFile,
+		ClientCAFiles: strings.Split(*clientCAs, ","),
+		PollInterval:  *pollInterval,
+	})
 	if err != nil {
-		log.Fatalf("❌ Failed to load server certificate: %v", err)
+		log.Fatalf("❌ Failed to load certificates: %v", err)
 	}
 
-	// Load CA certificate for client verification
-	caCert, err := ioutil.ReadFile(caCertFile)
-	if err != nil {
-		log.Fatalf("❌ Failed to read CA certificate: %v", err)
+	// Reload certificates on file change or SIGHUP
+	go certManager.Watch(context.Background())
+
+	// Load revocation data for client certificates
+	revocationConfig := revocation.Config{
+		DenyListFile:    *denyList,
+		RefreshInterval: *revocationRefresh,
+		OCSP:            *useOCSP,
+		OCSPFailOpen:    *ocspFailOpen,
+		// CRLs of the intermediate certctl creates are matched against
+		// the intermediates of the verified client chains
+		Issuers: certManager.ClientCACerts,
 	}
-
-	// Create CA certificate pool for client certificate verification
-	caCertPool := x509.NewCertPool()
-	if !caCertPool.AppendCertsFromPEM(caCert) {
-		log.Fatal("❌ Failed to append CA certificate to pool")
+	if *crlFiles != "" {
+		revocationConfig.CRLFiles = strings.Split(*crlFiles, ",")
 	}
+	revocationChecker, err := revocation.New(revocationConfig)
+	if err != nil {
+		log.Fatalf("❌ Failed to load revocation data: %v", err)
+	}
+	go revocationChecker.Run(context.Background())
 
 	// Create TLS configuration requiring mutual authentication
-	tlsConfig := &tls.Config{
-		Certificates: []tls.Certificate{serverCert}, // Server certificate
-		ClientAuth:   tls.RequireAndVerifyClientCert, // Require client certificate
-		ClientCAs:    caCertPool,                     // CA to verify client certificates
-		MinVersion:   tls.VersionTLS12,               // Minimum TLS version
+	tlsConfig := certManager.TLSConfig(&tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert, // Require client certificate
+		MinVersion: tls.VersionTLS12,               // Minimum TLS version
+		// Reject revoked client certificates after chain verification
+		VerifyConnection: revocationChecker.VerifyConnection,
+	})
+
+	// Restrict routes per client identity
+	handler := createRouter()
+	if *policyFile != "" {
+		policy, err := authz.LoadPolicy(*policyFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to load policy: %v", err)
+		}
+
+		auditLog := os.Stderr
+		if *auditFile != "" {
+			auditLog, err = os.OpenFile(*auditFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+			if err != nil {
+				log.Fatalf("❌ Failed to open audit log: %v", err)
+			}
+			defer auditLog.Close()
+		}
+
+		handler = authz.New(policy, authz.NewAuditLog(auditLog)).Middleware(handler)
+	} else {
+		log.Println("⚠️  No policy configured, every authenticated client may call every route")
 	}
 
 	// Create HTTP server with TLS configuration
 	server := &http.Server{
 		Addr:      serverPort,
 		TLSConfig: tlsConfig,
-		Handler:   createRouter(),
+		Handler:   handler,
 		// Add timeouts for security
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -75,6 +136,11 @@ func main() {
 	fmt.Println("   POST /echo  - Echo JSON payload")
 	fmt.Println("   GET  /info  - Server and client certificate info")
 	fmt.Println("🔐 Server requires mutual TLS authentication")
+	fmt.Println("🔄 Certificates reload on change or SIGHUP")
+	fmt.Println("🚫 Revoked client certificates are rejected (CRL, OCSP, deny-list)")
+	if *policyFile != "" {
+		fmt.Printf("🛂 Routes restricted by policy %s\n", *policyFile)
+	}
 	fmt.Println()
 
 	// Start the HTTPS server
@@ -96,14 +162,14 @@ func createRouter() http.Handler {
 func logRequest(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extract client certificate information if available
-		var clientCN string
+		var id authz.Identity
 		if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
-			clientCert := r.TLS.PeerCertificates[0]
-			clientCN = clientCert.Subject.CommonName
+			id = authz.IdentityOf(r.TLS.PeerCertificates[0])
 		}
 
-		fmt.Printf("📥 %s %s from client: %s (CN: %s)\n", r.Method, r.URL.Path, r.RemoteAddr, clientCN)
-		
+		fmt.Printf("📥 %s %s from client: %s (CN: %s, OU: %s, URI: %s)\n", r.Method, r.URL.Path, r.RemoteAddr,
+			id.CN, strings.Join(id.OUs, ","), strings.Join(id.URIs, ","))
+
 		// Call the next handler
 		next(w, r)
 	}
@@ -183,6 +249,7 @@ func handleInfo(w http.ResponseWriter, r *http.Request) {
 			"message":      "mTLS Server Information",
 			"tls_version":  getTLSVersionString(r.TLS.Version),
 			"cipher_suite": getCipherSuiteString(r.TLS.CipherSuite),
+			"certificates": certManager.Certificates(),
 		},
 	}
 
@@ -190,14 +257,14 @@ func handleInfo(w http.ResponseWriter, r *http.Request) {
 	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
 		clientCert := r.TLS.PeerCertificates[0]
 		info["client"] = map[string]interface{}{
-			"common_name":    clientCert.Subject.CommonName,
-			"organization":   clientCert.Subject.Organization,
-			"country":        clientCert.Subject.Country,
-			"serial_number":  clientCert.SerialNumber.String(),
-			"not_before":     clientCert.NotBefore.Format(time.RFC3339),
-			"not_after":      clientCert.NotAfter.Format(time.RFC3339),
-			"is_ca":          clientCert.IsCA,
-			"key_usage":      getKeyUsageString(clientCert.KeyUsage),
+			"common_name":   clientCert.Subject.CommonName,
+			"organization":  clientCert.Subject.Organization,
+			"country":       clientCert.Subject.Country,
+			"serial_number": clientCert.SerialNumber.String(),
+			"not_before":    clientCert.NotBefore.Format(time.RFC3339),
+			"not_after":     clientCert.NotAfter.Format(time.RFC3339),
+			"is_ca":         clientCert.IsCA,
+			"key_usage":     getKeyUsageString(clientCert.KeyUsage),
 		}
 	}
 
@@ -253,7 +320,7 @@ func getCipherSuiteString(cipherSuite uint16) string {
 // Helper function to get key usage string
 func getKeyUsageString(keyUsage x509.KeyUsage) []string {
 	var usages []string
-	
+
 	if keyUsage&x509.KeyUsageDigitalSignature != 0 {
 		usages = append(usages, "Digital Signature")
 	}
@@ -281,6 +348,6 @@ func getKeyUsageString(keyUsage x509.KeyUsage) []string {
 	if keyUsage&x509.KeyUsageDecipherOnly != 0 {
 		usages = append(usages, "Decipher Only")
 	}
-	
+
 	return usages
 }
@@ -1,135 +1,100 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"os/signal"
 	"time"
 
+	"concurrency/producer-consumer/pipeline"
+
 	"github.com/fatih/color"
 )
 
-const NumberOfPizzas = 10
-
-var pizzasMade, pizzasFailed, total int
+const (
+	NumberOfPizzas = 10
+	NumberOfCooks  = 3
+)
 
-type Producer struct {
-	data chan PizzaOrder
-	quit chan chan error
-}
+var (
+	errNoPower       = errors.New("no power")
+	errNoIngredients = errors.New("ran out of ingredients")
+)
 
 type PizzaOrder struct {
 	pizzaNumber int
-	message     string
-	success     bool
-}
-
-func (p *Producer) Close() error {
-	ch := make(chan error)
-
-	p.quit <- ch
-	return <-ch
 }
 
-func makePizza(pizzaNumber int) *PizzaOrder {
-	pizzaNumber++
-
-	if pizzaNumber <= NumberOfPizzas {
-		fmt.Printf("Received Order #%d", pizzaNumber)
-		fmt.Println()
-
-		delayToMakePizza := rand.Intn(6) + 1
-		fmt.Printf("Making pizza #%d, will be completed in %d seconds", pizzaNumber, delayToMakePizza)
-		fmt.Println()
-
-		time.Sleep(time.Duration(delayToMakePizza) * time.Second)
-
-		rnd := rand.Intn(12) + 1
-		msg := ""
-		sucess := false
-
-		if rnd < 5 {
-			pizzasFailed++
-			if rnd <= 2 {
-				msg = fmt.Sprintf("Failed to make pizza #%d ran out of ingredients", pizzaNumber)
-			} else {
-				msg = fmt.Sprintf("Failed to make pizza #%d no power", pizzaNumber)
-			}
-		} else {
-			pizzasMade++
-			sucess = true
-			msg = fmt.Sprintf("Pizza order #%d is ready", pizzaNumber)
-		}
-
-		total++
+// takeOrders is the producer, it blocks while every cook is busy and the
+// counter is full.
+func takeOrders(ctx context.Context, emit func(PizzaOrder) error) error {
+	for i := 1; i <= NumberOfPizzas; i++ {
+		fmt.Printf("Received Order #%d\n", i)
 
-		p := PizzaOrder{
-			pizzaNumber: pizzaNumber,
-			message:     msg,
-			success:     sucess,
+		if err := emit(PizzaOrder{pizzaNumber: i}); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		return &p
+// makePizza is run by the cooks. A power cut is retried, running out of
+// ingredients is not.
+func makePizza(ctx context.Context, order PizzaOrder) (string, error) {
+	delayToMakePizza := rand.Intn(6) + 1
+	fmt.Printf("Making pizza #%d, will be completed in %d seconds\n", order.pizzaNumber, delayToMakePizza)
 
+	select {
+	case <-time.After(time.Duration(delayToMakePizza) * time.Second):
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
 
-	return &PizzaOrder{
-		pizzaNumber: pizzaNumber,
+	rnd := rand.Intn(12) + 1
+	if rnd <= 2 {
+		return "", pipeline.Permanent(errNoIngredients)
 	}
-
-}
-
-func pizzeria(pizzaMaker *Producer) {
-	var i = 0
-
-	for {
-		currentPizza := makePizza(i)
-
-		if currentPizza != nil {
-			i = currentPizza.pizzaNumber
-			select {
-			case pizzaMaker.data <- *currentPizza:
-			case quitChan := <- pizzaMaker.quit:
-				close(pizzaMaker.data)
-				close(quitChan)
-				return
-			}
-		}
+	if rnd < 5 {
+		return "", errNoPower
 	}
+
+	return fmt.Sprintf("Pizza order #%d is ready", order.pizzaNumber), nil
 }
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
 	color.Cyan("Pizza Order is Online")
 	color.Cyan("---------------------")
 
-	pizzaJob := &Producer{
-		data: make(chan PizzaOrder),
-		quit: make(chan chan error),
-	}
-
-	go pizzeria(pizzaJob)
-
-	for i := range pizzaJob.data{
-		if i.pizzaNumber <= NumberOfPizzas {
-			if i.success {
-				color.Green(i.message)
-				color.Green("Order #%d out for delivery", i.pizzaNumber)
-			} else {
-				color.Red(i.message)
-				color.Red("Failed to make pizza #%d", i.pizzaNumber)
-			}
+	pizzeria := pipeline.New(makePizza, pipeline.Config{
+		Consumers: NumberOfCooks,
+		QueueSize: 2,
+		Ordered:   true,
+		Retry:     pipeline.Retry{Attempts: 3, Backoff: 500 * time.Millisecond},
+	})
+
+	for res := range pizzeria.Run(ctx, takeOrders) {
+		if res.Err == nil {
+			color.Green(res.Output)
+			color.Green("Order #%d out for delivery", res.Input.pizzaNumber)
 		} else {
-
-			err := pizzaJob.Close()
-
-			if err != nil {
-				color.Red("Failed to close channel", err)
-			}
+			color.Red("Failed to make pizza #%d %v after %d attempts", res.Input.pizzaNumber, res.Err, res.Attempts)
 		}
 	}
 
+	if err := pizzeria.Err(); err != nil {
+		color.Red("Closed early: %v", err)
+	}
+
+	stats := pizzeria.Stats()
+
 	color.Cyan("----------------")
 	color.Cyan("Done for the day")
-	color.Cyan("Made total of %d pizzas out of which %d got failed", total, pizzasFailed)
+	color.Cyan("Made total of %d pizzas out of which %d got failed (%d retries)",
+		stats.Succeeded+stats.Failed, stats.Failed, stats.Retries)
 }
@@ -0,0 +1,339 @@
+// Package pipeline runs jobs from N producers through M consumers.
+//
+// Producers emit values into a bounded queue, so they block once consumers
+// fall behind. Each value is handled by one consumer, retried with
+// exponential backoff on failure, and its Result is sent on the channel
+// returned by Run, either as soon as it is ready or in the order the values
+// were emitted.
+//
+//	p := pipeline.New(bake, pipeline.Config{Consumers: 3, QueueSize: 5, Ordered: true})
+//	for res := range p.Run(ctx, orders) {
+//		...
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+//
+// The results channel must be drained until it is closed. When ctx is
+// cancelled producers and consumers stop, queued values are dropped and the
+// channel is closed.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Producer emits values until it is done or emit fails. emit blocks while
+// the queue is full and returns ctx's error once the pipeline stops.
+type Producer[T any] func(ctx context.Context, emit func(T) error) error
+
+// Handler processes one value.
+type Handler[T, R any] func(ctx context.Context, in T) (R, error)
+
+type Config struct {
+	// Consumers is the number of concurrent handlers, at least 1.
+	Consumers int
+	// QueueSize is how many emitted values may wait for a consumer.
+	QueueSize int
+	// Ordered delivers results in emit order. Out of order results are
+	// held back, at most QueueSize+Consumers of them.
+	Ordered bool
+	Retry   Retry
+}
+
+// Retry configures how failed jobs are retried. The zero value tries each
+// job once.
+type Retry struct {
+	// Attempts is the total number of tries, values below 1 mean 1.
+	Attempts int
+	// Backoff is the wait before the first retry, doubled for every
+	// further retry up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable decides whether an error is worth another try, every
+	// error is when nil. Errors wrapped with Permanent never are.
+	Retryable func(error) bool
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not retryable.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// Result is the outcome of one job.
+type Result[T, R any] struct {
+	// Seq is the position of the job in emit order, starting at 0.
+	Seq      uint64
+	Input    T
+	Output   R
+	Err      error
+	Attempts int
+}
+
+// Stats are counters of a run, safe to read while it is going.
+type Stats struct {
+	Emitted   uint64
+	Succeeded uint64
+	Failed    uint64
+	Retries   uint64
+	// Dropped counts queued jobs that were never processed because the
+	// pipeline was cancelled. Once a run is over Emitted equals
+	// Succeeded+Failed+Dropped.
+	Dropped uint64
+}
+
+type job[T any] struct {
+	seq   uint64
+	value T
+}
+
+type Pipeline[T, R any] struct {
+	handler Handler[T, R]
+	cfg     Config
+
+	seq       atomic.Uint64
+	emitted   atomic.Uint64
+	succeeded atomic.Uint64
+	failed    atomic.Uint64
+	retries   atomic.Uint64
+	dropped   atomic.Uint64
+
+	errOnce sync.Once
+	err     error
+}
+
+func New[T, R any](handler Handler[T, R], cfg Config) *Pipeline[T, R] {
+	if cfg.Consumers < 1 {
+		cfg.Consumers = 1
+	}
+	if cfg.QueueSize < 0 {
+		cfg.QueueSize = 0
+	}
+	if cfg.Retry.Attempts < 1 {
+		cfg.Retry.Attempts = 1
+	}
+	return &Pipeline[T, R]{handler: handler, cfg: cfg}
+}
+
+// Stats returns a snapshot of the counters.
+func (p *Pipeline[T, R]) Stats() Stats {
+	return Stats{
+		Emitted:   p.emitted.Load(),
+		Succeeded: p.succeeded.Load(),
+		Failed:    p.failed.Load(),
+		Retries:   p.retries.Load(),
+		Dropped:   p.dropped.Load(),
+	}
+}
+
+// Err returns the first producer error, or ctx's error if the run was
+// cancelled. It is only meaningful after the results channel is closed.
+func (p *Pipeline[T, R]) Err() error {
+	return p.err
+}
+
+func (p *Pipeline[T, R]) setErr(err error) {
+	p.errOnce.Do(func() { p.err = err })
+}
+
+// Run starts the producers and consumers. A Pipeline runs once.
+func (p *Pipeline[T, R]) Run(ctx context.Context, producers ...Producer[T]) <-chan Result[T, R] {
+	ctx, cancel := context.WithCancel(ctx)
+
+	queue := make(chan job[T], p.cfg.QueueSize)
+	results := make(chan Result[T, R])
+
+	// In ordered mode window limits how far emitting may run ahead of
+	// delivery, which bounds the reorder buffer.
+	var window chan struct{}
+	if p.cfg.Ordered {
+		window = make(chan struct{}, p.cfg.QueueSize+p.cfg.Consumers)
+	}
+
+	emit := func(v T) error {
+		if window != nil {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// The seq is taken after the window slot so sequence numbers
+		// are handed out in the order jobs enter the queue.
+		j := job[T]{seq: p.seq.Add(1) - 1, value: v}
+		select {
+		case queue <- j:
+			p.emitted.Add(1)
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	var producersWG sync.WaitGroup
+	for _, produce := range producers {
+		producersWG.Add(1)
+		go func(produce Producer[T]) {
+			defer producersWG.Done()
+			if err := produce(ctx, emit); err != nil && !errors.Is(err, context.Canceled) {
+				p.setErr(err)
+				cancel()
+			}
+		}(produce)
+	}
+	go func() {
+		producersWG.Wait()
+		close(queue)
+	}()
+
+	out := results
+	if p.cfg.Ordered {
+		out = make(chan Result[T, R])
+	}
+
+	var consumersWG sync.WaitGroup
+	for i := 0; i < p.cfg.Consumers; i++ {
+		consumersWG.Add(1)
+		go func() {
+			defer consumersWG.Done()
+			p.consume(ctx, queue, out)
+		}()
+	}
+
+	if p.cfg.Ordered {
+		go func() {
+			consumersWG.Wait()
+			close(out)
+		}()
+		go func() {
+			p.reorder(ctx, out, results, window)
+			p.finish(ctx, cancel)
+			close(results)
+		}()
+	} else {
+		go func() {
+			consumersWG.Wait()
+			p.finish(ctx, cancel)
+			close(results)
+		}()
+	}
+
+	return results
+}
+
+func (p *Pipeline[T, R]) finish(ctx context.Context, cancel context.CancelFunc) {
+	if err := ctx.Err(); err != nil {
+		p.setErr(err)
+	}
+	cancel()
+}
+
+func (p *Pipeline[T, R]) consume(ctx context.Context, queue <-chan job[T], out chan<- Result[T, R]) {
+	for j := range queue {
+		if ctx.Err() != nil {
+			p.dropped.Add(1)
+			continue
+		}
+
+		res := p.process(ctx, j)
+
+		select {
+		case out <- res:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func (p *Pipeline[T, R]) process(ctx context.Context, j job[T]) Result[T, R] {
+	res := Result[T, R]{Seq: j.seq, Input: j.value}
+	backoff := p.cfg.Retry.Backoff
+
+	for {
+		res.Attempts++
+		res.Output, res.Err = p.handler(ctx, j.value)
+		if res.Err == nil {
+			p.succeeded.Add(1)
+			return res
+		}
+
+		if res.Attempts >= p.cfg.Retry.Attempts || !p.retryable(res.Err) || ctx.Err() != nil {
+			p.failed.Add(1)
+			return res
+		}
+
+		if !sleep(ctx, backoff) {
+			p.failed.Add(1)
+			return res
+		}
+		p.retries.Add(1)
+
+		backoff *= 2
+		if max := p.cfg.Retry.MaxBackoff; max > 0 && backoff > max {
+			backoff = max
+		}
+	}
+}
+
+func (p *Pipeline[T, R]) retryable(err error) bool {
+	var perm permanentError
+	if errors.As(err, &perm) {
+		return false
+	}
+	return p.cfg.Retry.Retryable == nil || p.cfg.Retry.Retryable(err)
+}
+
+// reorder sends results in seq order, holding back those that arrive
+// early. Each delivered result frees a window slot for the producers.
+// After a cancel, results behind a missing seq are never sent.
+func (p *Pipeline[T, R]) reorder(ctx context.Context, in <-chan Result[T, R], out chan<- Result[T, R], window <-chan struct{}) {
+	pending := map[uint64]Result[T, R]{}
+	var next uint64
+
+	for res := range in {
+		pending[res.Seq] = res
+
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			select {
+			case out <- r:
+			case <-ctx.Done():
+			}
+			<-window
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,257 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func counter(from, n int) Producer[int] {
+	return func(ctx context.Context, emit func(int) error) error {
+		for i := from; i < from+n; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func double(_ context.Context, in int) (int, error) {
+	return in * 2, nil
+}
+
+func collect[T, R any](ch <-chan Result[T, R]) []Result[T, R] {
+	var out []Result[T, R]
+	for res := range ch {
+		out = append(out, res)
+	}
+	return out
+}
+
+func TestRunUnordered(t *testing.T) {
+	p := New(double, Config{Consumers: 4, QueueSize: 2})
+	results := collect(p.Run(context.Background(), counter(0, 50), counter(50, 50)))
+
+	if err := p.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(results) != 100 {
+		t.Fatalf("got %d results, want 100", len(results))
+	}
+
+	seen := map[int]bool{}
+	for _, res := range results {
+		if res.Err != nil || res.Output != res.Input*2 {
+			t.Errorf("result %+v", res)
+		}
+		seen[res.Input] = true
+	}
+	if len(seen) != 100 {
+		t.Errorf("got %d distinct inputs, want 100", len(seen))
+	}
+
+	stats := p.Stats()
+	if stats.Emitted != 100 || stats.Succeeded != 100 || stats.Failed != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRunOrdered(t *testing.T) {
+	// Later inputs finish first, so ordering has to be restored.
+	slow := func(_ context.Context, in int) (int, error) {
+		time.Sleep(time.Duration(20-in%20) * time.Millisecond / 10)
+		return in, nil
+	}
+
+	p := New(slow, Config{Consumers: 8, QueueSize: 4, Ordered: true})
+	results := collect(p.Run(context.Background(), counter(0, 200)))
+
+	if len(results) != 200 {
+		t.Fatalf("got %d results, want 200", len(results))
+	}
+	for i, res := range results {
+		if res.Seq != uint64(i) || res.Input != i {
+			t.Fatalf("result %d = seq %d input %d", i, res.Seq, res.Input)
+		}
+	}
+}
+
+func TestOrderedWindowBoundsInFlight(t *testing.T) {
+	const consumers, queue = 2, 3
+
+	// Job 0 blocks until released, so every later result must be held
+	// back and producers stall once the window is full.
+	release := make(chan struct{})
+	handler := func(_ context.Context, in int) (int, error) {
+		if in == 0 {
+			<-release
+		}
+		return in, nil
+	}
+
+	p := New(handler, Config{Consumers: consumers, QueueSize: queue, Ordered: true})
+	results := p.Run(context.Background(), counter(0, 100))
+
+	time.Sleep(50 * time.Millisecond)
+	if got := p.Stats().Emitted; got > consumers+queue {
+		t.Errorf("emitted %d jobs while job 0 blocked, want at most %d", got, consumers+queue)
+	}
+
+	close(release)
+	if n := len(collect(results)); n != 100 {
+		t.Errorf("got %d results, want 100", n)
+	}
+}
+
+func TestBackpressure(t *testing.T) {
+	block := make(chan struct{})
+	handler := func(_ context.Context, in int) (int, error) {
+		<-block
+		return in, nil
+	}
+
+	p := New(handler, Config{Consumers: 1, QueueSize: 2})
+	results := p.Run(context.Background(), counter(0, 10))
+
+	time.Sleep(50 * time.Millisecond)
+	// One job in the handler and two waiting in the queue.
+	if got := p.Stats().Emitted; got != 3 {
+		t.Errorf("emitted %d jobs with a blocked consumer, want 3", got)
+	}
+
+	close(block)
+	collect(results)
+}
+
+func TestRetry(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[int]int{}
+
+	flaky := func(_ context.Context, in int) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[in]++
+		if calls[in] < 3 {
+			return 0, errors.New("no power")
+		}
+		return in, nil
+	}
+
+	p := New(flaky, Config{Consumers: 3, Retry: Retry{Attempts: 3, Backoff: time.Millisecond}})
+	for res := range p.Run(context.Background(), counter(0, 10)) {
+		if res.Err != nil || res.Attempts != 3 {
+			t.Errorf("input %d: err %v after %d attempts", res.Input, res.Err, res.Attempts)
+		}
+	}
+
+	stats := p.Stats()
+	if stats.Succeeded != 10 || stats.Retries != 20 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	errOut := errors.New("ran out of ingredients")
+	var calls atomic.Int32
+
+	tests := []struct {
+		name    string
+		err     error
+		retry   Retry
+		attempt int
+	}{
+		{"exhausted", errOut, Retry{Attempts: 4}, 4},
+		{"permanent", Permanent(errOut), Retry{Attempts: 4}, 1},
+		{"not retryable", errOut, Retry{Attempts: 4, Retryable: func(err error) bool { return false }}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls.Store(0)
+			failing := func(context.Context, int) (int, error) {
+				calls.Add(1)
+				return 0, tt.err
+			}
+
+			p := New(failing, Config{Retry: tt.retry})
+			results := collect(p.Run(context.Background(), counter(0, 1)))
+
+			if len(results) != 1 || !errors.Is(results[0].Err, errOut) {
+				t.Fatalf("results = %+v", results)
+			}
+			if results[0].Attempts != tt.attempt || int(calls.Load()) != tt.attempt {
+				t.Errorf("attempts = %d, calls = %d, want %d", results[0].Attempts, calls.Load(), tt.attempt)
+			}
+			if p.Stats().Failed != 1 {
+				t.Errorf("stats = %+v", p.Stats())
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	handler := func(ctx context.Context, in int) (int, error) {
+		select {
+		case <-time.After(time.Millisecond):
+			return in, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	endless := func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, ordered := range []bool{false, true} {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := New(handler, Config{Consumers: 4, QueueSize: 8, Ordered: ordered, Retry: Retry{Attempts: 5, Backoff: time.Hour}})
+
+		n := 0
+		for range p.Run(ctx, endless) {
+			if n++; n == 20 {
+				cancel()
+			}
+		}
+
+		if !errors.Is(p.Err(), context.Canceled) {
+			t.Errorf("ordered=%v: Err() = %v, want context.Canceled", ordered, p.Err())
+		}
+		stats := p.Stats()
+		if stats.Emitted != stats.Succeeded+stats.Failed+stats.Dropped {
+			t.Errorf("ordered=%v: stats don't add up: %+v", ordered, stats)
+		}
+		cancel()
+	}
+}
+
+func TestProducerError(t *testing.T) {
+	errBroken := errors.New("oven broken")
+	broken := func(ctx context.Context, emit func(int) error) error {
+		if err := emit(1); err != nil {
+			return err
+		}
+		return errBroken
+	}
+	endless := func(ctx context.Context, emit func(int) error) error {
+		for {
+			if err := emit(0); err != nil {
+				return err
+			}
+		}
+	}
+
+	p := New(double, Config{Consumers: 2})
+	collect(p.Run(context.Background(), broken, endless))
+
+	if !errors.Is(p.Err(), errBroken) {
+		t.Errorf("Err() = %v, want %v", p.Err(), errBroken)
+	}
+}
@@ -37,7 +37,7 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Add some values to the context (simulating middleware adding auth info)
-	ctx = context.WithValue(ctx, "requestID", time.Now().UnixNano())
+	ctx = context.WithValue(ctx, requestIDKey, time.Now().UnixNano())
 
 	// Perform the slow operation
 	result, err := slowOperation(ctx)
@@ -47,7 +47,7 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the request ID from context
-	requestID := ctx.Value("requestID").(int64)
+	requestID := ctx.Value(requestIDKey).(int64)
 
 	// Prepare and send response
 	response := Response{
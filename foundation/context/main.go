@@ -66,11 +66,21 @@ func cancelExample() {
 	time.Sleep(100 * time.Millisecond) // Wait to see the cancellation effect
 }
 
+// contextKey is unexported so no other package can build the same key,
+// a plain string key like "userID" could collide with one set elsewhere.
+type contextKey int
+
+const (
+	userIDKey contextKey = iota
+	authTokenKey
+	requestIDKey
+)
+
 func valueExample() {
 	// Create a context with some values
 	ctx := context.Background()
-	ctx = context.WithValue(ctx, "userID", "123")
-	ctx = context.WithValue(ctx, "authToken", "xyz789")
+	ctx = context.WithValue(ctx, userIDKey, "123")
+	ctx = context.WithValue(ctx, authTokenKey, "xyz789")
 
 	// Simulate passing context through function calls
 	processRequest(ctx)
@@ -78,13 +88,13 @@ func valueExample() {
 
 func processRequest(ctx context.Context) {
 	// Retrieve values from context
-	userID, ok := ctx.Value("userID").(string)
+	userID, ok := ctx.Value(userIDKey).(string)
 	if !ok {
 		log.Println("userID not found in context")
 		return
 	}
 
-	authToken, ok := ctx.Value("authToken").(string)
+	authToken, ok := ctx.Value(authTokenKey).(string)
 	if !ok {
 		log.Println("authToken not found in context")
 		return
@@ -0,0 +1,80 @@
+package deb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A .deb is an ar archive in the common format: the magic, then for every
+// member a 60 byte header and the data, padded to an even length.
+
+const arMagic = "!<arch>\n"
+
+type arMember struct {
+	Name string
+	Data []byte
+}
+
+func writeAr(w io.Writer, modTime time.Time, members []arMember) error {
+	if _, err := io.WriteString(w, arMagic); err != nil {
+		return err
+	}
+	for _, m := range members {
+		if len(m.Name) > 16 {
+			return fmt.Errorf("ar member name %q is longer than 16 bytes", m.Name)
+		}
+		header := fmt.Sprintf("%-16s%-12d%-6d%-6d%-8o%-10d`\n", m.Name, modTime.Unix(), 0, 0, 0o100644, len(m.Data))
+		if _, err := io.WriteString(w, header); err != nil {
+			return err
+		}
+		if _, err := w.Write(m.Data); err != nil {
+			return err
+		}
+		if len(m.Data)%2 == 1 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readAr(data []byte) ([]arMember, error) {
+	if !bytes.HasPrefix(data, []byte(arMagic)) {
+		return nil, fmt.Errorf("not an ar archive")
+	}
+	data = data[len(arMagic):]
+
+	var members []arMember
+	for len(data) > 0 {
+		if len(data) < 60 {
+			return nil, fmt.Errorf("truncated ar header")
+		}
+		header := data[:60]
+		if string(header[58:60]) != "`\n" {
+			return nil, fmt.Errorf("bad ar header magic")
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(string(header[48:58])))
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("bad ar member size %q", header[48:58])
+		}
+		data = data[60:]
+		if len(data) < size {
+			return nil, fmt.Errorf("truncated ar member")
+		}
+
+		// GNU ar ends names with a slash, dpkg-deb pads them with spaces
+		name := strings.TrimSuffix(strings.TrimRight(string(header[:16]), " "), "/")
+		members = append(members, arMember{Name: name, Data: data[:size]})
+
+		data = data[size:]
+		if size%2 == 1 && len(data) > 0 {
+			data = data[1:]
+		}
+	}
+	return members, nil
+}
@@ -0,0 +1,268 @@
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const unitDir = "/lib/systemd/system/"
+
+type Options struct {
+	// ModTime stamps every entry of the package, the current time when
+	// zero. Set it from SOURCE_DATE_EPOCH for reproducible builds.
+	ModTime time.Time
+}
+
+// entry is a file of the package. Paths have no leading slash,
+// directories end with one.
+type entry struct {
+	Path string
+	Mode int64
+	Data []byte
+}
+
+// Build cross-compiles the binaries of s for arch and writes the package
+// to w.
+func Build(ctx context.Context, s *Spec, arch string, w io.Writer, opts Options) error {
+	goarch, ok := Architectures[arch]
+	if !ok {
+		return fmt.Errorf("architecture %q is not supported", arch)
+	}
+	if arch == "all" && len(s.Binaries) > 0 {
+		return fmt.Errorf("architecture all cannot have binaries")
+	}
+	if opts.ModTime.IsZero() {
+		opts.ModTime = time.Now()
+	}
+
+	tmp, err := os.MkdirTemp("", "debbuild-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	var files []entry
+	for _, b := range s.Binaries {
+		out := filepath.Join(tmp, b.Name)
+		if err := compile(ctx, s, b, goarch, out); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			return err
+		}
+		files = append(files, entry{Path: b.dst()[1:], Mode: 0o755, Data: data})
+	}
+	for _, f := range s.Files {
+		data, err := os.ReadFile(s.path(f.Src))
+		if err != nil {
+			return err
+		}
+		mode, _ := f.mode()
+		files = append(files, entry{Path: f.Dst[1:], Mode: mode, Data: data})
+	}
+	for _, u := range s.Units {
+		data, err := os.ReadFile(s.path(u))
+		if err != nil {
+			return err
+		}
+		files = append(files, entry{Path: unitDir[1:] + filepath.Base(u), Mode: 0o644, Data: data})
+	}
+	slices.SortFunc(files, func(a, b entry) int { return strings.Compare(a.Path, b.Path) })
+
+	data := append(parentDirs(files), files...)
+	dataTar, err := writeTarGz(data, opts.ModTime)
+	if err != nil {
+		return fmt.Errorf("data.tar: %w", err)
+	}
+
+	control, err := s.controlFiles(arch, data)
+	if err != nil {
+		return err
+	}
+	controlTar, err := writeTarGz(append([]entry{{Path: "", Mode: 0o755}}, control...), opts.ModTime)
+	if err != nil {
+		return fmt.Errorf("control.tar: %w", err)
+	}
+
+	return writeAr(w, opts.ModTime, []arMember{
+		{Name: "debian-binary", Data: []byte("2.0\n")},
+		{Name: "control.tar.gz", Data: controlTar},
+		{Name: "data.tar.gz", Data: dataTar},
+	})
+}
+
+// BuildFile builds the package for arch into dir, under its conventional
+// file name, and returns its path.
+func BuildFile(ctx context.Context, s *Spec, arch, dir string, opts Options) (string, error) {
+	var buf bytes.Buffer
+	if err := Build(ctx, s, arch, &buf, opts); err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, s.Filename(arch))
+	return file, os.WriteFile(file, buf.Bytes(), 0o644)
+}
+
+func compile(ctx context.Context, s *Spec, b Binary, goarch, out string) error {
+	pkg := b.Package
+	if pkg == "" {
+		pkg = "."
+	}
+	ldflags := "-s -w"
+	if b.Ldflags != "" {
+		ldflags += " " + b.Ldflags
+	}
+
+	cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-ldflags", ldflags, "-o", out, pkg)
+	cmd.Dir = s.path(".")
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS=linux", "GOARCH="+goarch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("go build %s for %s: %w\n%s", pkg, goarch, err, output)
+	}
+	return nil
+}
+
+// parentDirs returns every directory holding files, parents first, so
+// the tar extracts in order.
+func parentDirs(files []entry) []entry {
+	seen := map[string]bool{}
+	for _, f := range files {
+		for dir := path.Dir(f.Path); dir != "."; dir = path.Dir(dir) {
+			seen[dir+"/"] = true
+		}
+	}
+	dirs := []entry{{Path: "", Mode: 0o755}}
+	for _, dir := range slices.Sorted(maps.Keys(seen)) {
+		dirs = append(dirs, entry{Path: dir, Mode: 0o755})
+	}
+	return dirs
+}
+
+func (s *Spec) controlFiles(arch string, data []entry) ([]entry, error) {
+	var installedSize int
+	var md5sums strings.Builder
+	for _, e := range data {
+		if strings.HasSuffix(e.Path, "/") || e.Path == "" {
+			installedSize++
+			continue
+		}
+		installedSize += (len(e.Data) + 1023) / 1024
+		// Like dh_md5sums, conffiles are checked through the conffiles
+		// list instead
+		if !s.isConffile("/" + e.Path) {
+			sum := md5.Sum(e.Data)
+			fmt.Fprintf(&md5sums, "%s  %s\n", hex.EncodeToString(sum[:]), e.Path)
+		}
+	}
+
+	control := []entry{{Path: "control", Mode: 0o644, Data: []byte(s.control(arch, installedSize))}}
+	if len(s.Conffiles) > 0 {
+		control = append(control, entry{Path: "conffiles", Mode: 0o644, Data: []byte(strings.Join(s.Conffiles, "\n") + "\n")})
+	}
+	if md5sums.Len() > 0 {
+		control = append(control, entry{Path: "md5sums", Mode: 0o644, Data: []byte(md5sums.String())})
+	}
+
+	snippets := unitSnippets(s.Units)
+	for _, script := range s.Scripts.byName() {
+		name, src := script[0], script[1]
+		var user []byte
+		if src != "" {
+			var err error
+			if user, err = os.ReadFile(s.path(src)); err != nil {
+				return nil, err
+			}
+		}
+		data, err := mergeScript(name, user, snippets[name])
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			control = append(control, entry{Path: name, Mode: 0o755, Data: data})
+		}
+	}
+	return control, nil
+}
+
+func (s *Spec) control(arch string, installedSize int) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	field("Package", s.Name)
+	field("Version", s.Version)
+	field("Architecture", arch)
+	field("Maintainer", s.Maintainer)
+	field("Installed-Size", fmt.Sprint(installedSize))
+	field("Depends", strings.Join(s.Depends, ", "))
+	field("Section", s.Section)
+	field("Priority", s.Priority)
+	field("Homepage", s.Homepage)
+	field("Description", s.Synopsis())
+
+	// The extended description is indented by one space, "." stands
+	// for an empty line
+	if _, extended, ok := strings.Cut(strings.TrimSpace(s.Description), "\n"); ok {
+		for _, line := range strings.Split(extended, "\n") {
+			if line = strings.TrimRight(line, " \t"); line == "" {
+				line = "."
+			}
+			b.WriteString(" " + line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func writeTarGz(entries []entry, modTime time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		header := &tar.Header{
+			Name:    "./" + e.Path,
+			Mode:    e.Mode,
+			Size:    int64(len(e.Data)),
+			ModTime: modTime.Truncate(time.Second),
+			Uname:   "root",
+			Gname:   "root",
+			Format:  tar.FormatGNU,
+		}
+		if e.Path == "" || strings.HasSuffix(e.Path, "/") {
+			header.Typeflag = tar.TypeDir
+		} else {
+			header.Typeflag = tar.TypeReg
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(e.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,161 @@
+package deb
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// helloSpec writes specYAML and everything it refers to, a Go main
+// package included, and loads it.
+func helloSpec(t *testing.T) *Spec {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":        "module hello\n\ngo 1.21\n",
+		"main.go":       "package main\n\nfunc main() { println(\"hello\") }\n",
+		"hello.conf":    "greeting=hello\n",
+		"hello.service": "[Service]\nExecStart=/usr/bin/hello\n\n[Install]\nWantedBy=multi-user.target\n",
+		"postinst":      "#!/bin/sh\nset -e\n\nadduser --system hello\n\n#DEBBUILD#\n\nexit 0\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := LoadSpec(writeSpec(t, dir, specYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestBuild(t *testing.T) {
+	s := helloSpec(t)
+	opts := Options{ModTime: time.Date(2025, 5, 10, 12, 0, 0, 0, time.UTC)}
+
+	for arch, machine := range elfMachines {
+		var buf bytes.Buffer
+		if err := Build(context.Background(), s, arch, &buf, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := Read(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Control["Architecture"] != arch || c.Control["Version"] != "1:2.0.1-3" || c.Control["Depends"] != "adduser" {
+			t.Errorf("control = %v", c.Control)
+		}
+		if want := "Prints a greeting.\n.\nNothing else."; c.Control["Description"] != "Says hello\n"+want {
+			t.Errorf("description = %q", c.Control["Description"])
+		}
+
+		files := map[string]TarFile{}
+		for _, f := range c.Files {
+			files[f.Path()] = f
+		}
+		for p, mode := range map[string]int64{
+			"/usr/bin/hello":                    0o755,
+			"/etc/hello/hello.conf":             0o644,
+			"/usr/share/hello/example.conf":     0o600,
+			"/lib/systemd/system/hello.service": 0o644,
+			"/etc/hello":                        0o755,
+		} {
+			if f, ok := files[p]; !ok || f.Header.Mode != mode || !f.Header.ModTime.Equal(opts.ModTime) {
+				t.Errorf("%s: %+v", p, f.Header)
+			}
+		}
+
+		bin, err := elf.NewFile(bytes.NewReader(files["/usr/bin/hello"].Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bin.Machine != machine {
+			t.Errorf("%s binary is for %s", arch, bin.Machine)
+		}
+
+		if got := string(c.Scripts["conffiles"].Data); got != "/etc/hello/hello.conf\n" {
+			t.Errorf("conffiles = %q", got)
+		}
+		md5sums := string(c.Scripts["md5sums"].Data)
+		if !strings.Contains(md5sums, "  usr/bin/hello\n") || strings.Contains(md5sums, "hello.conf\n") {
+			t.Errorf("md5sums = %q", md5sums)
+		}
+
+		postinst := string(c.Scripts["postinst"].Data)
+		if !strings.Contains(postinst, "adduser --system hello\n\n# Added by debbuild") || strings.Contains(postinst, scriptToken) {
+			t.Errorf("postinst = %q", postinst)
+		}
+		if prerm := c.Scripts["prerm"]; !strings.HasPrefix(string(prerm.Data), "#!/bin/sh\nset -e\n") || prerm.Header.Mode != 0o755 {
+			t.Errorf("prerm = %q mode %o", prerm.Data, prerm.Header.Mode)
+		}
+
+		for _, p := range Lint(buf.Bytes()) {
+			if p.Severity == Error {
+				t.Errorf("%s: %s", arch, p)
+			}
+		}
+	}
+}
+
+func TestBuildReproducible(t *testing.T) {
+	s := helloSpec(t)
+	dir := t.TempDir()
+	opts := Options{ModTime: time.Unix(1746878400, 0)}
+
+	first, err := BuildFile(context.Background(), s, "amd64", dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.ReadFile(first)
+
+	var b bytes.Buffer
+	if err := Build(context.Background(), s, "amd64", &b, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b.Bytes()) {
+		t.Error("two builds of the same spec differ")
+	}
+
+	// dpkg reads what we write, when it is around to ask
+	if _, err := exec.LookPath("dpkg-deb"); err != nil {
+		t.Skip("dpkg-deb not installed")
+	}
+	out, err := exec.Command("dpkg-deb", "--info", first).CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "Package: hello") {
+		t.Errorf("dpkg-deb --info: %v\n%s", err, out)
+	}
+	out, err = exec.Command("dpkg-deb", "--contents", first).CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "./usr/bin/hello") {
+		t.Errorf("dpkg-deb --contents: %v\n%s", err, out)
+	}
+}
+
+func TestMergeScript(t *testing.T) {
+	for _, tc := range []struct {
+		user, snippet, want string
+	}{
+		{"", "", ""},
+		{"", "run\n", "#!/bin/sh\nset -e\n\nrun\n"},
+		{"#!/bin/sh\nexit 0\n", "", "#!/bin/sh\nexit 0\n"},
+		{"#!/bin/sh\nexit 0\n", "run\n", "#!/bin/sh\nrun\nexit 0\n"},
+		{"#!/bin/sh\nA\n  #DEBBUILD#\nB\n", "run\n", "#!/bin/sh\nA\nrun\nB\n"},
+		{"#!/bin/sh\n#DEBBUILD#\n", "", "#!/bin/sh\n"},
+	} {
+		got, err := mergeScript("postinst", []byte(tc.user), tc.snippet)
+		if err != nil || string(got) != tc.want {
+			t.Errorf("mergeScript(%q, %q) = %q, %v, want %q", tc.user, tc.snippet, got, err, tc.want)
+		}
+	}
+
+	if _, err := mergeScript("postinst", []byte("adduser hello\n"), ""); err == nil {
+		t.Error("script without #! accepted")
+	}
+}
@@ -0,0 +1,276 @@
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/md5"
+	"debug/elf"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "E"
+	Warning Severity = "W"
+)
+
+// Problem is a lint finding. Tags follow lintian where it has one for
+// the same mistake.
+type Problem struct {
+	Severity Severity
+	Tag      string
+	Detail   string
+}
+
+func (p Problem) String() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%s: %s", p.Severity, p.Tag)
+	}
+	return fmt.Sprintf("%s: %s %s", p.Severity, p.Tag, p.Detail)
+}
+
+// elfMachines are the machines binaries of each architecture are built
+// for.
+var elfMachines = map[string]elf.Machine{
+	"amd64": elf.EM_X86_64,
+	"arm64": elf.EM_AARCH64,
+}
+
+var maintainerScripts = []string{"preinst", "postinst", "prerm", "postrm", "config"}
+
+// Lint checks a .deb for the mistakes that break installs or that
+// lintian would report: a broken archive, control fields, md5sums,
+// conffiles, maintainer scripts, ownership and binaries built for
+// another architecture.
+func Lint(data []byte) []Problem {
+	c, err := Read(data)
+	if err != nil {
+		return []Problem{{Error, "malformed-deb", err.Error()}}
+	}
+
+	l := &linter{c: c, arch: c.Control["Architecture"]}
+	l.control()
+	l.files()
+	l.md5sums()
+	l.conffiles()
+	l.scripts()
+	return l.problems
+}
+
+type linter struct {
+	c        *Contents
+	arch     string
+	problems []Problem
+}
+
+func (l *linter) report(severity Severity, tag, format string, args ...any) {
+	l.problems = append(l.problems, Problem{severity, tag, fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) control() {
+	fields := l.c.Control
+	for _, name := range []string{"Package", "Version", "Architecture", "Maintainer", "Description"} {
+		if fields[name] == "" {
+			l.report(Error, "missing-control-field", "%s", name)
+		}
+	}
+
+	if name := fields["Package"]; name != "" && !nameRe.MatchString(name) {
+		l.report(Error, "invalid-package-name", "%s", name)
+	}
+	if version := fields["Version"]; version != "" && !versionRe.MatchString(version) {
+		l.report(Error, "invalid-version", "%s", version)
+	}
+	if maintainer := fields["Maintainer"]; maintainer != "" && !maintainerRe.MatchString(maintainer) {
+		l.report(Error, "malformed-contact", "Maintainer %s", maintainer)
+	}
+	if fields["Installed-Size"] == "" {
+		l.report(Warning, "no-installed-size", "")
+	}
+
+	synopsis, extended, _ := strings.Cut(fields["Description"], "\n")
+	if len(synopsis) > 80 {
+		l.report(Warning, "synopsis-too-long", "%d characters", len(synopsis))
+	}
+	if name := fields["Package"]; name != "" && strings.HasPrefix(strings.ToLower(synopsis), name+" ") {
+		l.report(Warning, "description-synopsis-starts-with-package-name", "")
+	}
+	if synopsis != "" && strings.TrimSpace(extended) == "" {
+		l.report(Warning, "extended-description-is-empty", "")
+	}
+}
+
+func (l *linter) files() {
+	conffiles := l.conffileList()
+	hasUnits, hasCopyright := false, false
+
+	for _, f := range l.c.Files {
+		h, p := f.Header, f.Path()
+		if !strings.HasPrefix(h.Name, "./") {
+			l.report(Error, "tar-entry-not-relative", "%s", h.Name)
+		}
+		if h.Uid != 0 || h.Gid != 0 {
+			l.report(Error, "wrong-file-owner-uid-or-gid", "%s %d/%d", p, h.Uid, h.Gid)
+		}
+		if strings.HasPrefix(p, "/usr/local/") {
+			l.report(Error, "dir-or-file-in-usr-local", "%s", p)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if strings.HasPrefix(p, "/etc/") && !slices.Contains(conffiles, p) {
+			l.report(Warning, "file-in-etc-not-marked-as-conffile", "%s", p)
+		}
+		if strings.HasPrefix(p, unitDir) {
+			hasUnits = true
+		}
+		if p == "/usr/share/doc/"+l.c.Control["Package"]+"/copyright" {
+			hasCopyright = true
+		}
+
+		inBin := false
+		for _, dir := range []string{"/bin/", "/sbin/", "/usr/bin/", "/usr/sbin/"} {
+			inBin = inBin || strings.HasPrefix(p, dir)
+		}
+		if inBin && h.Mode&0o7777 != 0o755 {
+			l.report(Warning, "non-standard-executable-perm", "%s %04o != 0755", p, h.Mode&0o7777)
+		}
+
+		l.binary(p, f.Data)
+	}
+
+	if !hasCopyright {
+		l.report(Warning, "no-copyright-file", "")
+	}
+	if hasUnits {
+		postinst := string(l.c.Scripts["postinst"].Data)
+		if !strings.Contains(postinst, "systemctl enable") && !strings.Contains(postinst, "deb-systemd-helper") {
+			l.report(Warning, "systemd-unit-not-enabled", "postinst never enables the units")
+		}
+	}
+}
+
+// binary checks an ELF file against the architecture of the package.
+func (l *linter) binary(p string, data []byte) {
+	if !bytes.HasPrefix(data, []byte(elf.ELFMAG)) {
+		return
+	}
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		l.report(Error, "malformed-elf", "%s: %v", p, err)
+		return
+	}
+	defer f.Close()
+
+	if l.arch == "all" {
+		l.report(Error, "arch-independent-package-contains-binary-or-object", "%s", p)
+		return
+	}
+	if want, ok := elfMachines[l.arch]; ok && f.Machine != want {
+		l.report(Error, "binary-from-other-architecture", "%s is %s, package is %s", p, f.Machine, l.arch)
+	}
+
+	// Static Go binaries need nothing, cgo ones need their libraries
+	if libs, _ := f.ImportedLibraries(); len(libs) > 0 && l.c.Control["Depends"] == "" {
+		l.report(Warning, "missing-depends-line", "%s links %s", p, strings.Join(libs, ", "))
+	}
+}
+
+func (l *linter) md5sums() {
+	md5sums, ok := l.c.Scripts["md5sums"]
+	conffiles := l.conffileList()
+
+	data := map[string][]byte{}
+	for _, f := range l.c.Files {
+		if f.Header.Typeflag == tar.TypeReg {
+			data[f.Path()] = f.Data
+		}
+	}
+	if !ok {
+		if len(data) > len(conffiles) {
+			l.report(Warning, "no-md5sums-control-file", "")
+		}
+		return
+	}
+
+	listed := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(string(md5sums.Data)), "\n") {
+		sum, file, ok := strings.Cut(line, "  ")
+		if !ok {
+			l.report(Error, "malformed-md5sums-control-file", "%q", line)
+			continue
+		}
+		p := "/" + strings.TrimPrefix(file, "/")
+		listed[p] = true
+
+		content, ok := data[p]
+		if !ok {
+			l.report(Error, "md5sums-lists-nonexistent-file", "%s", p)
+			continue
+		}
+		actual := md5.Sum(content)
+		if hex.EncodeToString(actual[:]) != sum {
+			l.report(Error, "md5sum-mismatch", "%s", p)
+		}
+	}
+
+	for p := range data {
+		if !listed[p] && !slices.Contains(conffiles, p) {
+			l.report(Warning, "file-missing-in-md5sums", "%s", p)
+		}
+	}
+}
+
+func (l *linter) conffiles() {
+	installed := map[string]bool{}
+	for _, f := range l.c.Files {
+		installed[f.Path()] = f.Header.Typeflag == tar.TypeReg
+	}
+	for _, c := range l.conffileList() {
+		if !installed[c] {
+			l.report(Error, "conffile-is-not-in-package", "%s", c)
+		}
+		if !strings.HasPrefix(c, "/etc/") {
+			l.report(Warning, "non-etc-file-marked-as-conffile", "%s", c)
+		}
+	}
+}
+
+// conffileList returns the paths of the conffiles file, without the
+// flags dpkg allows before them.
+func (l *linter) conffileList() []string {
+	var list []string
+	for _, line := range strings.Split(string(l.c.Scripts["conffiles"].Data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			list = append(list, fields[len(fields)-1])
+		}
+	}
+	return list
+}
+
+func (l *linter) scripts() {
+	for _, name := range maintainerScripts {
+		f, ok := l.c.Scripts[name]
+		if !ok {
+			continue
+		}
+		if !bytes.HasPrefix(f.Data, []byte("#!")) {
+			l.report(Error, "maintainer-script-lacks-shebang", "%s", name)
+			continue
+		}
+		if f.Header.Mode&0o111 == 0 {
+			l.report(Error, "control-file-has-bad-permissions", "%s %04o != 0755", name, f.Header.Mode&0o7777)
+		}
+
+		shebang, body, _ := strings.Cut(string(f.Data), "\n")
+		if strings.Contains(shebang, "sh") && !strings.Contains(shebang, " -e") && !strings.Contains(body, "set -e") {
+			l.report(Warning, "maintainer-script-ignores-errors", "%s", name)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package deb
+
+import (
+	"bytes"
+	"crypto/md5"
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeELF returns an ELF header for machine, enough for debug/elf.
+func fakeELF(machine elf.Machine) []byte {
+	h := elf.Header64{Type: uint16(elf.ET_EXEC), Machine: uint16(machine), Version: 1, Ehsize: 64}
+	copy(h.Ident[:], elf.ELFMAG)
+	h.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	h.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	h.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, h)
+	return buf.Bytes()
+}
+
+// testPackage is a valid package the lint tests break one way at a time.
+type testPackage struct {
+	control   string
+	scripts   []entry
+	files     []entry
+	conffiles []string
+	// md5sums is computed from files when nil.
+	md5sums []byte
+	members []string
+}
+
+func newTestPackage() *testPackage {
+	return &testPackage{
+		control: "Package: hello\nVersion: 1.0-1\nArchitecture: amd64\nMaintainer: Jane Doe <jane@example.com>\n" +
+			"Installed-Size: 2\nDescription: Says hello\n Prints a greeting.\n",
+		scripts: []entry{{Path: "postinst", Mode: 0o755, Data: []byte("#!/bin/sh\nset -e\nexit 0\n")}},
+		files: []entry{
+			{Path: "usr/bin/hello", Mode: 0o755, Data: fakeELF(elf.EM_X86_64)},
+			{Path: "usr/share/doc/hello/copyright", Mode: 0o644, Data: []byte("MIT\n")},
+		},
+		members: []string{"debian-binary", "control.tar.gz", "data.tar.gz"},
+	}
+}
+
+func (p *testPackage) deb(t *testing.T) []byte {
+	t.Helper()
+	md5sums := p.md5sums
+	if md5sums == nil {
+		var b strings.Builder
+		for _, f := range p.files {
+			if !slices.Contains(p.conffiles, "/"+f.Path) {
+				sum := md5.Sum(f.Data)
+				fmt.Fprintf(&b, "%s  %s\n", hex.EncodeToString(sum[:]), f.Path)
+			}
+		}
+		md5sums = []byte(b.String())
+	}
+
+	control := append([]entry{{Path: "control", Mode: 0o644, Data: []byte(p.control)}, {Path: "md5sums", Mode: 0o644, Data: md5sums}}, p.scripts...)
+	if len(p.conffiles) > 0 {
+		control = append(control, entry{Path: "conffiles", Mode: 0o644, Data: []byte(strings.Join(p.conffiles, "\n") + "\n")})
+	}
+
+	controlTar, err := writeTarGz(control, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataTar, err := writeTarGz(p.files, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string][]byte{"debian-binary": []byte("2.0\n"), "control.tar.gz": controlTar, "data.tar.gz": dataTar}
+
+	var members []arMember
+	for _, name := range p.members {
+		members = append(members, arMember{Name: name, Data: data[name]})
+	}
+	var buf bytes.Buffer
+	if err := writeAr(&buf, time.Now(), members); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestLintClean(t *testing.T) {
+	if problems := Lint(newTestPackage().deb(t)); len(problems) > 0 {
+		t.Errorf("valid package has problems: %v", problems)
+	}
+}
+
+func TestLint(t *testing.T) {
+	for tag, breakIt := range map[string]func(p *testPackage){
+		"malformed-deb": func(p *testPackage) {
+			p.members = []string{"debian-binary", "data.tar.gz", "control.tar.gz"}
+		},
+		"missing-control-field": func(p *testPackage) {
+			p.control = strings.Replace(p.control, "Maintainer: Jane Doe <jane@example.com>\n", "", 1)
+		},
+		"invalid-version": func(p *testPackage) {
+			p.control = strings.Replace(p.control, "1.0-1", "v1", 1)
+		},
+		"extended-description-is-empty": func(p *testPackage) {
+			p.control = strings.Replace(p.control, " Prints a greeting.\n", "", 1)
+		},
+		"binary-from-other-architecture": func(p *testPackage) {
+			p.files[0].Data = fakeELF(elf.EM_AARCH64)
+		},
+		"arch-independent-package-contains-binary-or-object": func(p *testPackage) {
+			p.control = strings.Replace(p.control, "amd64", "all", 1)
+		},
+		"non-standard-executable-perm": func(p *testPackage) {
+			p.files[0].Mode = 0o775
+		},
+		"dir-or-file-in-usr-local": func(p *testPackage) {
+			p.files[0].Path = "usr/local/bin/hello"
+		},
+		"no-copyright-file": func(p *testPackage) {
+			p.files = p.files[:1]
+		},
+		"md5sum-mismatch": func(p *testPackage) {
+			p.md5sums = []byte(strings.Repeat("0", 32) + "  usr/bin/hello\n")
+		},
+		"file-missing-in-md5sums": func(p *testPackage) {
+			p.md5sums = []byte{}
+		},
+		"md5sums-lists-nonexistent-file": func(p *testPackage) {
+			p.md5sums = []byte(strings.Repeat("0", 32) + "  usr/bin/gone\n")
+		},
+		"file-in-etc-not-marked-as-conffile": func(p *testPackage) {
+			p.files = append(p.files, entry{Path: "etc/hello.conf", Mode: 0o644})
+		},
+		"conffile-is-not-in-package": func(p *testPackage) {
+			p.conffiles = []string{"/etc/hello.conf"}
+		},
+		"non-etc-file-marked-as-conffile": func(p *testPackage) {
+			p.conffiles = []string{"/usr/share/doc/hello/copyright"}
+		},
+		"maintainer-script-lacks-shebang": func(p *testPackage) {
+			p.scripts[0].Data = []byte("set -e\n")
+		},
+		"control-file-has-bad-permissions": func(p *testPackage) {
+			p.scripts[0].Mode = 0o644
+		},
+		"maintainer-script-ignores-errors": func(p *testPackage) {
+			p.scripts[0].Data = []byte("#!/bin/sh\nexit 0\n")
+		},
+		"systemd-unit-not-enabled": func(p *testPackage) {
+			p.files = append(p.files, entry{Path: "lib/systemd/system/hello.service", Mode: 0o644})
+		},
+	} {
+		p := newTestPackage()
+		breakIt(p)
+
+		var tags []string
+		for _, problem := range Lint(p.deb(t)) {
+			tags = append(tags, problem.Tag)
+		}
+		if !slices.Contains(tags, tag) {
+			t.Errorf("%s not reported, got %v", tag, tags)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Contents is a package read back from its .deb.
+type Contents struct {
+	// Control holds the fields of the control file, continuation lines
+	// joined with newlines.
+	Control map[string]string
+	// Scripts are the other files of control.tar: md5sums, conffiles and
+	// the maintainer scripts, by name.
+	Scripts map[string]TarFile
+	// Files are the entries of data.tar, in archive order.
+	Files []TarFile
+}
+
+type TarFile struct {
+	Header *tar.Header
+	Data   []byte
+}
+
+// Path is the installed path, with a leading slash.
+func (f TarFile) Path() string {
+	p := strings.TrimPrefix(strings.TrimPrefix(f.Header.Name, "."), "/")
+	return "/" + strings.TrimSuffix(p, "/")
+}
+
+// Read parses a .deb: the ar members in the order dpkg expects, then the
+// control and data tarballs, uncompressed or gzipped.
+func Read(data []byte) (*Contents, error) {
+	members, err := readAr(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) < 3 {
+		return nil, fmt.Errorf("want debian-binary, control.tar and data.tar, got %d ar members", len(members))
+	}
+
+	if members[0].Name != "debian-binary" {
+		return nil, fmt.Errorf("first member is %q, want debian-binary", members[0].Name)
+	}
+	if version := string(members[0].Data); !strings.HasPrefix(version, "2.") || !strings.HasSuffix(version, "\n") {
+		return nil, fmt.Errorf("unsupported format version %q", version)
+	}
+	if !strings.HasPrefix(members[1].Name, "control.tar") {
+		return nil, fmt.Errorf("second member is %q, want control.tar", members[1].Name)
+	}
+	if !strings.HasPrefix(members[2].Name, "data.tar") {
+		return nil, fmt.Errorf("third member is %q, want data.tar", members[2].Name)
+	}
+
+	control, err := readTar(members[1])
+	if err != nil {
+		return nil, err
+	}
+	files, err := readTar(members[2])
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Contents{Scripts: map[string]TarFile{}, Files: files}
+	for _, f := range control {
+		if f.Header.Typeflag == tar.TypeDir {
+			continue
+		}
+		name := strings.TrimPrefix(f.Path(), "/")
+		if name == "control" {
+			if c.Control, err = parseControl(f.Data); err != nil {
+				return nil, err
+			}
+		} else {
+			c.Scripts[name] = f
+		}
+	}
+	if c.Control == nil {
+		return nil, fmt.Errorf("control.tar has no control file")
+	}
+	return c, nil
+}
+
+func readTar(m arMember) ([]TarFile, error) {
+	var r io.Reader = bytes.NewReader(m.Data)
+	switch {
+	case strings.HasSuffix(m.Name, ".tar"):
+	case strings.HasSuffix(m.Name, ".tar.gz"):
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		r = gz
+	default:
+		return nil, fmt.Errorf("%s: only uncompressed and gzip tarballs are supported", m.Name)
+	}
+
+	var files []TarFile
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		files = append(files, TarFile{Header: header, Data: data})
+	}
+}
+
+// parseControl reads the fields of one control paragraph.
+func parseControl(data []byte) (map[string]string, error) {
+	fields := map[string]string{}
+	var last string
+	for i, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if last == "" {
+				return nil, fmt.Errorf("control line %d: continuation without a field", i+1)
+			}
+			fields[last] += "\n" + line[1:]
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("control line %d: %q is not a field", i+1, line)
+		}
+		last = name
+		fields[name] = strings.TrimSpace(value)
+	}
+	return fields, nil
+}
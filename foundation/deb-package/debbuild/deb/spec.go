@@ -0,0 +1,258 @@
+// Package deb builds Debian binary packages from a YAML spec without
+// dpkg-buildpackage or debhelper, and lints the result.
+//
+// A spec describes one package:
+//
+//	name: gowda
+//	version: 1.0.0-1
+//	architectures: [amd64, arm64]
+//	maintainer: Jane Doe <jane@example.com>
+//	description: |
+//	  Simple command line message printer
+//	  Prints the message given as its first argument.
+//	depends: [adduser]
+//	binaries:
+//	  - name: gowda
+//	    package: .
+//	files:
+//	  - src: gowda.conf
+//	    dst: /etc/gowda/gowda.conf
+//	conffiles: [/etc/gowda/gowda.conf]
+//	scripts:
+//	  postinst: packaging/postinst
+//	units: [packaging/gowda.service]
+//
+// Binaries are Go packages cross-compiled for every architecture with
+// CGO_ENABLED=0. Paths in the spec are relative to the spec file.
+package deb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Architectures maps the Debian architectures debbuild builds for to
+// their GOARCH. "all" is for packages without binaries.
+var Architectures = map[string]string{
+	"amd64": "amd64",
+	"arm64": "arm64",
+	"all":   "",
+}
+
+var (
+	nameRe       = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]+$`)
+	versionRe    = regexp.MustCompile(`^([0-9]+:)?[0-9][A-Za-z0-9.+~-]*$`)
+	maintainerRe = regexp.MustCompile(`^[^<>]+ <[^<>\s]+@[^<>\s]+>$`)
+)
+
+type Spec struct {
+	Name          string   `yaml:"name"`
+	Version       string   `yaml:"version"`
+	Architectures []string `yaml:"architectures"`
+	Maintainer    string   `yaml:"maintainer"`
+	// Description is the synopsis on the first line, followed by the
+	// extended description.
+	Description string   `yaml:"description"`
+	Section     string   `yaml:"section,omitempty"`
+	Priority    string   `yaml:"priority,omitempty"`
+	Homepage    string   `yaml:"homepage,omitempty"`
+	Depends     []string `yaml:"depends,omitempty"`
+	Binaries    []Binary `yaml:"binaries,omitempty"`
+	Files       []File   `yaml:"files,omitempty"`
+	// Conffiles are installed paths dpkg keeps local changes of on
+	// upgrade and only removes on purge.
+	Conffiles []string `yaml:"conffiles,omitempty"`
+	Scripts   Scripts  `yaml:"scripts,omitempty"`
+	// Units are systemd unit files, installed in /lib/systemd/system,
+	// enabled and started on install and stopped on removal.
+	Units []string `yaml:"units,omitempty"`
+
+	// dir is where the spec was read from, relative paths start there.
+	dir string
+}
+
+// Binary is a Go main package built for every architecture.
+type Binary struct {
+	Name string `yaml:"name"`
+	// Package is the Go package to build, "." by default.
+	Package string `yaml:"package,omitempty"`
+	// Dst is where it is installed, /usr/bin/<name> by default.
+	Dst     string `yaml:"dst,omitempty"`
+	Ldflags string `yaml:"ldflags,omitempty"`
+}
+
+type File struct {
+	Src string `yaml:"src"`
+	Dst string `yaml:"dst"`
+	// Mode is octal, "0644" by default.
+	Mode string `yaml:"mode,omitempty"`
+}
+
+// Scripts are the maintainer scripts. A line holding only #DEBBUILD# is
+// replaced by the commands managing the systemd units, which otherwise
+// go right after the first line.
+type Scripts struct {
+	Preinst  string `yaml:"preinst,omitempty"`
+	Postinst string `yaml:"postinst,omitempty"`
+	Prerm    string `yaml:"prerm,omitempty"`
+	Postrm   string `yaml:"postrm,omitempty"`
+}
+
+// byName lists the scripts in the order they run on install and removal.
+func (s Scripts) byName() [][2]string {
+	return [][2]string{{"preinst", s.Preinst}, {"postinst", s.Postinst}, {"prerm", s.Prerm}, {"postrm", s.Postrm}}
+}
+
+// LoadSpec reads and validates a spec. Unknown fields are rejected so a
+// typo does not silently leave a file out of the package.
+func LoadSpec(file string) (*Spec, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Spec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("parse spec %s: %w", file, err)
+	}
+	s.dir = filepath.Dir(file)
+
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("spec %s: %w", file, err)
+	}
+	return &s, nil
+}
+
+// Validate checks the fields dpkg would reject and the paths that would
+// collide in the package.
+func (s *Spec) Validate() error {
+	if !nameRe.MatchString(s.Name) {
+		return fmt.Errorf("name %q must be lowercase letters, digits, +, - and .", s.Name)
+	}
+	if !versionRe.MatchString(s.Version) || strings.HasSuffix(s.Version, "-") {
+		return fmt.Errorf("version %q is not a Debian version", s.Version)
+	}
+	if !maintainerRe.MatchString(s.Maintainer) {
+		return fmt.Errorf("maintainer %q must be \"Name <email>\"", s.Maintainer)
+	}
+	if s.Synopsis() == "" {
+		return fmt.Errorf("description is empty")
+	}
+
+	if len(s.Architectures) == 0 {
+		return fmt.Errorf("architectures is empty")
+	}
+	for _, arch := range s.Architectures {
+		if _, ok := Architectures[arch]; !ok {
+			return fmt.Errorf("architecture %q is not supported, use amd64, arm64 or all", arch)
+		}
+		if arch == "all" && len(s.Binaries) > 0 {
+			return fmt.Errorf("architecture all cannot have binaries")
+		}
+	}
+
+	installed := map[string]string{}
+	install := func(dst, from string) error {
+		if !path.IsAbs(dst) || path.Clean(dst) != dst || dst == "/" {
+			return fmt.Errorf("%s: destination %q must be a clean absolute path", from, dst)
+		}
+		if other, ok := installed[dst]; ok {
+			return fmt.Errorf("%s and %s are both installed as %s", other, from, dst)
+		}
+		installed[dst] = from
+		return nil
+	}
+
+	for i, b := range s.Binaries {
+		if b.Name == "" || strings.Contains(b.Name, "/") {
+			return fmt.Errorf("binary #%d: name %q is not a file name", i+1, b.Name)
+		}
+		if err := install(b.dst(), "binary "+b.Name); err != nil {
+			return err
+		}
+	}
+	for _, f := range s.Files {
+		if f.Src == "" {
+			return fmt.Errorf("file %s: src is empty", f.Dst)
+		}
+		if _, err := f.mode(); err != nil {
+			return fmt.Errorf("file %s: %w", f.Src, err)
+		}
+		if err := install(f.Dst, f.Src); err != nil {
+			return err
+		}
+	}
+	for _, u := range s.Units {
+		switch path.Ext(u) {
+		case ".service", ".socket", ".timer", ".path", ".mount":
+		default:
+			return fmt.Errorf("unit %s is not a systemd unit file", u)
+		}
+		if err := install(unitDir+filepath.Base(u), u); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range s.Conffiles {
+		if _, ok := installed[c]; !ok {
+			return fmt.Errorf("conffile %s is not installed by the package", c)
+		}
+	}
+	return nil
+}
+
+// Synopsis is the first line of the description.
+func (s *Spec) Synopsis() string {
+	synopsis, _, _ := strings.Cut(strings.TrimSpace(s.Description), "\n")
+	return strings.TrimSpace(synopsis)
+}
+
+// Filename is the conventional name of the package for arch, without the
+// epoch of the version.
+func (s *Spec) Filename(arch string) string {
+	version := s.Version
+	if _, v, ok := strings.Cut(version, ":"); ok {
+		version = v
+	}
+	return fmt.Sprintf("%s_%s_%s.deb", s.Name, version, arch)
+}
+
+func (s *Spec) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.dir, p)
+}
+
+func (s *Spec) isConffile(dst string) bool {
+	return slices.Contains(s.Conffiles, dst)
+}
+
+func (b Binary) dst() string {
+	if b.Dst != "" {
+		return b.Dst
+	}
+	return "/usr/bin/" + b.Name
+}
+
+func (f File) mode() (int64, error) {
+	if f.Mode == "" {
+		return 0o644, nil
+	}
+	mode, err := strconv.ParseInt(f.Mode, 8, 32)
+	if err != nil || mode&^0o7777 != 0 {
+		return 0, fmt.Errorf("mode %q is not an octal file mode", f.Mode)
+	}
+	return mode, nil
+}
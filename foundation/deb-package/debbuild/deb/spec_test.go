@@ -0,0 +1,85 @@
+package deb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const specYAML = `name: hello
+version: 1:2.0.1-3
+architectures: [amd64, arm64]
+maintainer: Jane Doe <jane@example.com>
+description: |
+  Says hello
+  Prints a greeting.
+
+  Nothing else.
+depends: [adduser]
+binaries:
+  - name: hello
+files:
+  - src: hello.conf
+    dst: /etc/hello/hello.conf
+  - src: hello.conf
+    dst: /usr/share/hello/example.conf
+    mode: 0600
+conffiles: [/etc/hello/hello.conf]
+scripts:
+  postinst: postinst
+units: [hello.service]
+`
+
+func writeSpec(t *testing.T, dir, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, "debbuild.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadSpec(t *testing.T) {
+	s, err := LoadSpec(writeSpec(t, t.TempDir(), specYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Synopsis() != "Says hello" {
+		t.Errorf("synopsis = %q", s.Synopsis())
+	}
+	if got := s.Filename("arm64"); got != "hello_2.0.1-3_arm64.deb" {
+		t.Errorf("filename = %s", got)
+	}
+	if mode, _ := s.Files[1].mode(); mode != 0o600 {
+		t.Errorf("mode = %o, want 600", mode)
+	}
+	if s.Binaries[0].dst() != "/usr/bin/hello" {
+		t.Errorf("binary dst = %s", s.Binaries[0].dst())
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	for name, edit := range map[string][2]string{
+		"name":         {"name: hello", "name: Hello"},
+		"version":      {"version: 1:2.0.1-3", "version: v2"},
+		"maintainer":   {"maintainer: Jane Doe <jane@example.com>", "maintainer: jane@example.com"},
+		"architecture": {"[amd64, arm64]", "[amd64, riscv64]"},
+		"all":          {"[amd64, arm64]", "[all]"},
+		"destination":  {"dst: /etc/hello/hello.conf", "dst: etc/hello.conf"},
+		"collision":    {"dst: /usr/share/hello/example.conf", "dst: /usr/bin/hello"},
+		"mode":         {"mode: 0600", "mode: rw"},
+		"conffile":     {"conffiles: [/etc/hello/hello.conf]", "conffiles: [/etc/hello/other.conf]"},
+		"unit":         {"units: [hello.service]", "units: [hello.conf]"},
+		"unknown":      {"depends:", "dependencies:"},
+	} {
+		content := strings.Replace(specYAML, edit[0], edit[1], 1)
+		if content == specYAML {
+			t.Fatalf("%s: edit %q does not apply", name, edit[0])
+		}
+		if _, err := LoadSpec(writeSpec(t, t.TempDir(), content)); err == nil {
+			t.Errorf("%s: invalid spec accepted", name)
+		}
+	}
+}
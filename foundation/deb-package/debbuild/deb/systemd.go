@@ -0,0 +1,89 @@
+package deb
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// scriptToken marks where the generated commands go in a maintainer
+// script, like #DEBHELPER# for debhelper.
+const scriptToken = "#DEBBUILD#"
+
+// unitSnippets returns the commands the maintainer scripts run for the
+// systemd units, by script name. Units are enabled even when systemd is
+// not running, in a chroot or an image build, but only started and
+// stopped when it is.
+func unitSnippets(units []string) map[string]string {
+	if len(units) == 0 {
+		return nil
+	}
+	names := make([]string, len(units))
+	for i, u := range units {
+		names[i] = filepath.Base(u)
+	}
+	list := strings.Join(names, " ")
+
+	return map[string]string{
+		"postinst": fmt.Sprintf(`# Added by debbuild: enable and (re)start the systemd units
+if [ "$1" = "configure" ] || [ "$1" = "abort-upgrade" ]; then
+	if command -v systemctl >/dev/null 2>&1; then
+		systemctl enable %[1]s >/dev/null || true
+	fi
+	if [ -d /run/systemd/system ]; then
+		systemctl daemon-reload >/dev/null || true
+		systemctl restart %[1]s >/dev/null || true
+	fi
+fi
+`, list),
+		"prerm": fmt.Sprintf(`# Added by debbuild: stop and disable the systemd units
+if [ "$1" = "remove" ]; then
+	if [ -d /run/systemd/system ]; then
+		systemctl stop %[1]s >/dev/null || true
+	fi
+	if command -v systemctl >/dev/null 2>&1; then
+		systemctl disable %[1]s >/dev/null || true
+	fi
+fi
+`, list),
+		"postrm": `# Added by debbuild: forget the removed systemd units
+if [ -d /run/systemd/system ]; then
+	systemctl daemon-reload >/dev/null || true
+fi
+`,
+	}
+}
+
+// mergeScript puts snippet in the user script, in place of a line with
+// only scriptToken or else after the shebang line. Without a user script
+// the snippet gets one of its own, nil means the package has no script
+// called name.
+func mergeScript(name string, user []byte, snippet string) ([]byte, error) {
+	if len(user) == 0 {
+		if snippet == "" {
+			return nil, nil
+		}
+		return []byte("#!/bin/sh\nset -e\n\n" + snippet), nil
+	}
+	if !bytes.HasPrefix(user, []byte("#!")) {
+		return nil, fmt.Errorf("%s: maintainer scripts must start with #!", name)
+	}
+
+	lines := strings.SplitAfter(string(user), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == scriptToken {
+			lines[i] = snippet
+			return []byte(strings.Join(lines, "")), nil
+		}
+	}
+	if snippet == "" {
+		return user, nil
+	}
+
+	shebang, rest := lines[0], strings.Join(lines[1:], "")
+	if !strings.HasSuffix(shebang, "\n") {
+		shebang += "\n"
+	}
+	return []byte(shebang + snippet + rest), nil
+}
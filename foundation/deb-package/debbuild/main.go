@@ -0,0 +1,160 @@
+// debbuild packages Go binaries as .deb files from a YAML spec, without
+// dpkg-buildpackage or debhelper.
+//
+//	debbuild build                        every architecture of debbuild.yaml into dist/
+//	debbuild build -arch arm64 -spec ../debbuild.yaml
+//	debbuild lint dist/gowda_1.0.0-1_amd64.deb
+//
+// build lints every package it writes and fails on errors, set
+// SOURCE_DATE_EPOCH for reproducible packages.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"debbuild/deb"
+)
+
+const usageMessage = `usage: debbuild <command> [flags]
+
+commands:
+  build   cross-compile and package the spec for its architectures
+  lint    check .deb files: debbuild lint file.deb...
+
+run debbuild <command> -h for the flags of a command
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usageMessage)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "build":
+		err = build(ctx, args)
+	case "lint":
+		err = lint(args)
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usageMessage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usageMessage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func build(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("build", flag.ExitOnError)
+	specFile := fs.String("spec", "debbuild.yaml", "package spec")
+	archs := fs.String("arch", "", "comma separated architectures, the ones of the spec by default")
+	out := fs.String("out", "dist", "output directory")
+	noLint := fs.Bool("no-lint", false, "do not lint the packages")
+	strict := fs.Bool("strict", false, "fail on lint warnings too")
+	fs.Parse(args)
+
+	spec, err := deb.LoadSpec(*specFile)
+	if err != nil {
+		return err
+	}
+	opts, err := buildOptions()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+
+	architectures := spec.Architectures
+	if *archs != "" {
+		architectures = strings.Split(*archs, ",")
+	}
+
+	for _, arch := range architectures {
+		file, err := deb.BuildFile(ctx, spec, arch, *out, opts)
+		if err != nil {
+			return fmt.Errorf("build %s: %w", arch, err)
+		}
+		fmt.Printf("📦 %s\n", file)
+
+		if !*noLint {
+			if err := lintFile(file, *strict); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// buildOptions stamps the packages with SOURCE_DATE_EPOCH when it is set.
+func buildOptions() (deb.Options, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return deb.Options{}, nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return deb.Options{}, fmt.Errorf("SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return deb.Options{ModTime: time.Unix(sec, 0)}, nil
+}
+
+func lint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "fail on warnings too")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("no .deb files given")
+	}
+
+	failed := 0
+	for _, file := range fs.Args() {
+		if err := lintFile(file, *strict); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d packages failed lint", failed, fs.NArg())
+	}
+	return nil
+}
+
+func lintFile(file string, strict bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	errs, warnings := 0, 0
+	for _, p := range deb.Lint(data) {
+		fmt.Printf("   %s\n", p)
+		if p.Severity == deb.Error {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+
+	if errs > 0 || (strict && warnings > 0) {
+		return fmt.Errorf("%s: %d errors, %d warnings", file, errs, warnings)
+	}
+	fmt.Printf("✅ %s: %d warnings\n", file, warnings)
+	return nil
+}
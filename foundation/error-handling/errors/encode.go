@@ -0,0 +1,90 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// jsonError is the JSON shape of an error chain. Errors that do not come
+// from this package are reduced to their message.
+type jsonError struct {
+	Message  string                 `json:"message"`
+	Kind     string                 `json:"kind,omitempty"`
+	Code     string                 `json:"code,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+	Location string                 `json:"location,omitempty"`
+	Stack    []Frame                `json:"stack,omitempty"`
+	Cause    *jsonError             `json:"cause,omitempty"`
+	Errors   []*jsonError           `json:"errors,omitempty"`
+}
+
+func toJSON(err error) *jsonError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *Error:
+		j := &jsonError{
+			Message: e.message,
+			Code:    e.code,
+			Stack:   e.StackTrace(),
+			Cause:   toJSON(e.cause),
+		}
+		if e.cause == nil && e.message == "" {
+			j.Message = e.Error()
+		}
+		if e.kind != Unknown {
+			j.Kind = e.kind.String()
+		}
+		if len(e.fields) > 0 {
+			j.Fields = make(map[string]interface{}, len(e.fields))
+			for _, f := range e.fields {
+				j.Fields[f.Key] = f.Value
+			}
+		}
+		if file, line := e.Location(); line > 0 {
+			j.Location = fmt.Sprintf("%s:%d", file, line)
+		}
+		return j
+	case *joinError:
+		j := &jsonError{Message: "multiple errors"}
+		for _, inner := range e.errs {
+			j.Errors = append(j.Errors, toJSON(inner))
+		}
+		return j
+	default:
+		return &jsonError{Message: err.Error()}
+	}
+}
+
+// MarshalJSON encodes the whole chain, including kind, code, fields,
+// location and stack of every error created by this package.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSON(e))
+}
+
+func (e *joinError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJSON(e))
+}
+
+// LogValue implements slog.LogValuer. The error is logged as a group with
+// the full message, the kind and code found in the chain, every field and
+// the location where it was created.
+func (e *Error) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("msg", e.Error())}
+
+	if kind := KindOf(e); kind != Unknown {
+		attrs = append(attrs, slog.String("kind", kind.String()))
+	}
+	if code := CodeOf(e); code != "" {
+		attrs = append(attrs, slog.String("code", code))
+	}
+	for _, f := range FieldsOf(e) {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	if file, line := e.Location(); line > 0 {
+		attrs = append(attrs, slog.String("location", fmt.Sprintf("%s:%d", file, line)))
+	}
+
+	return slog.GroupValue(attrs...)
+}
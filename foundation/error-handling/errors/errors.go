@@ -45,6 +45,26 @@
 //	default:
 //	        // unknown error
 //	}
+//
+// Errors created by this package also implement Unwrap, so the standard
+// library errors.Is and errors.As walk the same chain.
+//
+// # Kinds, codes and fields
+//
+// New and Wrap accept options that classify the failure and attach data
+//
+//	return errors.Wrap(err, "load user",
+//	        errors.WithKind(errors.NotFound),
+//	        errors.WithCode("USER_NOT_FOUND"),
+//	        errors.WithFields("user_id", id))
+//
+// KindOf, CodeOf and FieldsOf read them back from anywhere in the chain.
+//
+// # Stack traces
+//
+// Every error records the file and line where it was created. Setting
+// CaptureStacks, or passing WithStack, records the full call stack as well,
+// which is printed by the %+v verb.
 package errors
 
 import (
@@ -109,35 +129,57 @@ func (l location) Location() (string, int) {
 	return file, line
 }
 
+// Error is the error type returned by New, Wrap and their variants. Besides
+// the message and cause it records where it was created and, optionally, a
+// kind, a code, key-value fields and the full call stack.
+type Error struct {
+	message string
+	cause   error
+	kind    Kind
+	code    string
+	fields  []Field
+	stack   []uintptr
+	location
+
+	// causeInMessage is set by Errorf when %w already put the text of
+	// the cause into message.
+	causeInMessage bool
+}
+
 // New returns an error that formats as the given text.
-func New(text string) error {
+func New(text string, opts ...Option) error {
 	pc, _, _, _ := runtime.Caller(1)
-	return struct {
-		error
-		location
-	}{
-		errors.New(text),
-		location(pc),
-	}
+	return newError(text, nil, pc, opts)
 }
 
-type cause struct {
-	cause   error
-	message string
-}
+// Errorf returns an error that formats according to the format specifier.
+// Like fmt.Errorf, an operand of %w becomes the cause of the error, several
+// of them are joined as with Join.
+func Errorf(format string, args ...interface{}) error {
+	pc, _, _, _ := runtime.Caller(1)
+	err := fmt.Errorf(format, args...)
 
-func (c cause) Error() string   { return c.Message() + ": " + c.Cause().Error() }
-func (c cause) Cause() error    { return c.cause }
-func (c cause) Message() string { return c.message }
+	var cause error
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		cause = u.Unwrap()
+	case interface{ Unwrap() []error }:
+		cause = Join(u.Unwrap()...)
+	}
+
+	e := newError(err.Error(), cause, pc, nil)
+	e.causeInMessage = cause != nil
+	return e
+}
 
 // Wrap returns an error annotating the cause with message.
 // If cause is nil, Wrap returns nil.
-func Wrap(cause error, message string) error {
+func Wrap(cause error, message string, opts ...Option) error {
 	if cause == nil {
 		return nil
 	}
 	pc, _, _, _ := runtime.Caller(1)
-	return wrap(cause, message, pc)
+	return newError(message, cause, pc, opts)
 }
 
 // Wrapf returns an error annotating the cause with the format specifier.
@@ -147,22 +189,50 @@ func Wrapf(cause error, format string, args ...interface{}) error {
 		return nil
 	}
 	pc, _, _, _ := runtime.Caller(1)
-	return wrap(cause, fmt.Sprintf(format, args...), pc)
+	return newError(fmt.Sprintf(format, args...), cause, pc, nil)
 }
 
-func wrap(err error, msg string, pc uintptr) error {
-	return struct {
-		cause
-		location
-	}{
-		cause{
-			cause:   err,
-			message: msg,
-		},
-		location(pc),
+func newError(msg string, cause error, pc uintptr, opts []Option) *Error {
+	e := &Error{
+		message:  msg,
+		cause:    cause,
+		location: location(pc),
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	if e.stack == nil && CaptureStacks {
+		// skip runtime.Callers, callers, newError and New/Wrap.
+		e.stack = callers(4)
+	}
+	return e
 }
 
+func (e *Error) Error() string {
+	switch {
+	case e.cause == nil, e.causeInMessage:
+		return e.message
+	case e.message == "":
+		return e.cause.Error()
+	default:
+		return e.message + ": " + e.cause.Error()
+	}
+}
+
+func (e *Error) Cause() error    { return e.cause }
+func (e *Error) Unwrap() error   { return e.cause }
+func (e *Error) Message() string { return e.message }
+
+// Kind returns the kind set on this error without looking at its causes,
+// KindOf searches the whole chain.
+func (e *Error) Kind() Kind { return e.kind }
+
+// Code returns the code set on this error, see CodeOf.
+func (e *Error) Code() string { return e.code }
+
+// Fields returns the fields set on this error, see FieldsOf.
+func (e *Error) Fields() []Field { return e.fields }
+
 type causer interface {
 	Cause() error
 }
@@ -181,7 +251,7 @@ type causer interface {
 func Cause(err error) error {
 	for err != nil {
 		cause, ok := err.(causer)
-		if !ok {
+		if !ok || cause.Cause() == nil {
 			break
 		}
 		err = cause.Cause()
@@ -189,6 +259,17 @@ func Cause(err error) error {
 	return err
 }
 
+// Is reports whether any error in err's chain matches target. It is the
+// standard library errors.Is, re-exported so callers need one import.
+func Is(err, target error) bool { return errors.Is(err, target) }
+
+// As finds the first error in err's chain that matches target, see the
+// standard library errors.As.
+func As(err error, target interface{}) bool { return errors.As(err, target) }
+
+// Unwrap returns the result of calling the Unwrap method on err, if any.
+func Unwrap(err error) error { return errors.Unwrap(err) }
+
 // Print prints the error to Stderr.
 // If the error implements the Causer interface described in Cause
 // Print will recurse into the error's cause.
@@ -205,8 +286,14 @@ func Print(err error) {
 
 // Fprint prints the error to the supplied writer.
 // The format of the output is the same as Print.
-// If err is nil, nothing is printed.
+// If err is nil, nothing is printed. Kinds, codes and fields are appended
+// to the line of the error that carries them, and every error joined with
+// Join is printed as its own indented chain.
 func Fprint(w io.Writer, err error) {
+	fprint(w, err, "")
+}
+
+func fprint(w io.Writer, err error, indent string) {
 	type location interface {
 		Location() (string, int)
 	}
@@ -215,11 +302,20 @@ func Fprint(w io.Writer, err error) {
 	}
 
 	for err != nil {
+		fmt.Fprint(w, indent)
 		if err, ok := err.(location); ok {
 			file, line := err.Location()
 			fmt.Fprintf(w, "%s:%d: ", file, line)
 		}
 		switch err := err.(type) {
+		case *Error:
+			fmt.Fprintln(w, err.Message()+err.details())
+		case *joinError:
+			fmt.Fprintln(w, "multiple errors:")
+			for _, e := range err.errs {
+				fprint(w, e, indent+"\t")
+			}
+			return
 		case message:
 			fmt.Fprintln(w, err.Message())
 		default:
@@ -233,3 +329,25 @@ func Fprint(w io.Writer, err error) {
 		err = cause.Cause()
 	}
 }
+
+// details renders kind, code and fields as " [kind code] k=v", or "".
+func (e *Error) details() string {
+	var b strings.Builder
+	if e.kind != Unknown || e.code != "" {
+		b.WriteString(" [")
+		if e.kind != Unknown {
+			b.WriteString(e.kind.String())
+		}
+		if e.code != "" {
+			if e.kind != Unknown {
+				b.WriteByte(' ')
+			}
+			b.WriteString(e.code)
+		}
+		b.WriteByte(']')
+	}
+	for _, f := range e.fields {
+		fmt.Fprintf(&b, " %s=%v", f.Key, f.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,175 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+var errSentinel = New("sentinel")
+
+type customErr struct{ id int }
+
+func (c *customErr) Error() string { return fmt.Sprintf("custom %d", c.id) }
+
+func TestIsAsThroughWrap(t *testing.T) {
+	err := Wrap(Wrap(errSentinel, "inner"), "outer")
+
+	if !stderrors.Is(err, errSentinel) {
+		t.Error("expected errors.Is to find the sentinel")
+	}
+
+	err = Wrapf(&customErr{id: 7}, "load %d", 7)
+
+	var target *customErr
+	if !As(err, &target) || target.id != 7 {
+		t.Errorf("expected errors.As to find customErr, got %v", target)
+	}
+
+	if Cause(err) != error(target) {
+		t.Errorf("expected Cause to return the innermost error, got %v", Cause(err))
+	}
+
+	if Wrap(nil, "nothing") != nil {
+		t.Error("expected Wrap(nil) to be nil")
+	}
+}
+
+func TestErrorfWraps(t *testing.T) {
+	err := Errorf("load config %q: %w", "app.yaml", errSentinel)
+
+	if !Is(err, errSentinel) {
+		t.Error("expected errors.Is to find the wrapped error")
+	}
+	if want := `load config "app.yaml": ` + errSentinel.Error(); err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+	if Cause(err) != errSentinel {
+		t.Errorf("expected Cause to return the %%w operand, got %v", Cause(err))
+	}
+
+	other := &customErr{id: 3}
+	err = Errorf("both: %w, %w", errSentinel, other)
+
+	var target *customErr
+	if !Is(err, errSentinel) || !As(err, &target) || target != other {
+		t.Errorf("expected both %%w operands in the chain of %v", err)
+	}
+	if want := "both: " + errSentinel.Error() + ", custom 3"; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+
+	if Unwrap(Errorf("plain %d", 1)) != nil {
+		t.Error("expected Errorf without a wrapped error to have no cause")
+	}
+}
+
+func TestKindCodeFields(t *testing.T) {
+	base := New("row missing", WithKind(NotFound), WithCode("USER_NOT_FOUND"), WithFields("user_id", 42))
+	err := Wrap(base, "get user", WithFields("op", "GetUser", "user_id", 1))
+
+	if KindOf(err) != NotFound || CodeOf(err) != "USER_NOT_FOUND" {
+		t.Errorf("unexpected kind %v or code %q", KindOf(err), CodeOf(err))
+	}
+
+	fields := FieldsOf(err)
+	if len(fields) != 2 || fields[0] != (Field{"op", "GetUser"}) || fields[1] != (Field{"user_id", 1}) {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	if KindOf(io.EOF) != Unknown {
+		t.Error("expected foreign errors to be Unknown")
+	}
+
+	if err.Error() != "get user: row missing" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+}
+
+func TestFprintAndFormat(t *testing.T) {
+	err := Wrap(New("boom", WithKind(Internal)), "handler", WithFields("path", "/x"))
+
+	var buf bytes.Buffer
+	Fprint(&buf, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 ||
+		!strings.HasSuffix(lines[0], ": handler path=/x") ||
+		!strings.HasSuffix(lines[1], ": boom [internal]") ||
+		!strings.Contains(lines[0], "errors_test.go:") {
+		t.Errorf("unexpected Fprint output:\n%s", buf.String())
+	}
+
+	if got := fmt.Sprintf("%v", err); got != "handler: boom" {
+		t.Errorf("unexpected %%v output %q", got)
+	}
+
+	stacked := New("with stack", WithStack())
+	out := fmt.Sprintf("%+v", stacked)
+
+	if !strings.Contains(out, "TestFprintAndFormat") {
+		t.Errorf("expected %%+v to include the stack, got:\n%s", out)
+	}
+}
+
+func TestCaptureStacks(t *testing.T) {
+	CaptureStacks = true
+	defer func() { CaptureStacks = false }()
+
+	err := New("captured").(*Error)
+	frames := err.StackTrace()
+
+	if len(frames) == 0 || !strings.HasSuffix(frames[0].Function, "TestCaptureStacks") {
+		t.Errorf("expected first frame to be the caller, got %v", frames)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	if Join(nil, nil) != nil {
+		t.Error("expected Join of nils to be nil")
+	}
+
+	err := Join(io.EOF, New("bad input", WithKind(Invalid)))
+
+	if !stderrors.Is(err, io.EOF) || KindOf(err) != Invalid {
+		t.Errorf("expected joined errors to be searchable, got kind %v", KindOf(err))
+	}
+
+	var buf bytes.Buffer
+	Fprint(&buf, err)
+
+	if !strings.Contains(buf.String(), "multiple errors:\n\tEOF\n\t") {
+		t.Errorf("unexpected Fprint output:\n%s", buf.String())
+	}
+}
+
+func TestJSONAndSlog(t *testing.T) {
+	err := Wrap(io.ErrUnexpectedEOF, "decode", WithKind(Invalid), WithCode("BAD_BODY"), WithFields("size", 10))
+
+	data, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+
+	if got["message"] != "decode" || got["kind"] != "invalid" || got["code"] != "BAD_BODY" ||
+		got["cause"].(map[string]interface{})["message"] != "unexpected EOF" {
+		t.Errorf("unexpected json %s", data)
+	}
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Error("request failed", "error", err)
+
+	for _, want := range []string{`error.msg="decode: unexpected EOF"`, "error.kind=invalid", "error.code=BAD_BODY", "error.size=10"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in %q", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package errors
+
+import "strings"
+
+type joinError struct {
+	errs []error
+}
+
+// Join returns an error that wraps the given errors, discarding nils. It
+// returns nil if every error is nil. The result works with Is and As like
+// the standard library errors.Join, and KindOf, CodeOf and FieldsOf look
+// into every joined error.
+func Join(errs ...error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	if len(nonNil) == 0 {
+		return nil
+	}
+	return &joinError{errs: nonNil}
+}
+
+func (e *joinError) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *joinError) Unwrap() []error { return e.errs }
@@ -0,0 +1,135 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error independently of its message, so callers such
+// as HTTP handlers can react to the class of failure.
+type Kind uint8
+
+const (
+	Unknown Kind = iota
+	NotFound
+	Invalid
+	Conflict
+	Internal
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NotFound:
+		return "not_found"
+	case Invalid:
+		return "invalid"
+	case Conflict:
+		return "conflict"
+	case Internal:
+		return "internal"
+	default:
+		return "unknown"
+	}
+}
+
+// Field is a key-value pair attached to an error with WithFields.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Option configures an error created by New or Wrap.
+type Option func(*Error)
+
+func WithKind(kind Kind) Option {
+	return func(e *Error) { e.kind = kind }
+}
+
+// WithCode sets a stable, machine readable code such as "USER_NOT_FOUND".
+func WithCode(code string) Option {
+	return func(e *Error) { e.code = code }
+}
+
+// WithFields attaches key-value pairs, given as alternating keys and values
+// like slog. A key without a value gets the value "!MISSING".
+func WithFields(kv ...interface{}) Option {
+	return func(e *Error) {
+		for i := 0; i < len(kv); i += 2 {
+			key := fmt.Sprint(kv[i])
+			var value interface{} = "!MISSING"
+			if i+1 < len(kv) {
+				value = kv[i+1]
+			}
+			e.fields = append(e.fields, Field{Key: key, Value: value})
+		}
+	}
+}
+
+// WithStack records the full call stack for this error even when
+// CaptureStacks is off.
+func WithStack() Option {
+	return func(e *Error) {
+		// skip runtime.Callers, callers, this func, newError and New/Wrap.
+		e.stack = callers(5)
+	}
+}
+
+// KindOf returns the first kind set in err's chain, or Unknown. For joined
+// errors the first error with a kind wins.
+func KindOf(err error) Kind {
+	var kind Kind
+	walk(err, func(e *Error) bool {
+		kind = e.kind
+		return kind != Unknown
+	})
+	return kind
+}
+
+// CodeOf returns the first code set in err's chain, or "".
+func CodeOf(err error) string {
+	var code string
+	walk(err, func(e *Error) bool {
+		code = e.code
+		return code != ""
+	})
+	return code
+}
+
+// FieldsOf collects the fields of every error in err's chain, outermost
+// first. A key set by an outer error is not repeated from an inner one.
+func FieldsOf(err error) []Field {
+	var fields []Field
+	seen := map[string]bool{}
+	walk(err, func(e *Error) bool {
+		for _, f := range e.fields {
+			if !seen[f.Key] {
+				seen[f.Key] = true
+				fields = append(fields, f)
+			}
+		}
+		return false
+	})
+	return fields
+}
+
+// walk calls fn for every *Error in err's chain, depth first, until fn
+// returns true.
+func walk(err error, fn func(*Error) bool) bool {
+	for err != nil {
+		if e, ok := err.(*Error); ok && fn(e) {
+			return true
+		}
+
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, inner := range multi.Unwrap() {
+				if walk(inner, fn) {
+					return true
+				}
+			}
+			return false
+		}
+
+		err = errors.Unwrap(err)
+	}
+	return false
+}
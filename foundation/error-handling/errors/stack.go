@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// CaptureStacks makes New and Wrap record the full call stack. It is off by
+// default because walking the stack costs far more than recording one
+// caller, set it once during start-up.
+var CaptureStacks = false
+
+const maxStackDepth = 32
+
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip, pcs)
+	return pcs[:n]
+}
+
+// Frame is one entry of a captured stack.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// StackTrace returns the stack captured for this error, or nil if none was.
+func (e *Error) StackTrace() []Frame {
+	if len(e.stack) == 0 {
+		return nil
+	}
+
+	var out []Frame
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		out = append(out, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if !more {
+			break
+		}
+	}
+	return out
+}
+
+// Format implements fmt.Formatter.
+//
+//	%s, %v  the message chain, as returned by Error
+//	%q      the quoted message chain
+//	%+v     the Fprint output, followed by the stack of every error in the
+//	        chain that captured one
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			Fprint(s, e)
+			walk(e, func(inner *Error) bool {
+				for _, f := range inner.StackTrace() {
+					fmt.Fprintf(s, "\t%s\n\t\t%s:%d\n", f.Function, f.File, f.Line)
+				}
+				return false
+			})
+			return
+		}
+		io.WriteString(s, e.Error())
+	case 's':
+		io.WriteString(s, e.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	}
+}
@@ -0,0 +1,63 @@
+// Package ginerr adapts httperr to gin.
+package ginerr
+
+import (
+	"net/http"
+
+	"error-handling/httperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware sets the correlation ID, recovers panics into 500 problems and
+// renders the last error added with c.Error once the handlers are done,
+// unless a response was already written.
+//
+//	r := gin.New()
+//	r.Use(ginerr.Middleware(m))
+//	r.GET("/cars/:id", func(c *gin.Context) {
+//	        car, err := svc.Get(c, c.Param("id"))
+//	        if err != nil {
+//	                c.Error(err)
+//	                return
+//	        }
+//	        c.JSON(http.StatusOK, car)
+//	})
+func Middleware(m *httperr.Mapper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = httperr.EnsureCorrelationID(c.Writer, c.Request)
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			c.Abort()
+			if !c.Writer.Written() {
+				m.Write(c.Writer, c.Request, httperr.Recovered(v))
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		m.Write(c.Writer, c.Request, c.Errors.Last().Err)
+	}
+}
+
+// Handler adapts an error returning gin handler. The error is added to the
+// context and rendered by Middleware.
+func Handler(fn func(c *gin.Context) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := fn(c); err != nil {
+			c.Error(err)
+			c.Abort()
+		}
+	}
+}
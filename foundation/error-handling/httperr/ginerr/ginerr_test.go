@@ -0,0 +1,52 @@
+package ginerr
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"error-handling/errors"
+	"error-handling/httperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := httperr.New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := gin.New()
+	r.Use(Middleware(m))
+	r.GET("/missing", Handler(func(c *gin.Context) error {
+		return errors.New("engine not found", errors.WithKind(errors.NotFound))
+	}))
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/missing", http.StatusNotFound, `"detail":"engine not found"`},
+		{"/panic", http.StatusInternalServerError, `"title":"Internal Server Error"`},
+	}
+
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rr.Code != tt.status || !strings.Contains(rr.Body.String(), tt.body) {
+			t.Errorf("%s: expected %d with %s, got %d %s", tt.path, tt.status, tt.body, rr.Code, rr.Body.String())
+		}
+
+		if rr.Header().Get("Content-Type") != httperr.ContentType {
+			t.Errorf("%s: unexpected content type %q", tt.path, rr.Header().Get("Content-Type"))
+		}
+	}
+}
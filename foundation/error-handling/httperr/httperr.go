@@ -0,0 +1,276 @@
+// Package httperr turns errors from error-handling/errors into HTTP
+// responses.
+//
+// A Mapper picks the status code from the error kind (or a code override),
+// writes an RFC 7807 problem+json body tagged with a correlation ID and logs
+// the full error chain. Clients only ever see the outermost message of a
+// 4xx error; the chain and the cause of 5xx errors stay in the logs.
+//
+//	m := httperr.New(slog.Default())
+//	http.Handle("/users/{id}", m.Middleware(m.Handler(getUser)))
+//
+//	func getUser(w http.ResponseWriter, r *http.Request) error {
+//	        u, err := store.Get(r.Context(), r.PathValue("id"))
+//	        if err != nil {
+//	                return errors.Wrap(err, "user not found", errors.WithKind(errors.NotFound))
+//	        }
+//	        return json.NewEncoder(w).Encode(u)
+//	}
+//
+// The muxerr and ginerr sub packages adapt the same Mapper to gorilla/mux
+// and gin.
+package httperr
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"error-handling/errors"
+)
+
+const (
+	ContentType = "application/problem+json"
+
+	// HeaderCorrelationID is read from the request, generated when
+	// missing or malformed, and always set on the response.
+	HeaderCorrelationID = "X-Correlation-ID"
+)
+
+// maxCorrelationIDLen bounds correlation IDs taken from clients, a UUID or
+// a 32 character hex ID fit with room to spare.
+const maxCorrelationIDLen = 128
+
+// Problem is an RFC 7807 problem details object with the code and
+// correlation ID as extension members.
+type Problem struct {
+	Type          string `json:"type"`
+	Title         string `json:"title"`
+	Status        int    `json:"status"`
+	Detail        string `json:"detail,omitempty"`
+	Instance      string `json:"instance,omitempty"`
+	Code          string `json:"code,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// DefaultKindStatus is the status used for each error kind unless the
+// Mapper overrides it.
+var DefaultKindStatus = map[errors.Kind]int{
+	errors.Unknown:  http.StatusInternalServerError,
+	errors.NotFound: http.StatusNotFound,
+	errors.Invalid:  http.StatusBadRequest,
+	errors.Conflict: http.StatusConflict,
+	errors.Internal: http.StatusInternalServerError,
+}
+
+// CodeMethodNotAllowed is the code of errors for requests whose method the
+// route does not serve.
+const CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+
+// DefaultCodeStatus is the status used for the codes the router adapters
+// return unless the Mapper overrides it.
+var DefaultCodeStatus = map[string]int{
+	CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+}
+
+type Mapper struct {
+	// KindStatus overrides DefaultKindStatus per kind.
+	KindStatus map[errors.Kind]int
+	// CodeStatus maps error codes to a status, it overrides
+	// DefaultCodeStatus and wins over KindStatus.
+	CodeStatus map[string]int
+	// TypeBase prefixes the problem type, e.g. "https://example.com/probs/".
+	// The code, or the kind when there is no code, is appended to it. An
+	// empty TypeBase yields "about:blank" as RFC 7807 suggests.
+	TypeBase string
+
+	// log is nil for a Mapper built as a literal, see logger.
+	log *slog.Logger
+}
+
+// New returns a Mapper that logs to log, or to slog.Default when log is nil.
+func New(log *slog.Logger) *Mapper {
+	if log == nil {
+		log = slog.Default()
+	}
+	return &Mapper{
+		KindStatus: map[errors.Kind]int{},
+		CodeStatus: map[string]int{},
+		log:        log,
+	}
+}
+
+// logger returns the logger given to New, or slog.Default.
+func (m *Mapper) logger() *slog.Logger {
+	if m.log == nil {
+		return slog.Default()
+	}
+	return m.log
+}
+
+// Status returns the HTTP status for err.
+func (m *Mapper) Status(err error) int {
+	if code := errors.CodeOf(err); code != "" {
+		if status, ok := m.CodeStatus[code]; ok {
+			return status
+		}
+		if status, ok := DefaultCodeStatus[code]; ok {
+			return status
+		}
+	}
+
+	kind := errors.KindOf(err)
+	if status, ok := m.KindStatus[kind]; ok {
+		return status
+	}
+	return DefaultKindStatus[kind]
+}
+
+// Problem builds the client facing problem for err. Server errors get a
+// generic detail, client errors the message of the outermost error only,
+// never the messages of its causes.
+func (m *Mapper) Problem(r *http.Request, err error) Problem {
+	status := m.Status(err)
+	code := errors.CodeOf(err)
+
+	p := Problem{
+		Type:          "about:blank",
+		Title:         http.StatusText(status),
+		Status:        status,
+		Code:          code,
+		CorrelationID: CorrelationID(r.Context()),
+	}
+
+	if r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+
+	if m.TypeBase != "" {
+		name := code
+		if name == "" {
+			name = errors.KindOf(err).String()
+		}
+		p.Type = m.TypeBase + name
+	}
+
+	if status < http.StatusInternalServerError {
+		p.Detail = publicMessage(err)
+	}
+
+	return p
+}
+
+func publicMessage(err error) string {
+	if e, ok := err.(*errors.Error); ok && e.Message() != "" {
+		return e.Message()
+	}
+	if _, ok := err.(interface{ Unwrap() error }); ok {
+		// A foreign wrapper, its Error() would include the cause.
+		return ""
+	}
+	return err.Error()
+}
+
+// Write logs err and renders it as a problem response.
+func (m *Mapper) Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := m.Problem(r, err)
+
+	var chain bytes.Buffer
+	errors.Fprint(&chain, err)
+
+	level := slog.LevelWarn
+	if p.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	m.logger().Log(r.Context(), level, "request failed",
+		"method", r.Method,
+		"path", p.Instance,
+		"status", p.Status,
+		"correlation_id", p.CorrelationID,
+		"error", err,
+		"chain", chain.String(),
+	)
+
+	WriteProblem(w, p)
+}
+
+// WriteProblem renders p as application/problem+json.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	if p.CorrelationID != "" {
+		w.Header().Set(HeaderCorrelationID, p.CorrelationID)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Recovered converts a value recovered from a panic into an internal error
+// that keeps the panic value and stack in its fields for the log.
+func Recovered(v interface{}) error {
+	if err, ok := v.(error); ok {
+		return errors.Wrap(err, "panic", errors.WithKind(errors.Internal), errors.WithFields("stack", string(debug.Stack())))
+	}
+	return errors.New(fmt.Sprintf("panic: %v", v), errors.WithKind(errors.Internal), errors.WithFields("stack", string(debug.Stack())))
+}
+
+type ctxKey struct{}
+
+// WithCorrelationID stores id in ctx.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// CorrelationID returns the ID stored by the middleware, or "".
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// EnsureCorrelationID returns r with a correlation ID in its context, taken
+// from the request header or newly generated, and sets it on w. IDs sent by
+// the client are only kept if they are safe to echo and log, see
+// validCorrelationID.
+func EnsureCorrelationID(w http.ResponseWriter, r *http.Request) *http.Request {
+	if CorrelationID(r.Context()) != "" {
+		return r
+	}
+
+	id := r.Header.Get(HeaderCorrelationID)
+	if id == "" {
+		id = r.Header.Get("X-Request-ID")
+	}
+	if !validCorrelationID(id) {
+		b := make([]byte, 16)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+
+	w.Header().Set(HeaderCorrelationID, id)
+	return r.WithContext(WithCorrelationID(r.Context(), id))
+}
+
+// validCorrelationID reports whether id is not empty, not too long, and only
+// letters, digits and "-", "_", ".", ":".
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLen {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
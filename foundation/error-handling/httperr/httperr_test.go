@@ -0,0 +1,152 @@
+package httperr
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"error-handling/errors"
+)
+
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) Problem {
+	t.Helper()
+
+	if ct := rr.Header().Get("Content-Type"); ct != ContentType {
+		t.Fatalf("expected content type %q, got %q", ContentType, ct)
+	}
+
+	var p Problem
+	if err := json.NewDecoder(rr.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestHandlerMapsKinds(t *testing.T) {
+	var logs bytes.Buffer
+	m := New(slog.New(slog.NewTextHandler(&logs, nil)))
+	m.CodeStatus["RATE_LIMITED"] = http.StatusTooManyRequests
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		detail string
+	}{
+		{"not found", errors.Wrap(io.EOF, "car not found", errors.WithKind(errors.NotFound)), http.StatusNotFound, "car not found"},
+		{"invalid", errors.New("year must be numeric", errors.WithKind(errors.Invalid)), http.StatusBadRequest, "year must be numeric"},
+		{"conflict", errors.New("duplicate", errors.WithKind(errors.Conflict)), http.StatusConflict, "duplicate"},
+		{"code override", errors.New("slow down", errors.WithKind(errors.Invalid), errors.WithCode("RATE_LIMITED")), http.StatusTooManyRequests, "slow down"},
+		{"internal hides cause", errors.Wrap(io.ErrUnexpectedEOF, "db password=secret"), http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := m.Handler(func(w http.ResponseWriter, r *http.Request) error { return tt.err })
+
+			req := httptest.NewRequest(http.MethodGet, "/cars/1", nil)
+			req.Header.Set(HeaderCorrelationID, "corr-1")
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+
+			p := decodeProblem(t, rr)
+
+			if p.Detail != tt.detail || p.Status != tt.status || p.CorrelationID != "corr-1" || p.Instance != "/cars/1" {
+				t.Errorf("unexpected problem %+v", p)
+			}
+		})
+	}
+
+	if !strings.Contains(logs.String(), "password") {
+		t.Error("expected the full chain in the log")
+	}
+}
+
+func TestMiddlewareRecoversPanics(t *testing.T) {
+	m := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("nil map")
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+
+	p := decodeProblem(t, rr)
+
+	if p.Detail != "" || p.CorrelationID == "" || rr.Header().Get(HeaderCorrelationID) != p.CorrelationID {
+		t.Errorf("unexpected problem %+v", p)
+	}
+}
+
+func TestHandlerKeepsWrittenResponse(t *testing.T) {
+	m := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	h := m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		return errors.New("late")
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusAccepted || rr.Body.Len() != 0 {
+		t.Errorf("expected the handler's response to be kept, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestMapperLiteralLogsToDefault(t *testing.T) {
+	m := &Mapper{}
+
+	h := m.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+}
+
+func TestEnsureCorrelationIDRejectsMalformedIDs(t *testing.T) {
+	tests := []struct {
+		header string
+		keep   bool
+	}{
+		{"req-42:a_b.c", true},
+		{"", false},
+		{"bad id", false},
+		{"evil\nline", false},
+		{strings.Repeat("a", maxCorrelationIDLen+1), false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderCorrelationID, tt.header)
+		rr := httptest.NewRecorder()
+
+		id := CorrelationID(EnsureCorrelationID(rr, req).Context())
+
+		if id == "" || rr.Header().Get(HeaderCorrelationID) != id {
+			t.Errorf("%q: expected the ID %q on the response, got %q", tt.header, id, rr.Header().Get(HeaderCorrelationID))
+		}
+		if (id == tt.header) != tt.keep {
+			t.Errorf("%q: keep = %v, got ID %q", tt.header, tt.keep, id)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Package muxerr adapts httperr to gorilla/mux routers.
+package muxerr
+
+import (
+	"net/http"
+
+	"error-handling/errors"
+	"error-handling/httperr"
+
+	"github.com/gorilla/mux"
+)
+
+// Use installs the panic and correlation ID middleware on r and makes its
+// 404 and 405 responses problem documents too.
+func Use(r *mux.Router, m *httperr.Mapper) {
+	r.Use(mux.MiddlewareFunc(m.Middleware))
+
+	r.NotFoundHandler = m.Handler(func(w http.ResponseWriter, req *http.Request) error {
+		return errors.New("no route for "+req.URL.Path, errors.WithKind(errors.NotFound))
+	})
+
+	r.MethodNotAllowedHandler = m.Handler(func(w http.ResponseWriter, req *http.Request) error {
+		return errors.New(req.Method+" not allowed", errors.WithCode(httperr.CodeMethodNotAllowed))
+	})
+}
+
+// HandleFunc registers an error returning handler on r.
+func HandleFunc(r *mux.Router, m *httperr.Mapper, path string, fn httperr.HandlerFunc) *mux.Route {
+	return r.Handle(path, m.Handler(fn))
+}
@@ -0,0 +1,39 @@
+package muxerr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"error-handling/httperr"
+
+	"github.com/gorilla/mux"
+)
+
+func TestUseLeavesMapperAlone(t *testing.T) {
+	m := httperr.New(nil)
+	m.CodeStatus = nil
+
+	r := mux.NewRouter()
+	Use(r, m)
+	HandleFunc(r, m, "/cars", func(w http.ResponseWriter, req *http.Request) error { return nil }).Methods(http.MethodGet)
+
+	for _, tt := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodPost, "/cars", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/boats", http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rr.Code != tt.status {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.status, rr.Code)
+		}
+	}
+
+	if m.CodeStatus != nil {
+		t.Errorf("expected Use not to touch CodeStatus, got %v", m.CodeStatus)
+	}
+}
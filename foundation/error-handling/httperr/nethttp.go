@@ -0,0 +1,72 @@
+package httperr
+
+import "net/http"
+
+// HandlerFunc is an http.HandlerFunc that returns its error instead of
+// writing it.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handler adapts fn to http.Handler, rendering a returned error with Write.
+// Nothing is written for the error if fn already wrote a response.
+func (m *Mapper) Handler(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = EnsureCorrelationID(w, r)
+		tw := &trackingWriter{ResponseWriter: w}
+
+		if err := fn(tw, r); err != nil {
+			if tw.wrote {
+				m.logger().ErrorContext(r.Context(), "error after response was written",
+					"path", r.URL.Path, "correlation_id", CorrelationID(r.Context()), "error", err)
+				return
+			}
+			m.Write(w, r, err)
+		}
+	})
+}
+
+// Middleware sets the correlation ID and turns panics in next into a 500
+// problem response. http.ErrAbortHandler is re-panicked, as net/http
+// expects.
+func (m *Mapper) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = EnsureCorrelationID(w, r)
+		tw := &trackingWriter{ResponseWriter: w}
+
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			if tw.wrote {
+				m.logger().ErrorContext(r.Context(), "panic after response was written",
+					"path", r.URL.Path, "correlation_id", CorrelationID(r.Context()), "error", Recovered(v))
+				return
+			}
+			m.Write(w, r, Recovered(v))
+		}()
+
+		next.ServeHTTP(tw, r)
+	})
+}
+
+type trackingWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (t *trackingWriter) WriteHeader(status int) {
+	t.wrote = true
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *trackingWriter) Write(b []byte) (int, error) {
+	t.wrote = true
+	return t.ResponseWriter.Write(b)
+}
+
+func (t *trackingWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
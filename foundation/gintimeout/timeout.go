@@ -0,0 +1,84 @@
+// Package gintimeout bounds gin routes to a deadline and answers the
+// requests whose context ended before the route did: 504 when the deadline
+// passed, 499 when the client went away.
+//
+//	timedOut := gin.H{"error": "request timed out"}
+//	r.GET("/users/:id", gintimeout.Middleware(2*time.Second, timedOut), getUser)
+//
+//	func getUser(c *gin.Context) {
+//		u, err := store.Get(c.Request.Context(), c.Param("id"))
+//		if gintimeout.Abort(c, err) {
+//			return
+//		}
+//		...
+//	}
+//
+// The 504 body is passed in so every API keeps its own error shape.
+package gintimeout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is nginx's status for a request whose client
+// disconnected before it was answered. Nobody reads the response, it only
+// shows up in the access log.
+const StatusClientClosedRequest = 499
+
+// bodyKey holds the 504 body set by Middleware in the gin context.
+const bodyKey = "gintimeout.body"
+
+// Middleware bounds the handlers after it to d through the request context,
+// so queries run with c.Request.Context() are interrupted when d passes or
+// the client disconnects. timedOut is rendered as JSON with the 504. If the
+// handlers wrote nothing by the time they return, Middleware answers like
+// Abort.
+func Middleware(d time.Duration, timedOut any) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+
+		c.Set(bodyKey, timedOut)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if !c.Writer.Written() {
+			Abort(c, ctx.Err())
+		}
+	}
+}
+
+// Abort checks an error from a store or service call. When the request
+// context ended it answers 504 or 499 and returns true, any other error is
+// left to the handler.
+//
+// SQL drivers such as modernc's sqlite and go-sqlite3 report an interrupted
+// query with their own error rather than one wrapping ctx.Err(), so the
+// request context is consulted as well.
+func Abort(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if ctxErr := c.Request.Context().Err(); ctxErr != nil {
+		err = ctxErr
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		if body, ok := c.Get(bodyKey); ok {
+			c.AbortWithStatusJSON(http.StatusGatewayTimeout, body)
+		} else {
+			c.AbortWithStatus(http.StatusGatewayTimeout)
+		}
+	case errors.Is(err, context.Canceled):
+		c.AbortWithStatus(StatusClientClosedRequest)
+	default:
+		return false
+	}
+	return true
+}
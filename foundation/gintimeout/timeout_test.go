@@ -0,0 +1,84 @@
+package gintimeout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+var timedOut = gin.H{"message": "Request timed out"}
+
+// slow waits for the request context like a long query would.
+func slow(c *gin.Context) {
+	select {
+	case <-time.After(time.Second):
+		c.Status(http.StatusOK)
+	case <-c.Request.Context().Done():
+		Abort(c, errors.New("interrupted"))
+	}
+}
+
+func serve(t *testing.T, ctx context.Context, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.GET("/", handlers...)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	return w
+}
+
+func TestMiddlewareDeadline(t *testing.T) {
+	start := time.Now()
+	w := serve(t, context.Background(), Middleware(20*time.Millisecond, timedOut), slow)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+	if want := `{"message":"Request timed out"}`; w.Body.String() != want {
+		t.Errorf("body = %s, want %s", w.Body, want)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("request took %v", d)
+	}
+}
+
+func TestMiddlewareClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	w := serve(t, ctx, Middleware(time.Minute, timedOut), slow)
+	if w.Code != StatusClientClosedRequest {
+		t.Errorf("status = %d, want %d", w.Code, StatusClientClosedRequest)
+	}
+}
+
+func TestMiddlewareUnwrittenResponse(t *testing.T) {
+	w := serve(t, context.Background(), Middleware(10*time.Millisecond, timedOut), func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusGatewayTimeout)
+	}
+}
+
+func TestAbortOtherErrors(t *testing.T) {
+	w := serve(t, context.Background(), Middleware(time.Minute, timedOut), func(c *gin.Context) {
+		if Abort(c, nil) || Abort(c, errors.New("no rows")) {
+			t.Error("Abort handled an error unrelated to the context")
+		}
+		c.Status(http.StatusNotFound)
+	})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -6,11 +6,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"runtime"
 	"sync"
 	"sync/atomic"
+
+	"concurrency-parallelism/parallel"
 )
 
 func main() {
@@ -18,6 +21,13 @@ func main() {
 
 	fmt.Println(add(numbers))
 	fmt.Println(addConcurrent(runtime.NumCPU(), numbers))
+
+	sum, err := parallel.Sum(context.Background(), numbers)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(sum, "with", parallel.Procs(), "workers")
 }
 
 func generateList(totalNumbers int) []int {
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"cmp"
+	"context"
 	"runtime"
+	"slices"
 	"testing"
+
+	"concurrency-parallelism/parallel"
 )
 
+// sink keeps the compiler from dropping calls whose result is unused.
+var sink int
+
 func BenchmarkAdd(b *testing.B) {
 	numbers := generateList(1e7)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		add(numbers)
+		sink = add(numbers)
 	}
 }
 
@@ -19,6 +27,54 @@ func BenchmarkAddConcurrent(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		addConcurrent(goroutines, numbers)
+		sink = addConcurrent(goroutines, numbers)
 	}
 }
+
+func BenchmarkSum(b *testing.B) {
+	numbers := generateList(1e7)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sink, _ = parallel.Sum(ctx, numbers)
+	}
+}
+
+// BenchmarkSumSmall shows the sequential fallback: below the threshold Sum
+// costs about as much as add.
+func BenchmarkSumSmall(b *testing.B) {
+	numbers := generateList(1000)
+	ctx := context.Background()
+
+	b.Run("add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sink = add(numbers)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sink, _ = parallel.Sum(ctx, numbers)
+		}
+	})
+}
+
+func BenchmarkSort(b *testing.B) {
+	numbers := generateList(1e6)
+	ctx := context.Background()
+
+	b.Run("sequential", func(b *testing.B) {
+		s := make([]int, len(numbers))
+		for i := 0; i < b.N; i++ {
+			copy(s, numbers)
+			slices.Sort(s)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		s := make([]int, len(numbers))
+		for i := 0; i < b.N; i++ {
+			copy(s, numbers)
+			parallel.Sort(ctx, s, cmp.Compare[int])
+		}
+	})
+}
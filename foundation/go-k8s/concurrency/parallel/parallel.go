@@ -0,0 +1,344 @@
+// Package parallel runs Map, Filter, Reduce and Sort over slices on all the
+// CPUs the process may use.
+//
+// Work is handed out in chunks from a shared cursor. Each chunk is a share
+// of what is left, so the first chunks are large and the last ones small,
+// which keeps every worker busy until the end even when elements cost
+// different amounts of time. Slices shorter than the threshold are
+// processed sequentially, for them starting goroutines costs more than it
+// saves.
+//
+// The context is checked between chunks. A cancelled call returns ctx's
+// error and a partial result that should be discarded.
+package parallel
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultThreshold is the length below which slices are processed
+	// sequentially.
+	DefaultThreshold = 4096
+
+	// chunksPerWorker controls the chunk size: each chunk is the remaining
+	// work divided by workers*chunksPerWorker.
+	chunksPerWorker = 4
+	minChunk        = 256
+	maxChunk        = 1 << 16
+)
+
+type options struct {
+	workers   int
+	threshold int
+	minChunk  int
+}
+
+type Option func(*options)
+
+// WithWorkers sets the number of goroutines, Procs() by default.
+func WithWorkers(n int) Option {
+	return func(o *options) { o.workers = n }
+}
+
+// WithThreshold sets the length below which slices are processed
+// sequentially. Use 0 to always go parallel.
+func WithThreshold(n int) Option {
+	return func(o *options) { o.threshold = n }
+}
+
+// WithMinChunk sets the smallest chunk a worker takes. Raise it when the
+// function is very cheap, lower it when elements are expensive.
+func WithMinChunk(n int) Option {
+	return func(o *options) { o.minChunk = n }
+}
+
+func newOptions(opts []Option) options {
+	o := options{threshold: DefaultThreshold, minChunk: minChunk}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.workers < 1 {
+		o.workers = Procs()
+	}
+	if o.minChunk < 1 {
+		o.minChunk = 1
+	}
+	return o
+}
+
+// sequential reports whether n elements are not worth splitting.
+func (o options) sequential(n int) bool {
+	return o.workers == 1 || n < o.threshold || n <= o.minChunk
+}
+
+// cursor hands out [start, end) chunks of n elements, sized adaptively.
+type cursor struct {
+	next    atomic.Int64
+	n       int
+	workers int
+	min     int
+}
+
+func (c *cursor) take() (int, int, bool) {
+	for {
+		start := int(c.next.Load())
+		if start >= c.n {
+			return 0, 0, false
+		}
+
+		size := (c.n - start) / (c.workers * chunksPerWorker)
+		size = min(max(size, c.min), maxChunk)
+		end := min(start+size, c.n)
+
+		if c.next.CompareAndSwap(int64(start), int64(end)) {
+			return start, end, true
+		}
+	}
+}
+
+// forEachChunk calls body for chunks covering [0, n) from up to o.workers
+// goroutines. worker identifies the calling goroutine, from 0 to
+// o.workers-1.
+func forEachChunk(ctx context.Context, n int, o options, body func(worker, start, end int)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if o.sequential(n) {
+		body(0, 0, n)
+		return ctx.Err()
+	}
+
+	c := &cursor{n: n, workers: o.workers, min: o.minChunk}
+	workers := min(o.workers, (n+o.minChunk-1)/o.minChunk)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				start, end, ok := c.take()
+				if !ok {
+					return
+				}
+				body(w, start, end)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// Map returns f applied to every element of in.
+func Map[T, U any](ctx context.Context, in []T, f func(T) U, opts ...Option) ([]U, error) {
+	out := make([]U, len(in))
+
+	err := forEachChunk(ctx, len(in), newOptions(opts), func(_, start, end int) {
+		for i := start; i < end; i++ {
+			out[i] = f(in[i])
+		}
+	})
+	return out, err
+}
+
+// part is the result of one chunk, kept with its start so results can be
+// put back in slice order.
+type part[A any] struct {
+	start int
+	value A
+}
+
+// chunkResults runs fn on every chunk and returns the results in slice
+// order.
+func chunkResults[A any](ctx context.Context, n int, o options, fn func(start, end int) A) ([]A, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if o.sequential(n) {
+		return []A{fn(0, n)}, ctx.Err()
+	}
+
+	perWorker := make([][]part[A], o.workers)
+
+	err := forEachChunk(ctx, n, o, func(w, start, end int) {
+		perWorker[w] = append(perWorker[w], part[A]{start, fn(start, end)})
+	})
+
+	var parts []part[A]
+	for _, p := range perWorker {
+		parts = append(parts, p...)
+	}
+	slices.SortFunc(parts, func(a, b part[A]) int { return a.start - b.start })
+
+	values := make([]A, len(parts))
+	for i, p := range parts {
+		values[i] = p.value
+	}
+	return values, err
+}
+
+// Filter returns the elements of in for which keep is true, in their
+// original order.
+func Filter[T any](ctx context.Context, in []T, keep func(T) bool, opts ...Option) ([]T, error) {
+	parts, err := chunkResults(ctx, len(in), newOptions(opts), func(start, end int) []T {
+		var kept []T
+		for _, v := range in[start:end] {
+			if keep(v) {
+				kept = append(kept, v)
+			}
+		}
+		return kept
+	})
+
+	size := 0
+	for _, p := range parts {
+		size += len(p)
+	}
+	out := make([]T, 0, size)
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out, err
+}
+
+// Reduce folds every chunk of in starting from identity, then combines
+// the chunk results left to right. identity must be neutral for combine
+// (0 for a sum, 1 for a product) and combine must be associative; it does
+// not need to be commutative.
+func Reduce[T, A any](ctx context.Context, in []T, identity A, fold func(A, T) A, combine func(A, A) A, opts ...Option) (A, error) {
+	parts, err := chunkResults(ctx, len(in), newOptions(opts), func(start, end int) A {
+		acc := identity
+		for _, v := range in[start:end] {
+			acc = fold(acc, v)
+		}
+		return acc
+	})
+
+	acc := identity
+	for _, p := range parts {
+		acc = combine(acc, p)
+	}
+	return acc, err
+}
+
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64
+}
+
+// Sum adds up the elements of in. It is Reduce with the addition written
+// out, calling a function per element would cost more than the addition.
+func Sum[T Number](ctx context.Context, in []T, opts ...Option) (T, error) {
+	parts, err := chunkResults(ctx, len(in), newOptions(opts), func(start, end int) T {
+		var v T
+		for _, n := range in[start:end] {
+			v += n
+		}
+		return v
+	})
+
+	var v T
+	for _, p := range parts {
+		v += p
+	}
+	return v, err
+}
+
+// Sort sorts s in place with cmp, as slices.SortFunc does. The slice is
+// cut in one run per worker, the runs are sorted concurrently and then
+// merged pairwise, each round of merges in parallel.
+func Sort[T any](ctx context.Context, s []T, cmp func(a, b T) int, opts ...Option) error {
+	return sortRuns(ctx, s, cmp, slices.SortFunc[[]T], newOptions(opts))
+}
+
+// SortStable is Sort keeping equal elements in their original order, as
+// slices.SortStableFunc does.
+func SortStable[T any](ctx context.Context, s []T, cmp func(a, b T) int, opts ...Option) error {
+	return sortRuns(ctx, s, cmp, slices.SortStableFunc[[]T], newOptions(opts))
+}
+
+func sortRuns[T any](ctx context.Context, s []T, cmp func(a, b T) int, sortRun func([]T, func(a, b T) int), o options) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if o.sequential(len(s)) {
+		sortRun(s, cmp)
+		return nil
+	}
+
+	runs := min(o.workers, len(s)/o.minChunk)
+	bounds := make([]int, runs+1)
+	for i := range bounds {
+		bounds[i] = i * len(s) / runs
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sortRun(s[bounds[i]:bounds[i+1]], cmp)
+		}()
+	}
+	wg.Wait()
+
+	buf := make([]T, len(s))
+	src, dst := s, buf
+	for len(bounds) > 2 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		next := []int{0}
+		for i := 0; i+1 < len(bounds); i += 2 {
+			lo := bounds[i]
+			if i+2 >= len(bounds) {
+				// Odd run out, carried over to the next round.
+				copy(dst[lo:], src[lo:bounds[i+1]])
+				next = append(next, bounds[i+1])
+				continue
+			}
+
+			mid, hi := bounds[i+1], bounds[i+2]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				merge(dst[lo:hi], src[lo:mid], src[mid:hi], cmp)
+			}()
+			next = append(next, hi)
+		}
+		wg.Wait()
+
+		bounds = next
+		src, dst = dst, src
+	}
+
+	if &src[0] != &s[0] {
+		copy(s, src)
+	}
+	return nil
+}
+
+// merge merges the sorted a and b into dst, taking from a on ties.
+func merge[T any](dst, a, b []T, cmp func(a, b T) int) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if cmp(b[j], a[i]) < 0 {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
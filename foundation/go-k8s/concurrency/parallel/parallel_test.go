@@ -0,0 +1,220 @@
+package parallel
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func numbers(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = rand.Intn(n)
+	}
+	return out
+}
+
+// sizes covers the sequential path, a single chunk and many chunks.
+var sizes = []int{0, 1, 100, DefaultThreshold - 1, DefaultThreshold + 1, 100_000, 1_000_003}
+
+func TestSum(t *testing.T) {
+	for _, n := range sizes {
+		in := numbers(n)
+		want := 0
+		for _, v := range in {
+			want += v
+		}
+
+		for _, workers := range []int{1, 3, 8} {
+			got, err := Sum(context.Background(), in, WithWorkers(workers))
+			if err != nil || got != want {
+				t.Errorf("Sum(n=%d, workers=%d) = %d, %v, want %d", n, workers, got, err, want)
+			}
+		}
+	}
+}
+
+func TestReduceKeepsOrder(t *testing.T) {
+	in := make([]string, 20_000)
+	for i := range in {
+		in[i] = strconv.Itoa(i % 10)
+	}
+
+	// Concatenation is associative but not commutative.
+	concat := func(a, b string) string { return a + b }
+	got, err := Reduce(context.Background(), in, "", concat, concat, WithWorkers(8), WithMinChunk(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Join(in, ""); got != want {
+		t.Error("Reduce did not combine chunks in order")
+	}
+}
+
+func TestMap(t *testing.T) {
+	for _, n := range sizes {
+		in := numbers(n)
+		got, err := Map(context.Background(), in, strconv.Itoa, WithWorkers(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != n {
+			t.Fatalf("Map(n=%d) returned %d elements", n, len(got))
+		}
+		for i := range in {
+			if got[i] != strconv.Itoa(in[i]) {
+				t.Fatalf("Map(n=%d)[%d] = %q, want %d", n, i, got[i], in[i])
+			}
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	even := func(v int) bool { return v%2 == 0 }
+
+	for _, n := range sizes {
+		in := numbers(n)
+		got, err := Filter(context.Background(), in, even, WithWorkers(4))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var want []int
+		for _, v := range in {
+			if even(v) {
+				want = append(want, v)
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("Filter(n=%d) returned %d elements, want %d in order", n, len(got), len(want))
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	for _, n := range sizes[:len(sizes)-1] {
+		for _, workers := range []int{2, 3, 8} {
+			in := numbers(n)
+			want := slices.Clone(in)
+			slices.Sort(want)
+
+			if err := Sort(context.Background(), in, cmp.Compare[int], WithWorkers(workers)); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(in, want) {
+				t.Errorf("Sort(n=%d, workers=%d) not sorted", n, workers)
+			}
+		}
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	type item struct{ key, pos int }
+
+	in := make([]item, 50_000)
+	for i := range in {
+		in[i] = item{key: rand.Intn(10), pos: i}
+	}
+
+	byKey := func(a, b item) int { return cmp.Compare(a.key, b.key) }
+	if err := SortStable(context.Background(), in, byKey, WithWorkers(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i < len(in); i++ {
+		if in[i-1].key == in[i].key && in[i-1].pos > in[i].pos {
+			t.Fatalf("equal keys reordered at %d", i)
+		}
+	}
+}
+
+func TestSequentialBelowThreshold(t *testing.T) {
+	var calls atomic.Int32
+	in := numbers(1000)
+
+	_, err := Map(context.Background(), in, func(v int) int {
+		calls.Add(1)
+		return v
+	}, WithWorkers(8), WithThreshold(5000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var chunks int
+	forEachChunk(context.Background(), len(in), newOptions([]Option{WithWorkers(8), WithThreshold(5000)}), func(_, start, end int) {
+		chunks++
+		if start != 0 || end != len(in) {
+			t.Errorf("chunk [%d, %d), want the whole slice", start, end)
+		}
+	})
+	if chunks != 1 || calls.Load() != 1000 {
+		t.Errorf("chunks = %d, calls = %d", chunks, calls.Load())
+	}
+}
+
+func TestChunksCoverSlice(t *testing.T) {
+	const n = 1_000_003
+	seen := make([]atomic.Int32, n)
+	var chunks atomic.Int32
+	var first, last atomic.Int64
+
+	err := forEachChunk(context.Background(), n, newOptions([]Option{WithWorkers(4)}), func(_, start, end int) {
+		chunks.Add(1)
+		if start == 0 {
+			first.Store(int64(end - start))
+		}
+		if end == n {
+			last.Store(int64(end - start))
+		}
+		for i := start; i < end; i++ {
+			seen[i].Add(1)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range seen {
+		if seen[i].Load() != 1 {
+			t.Fatalf("element %d visited %d times", i, seen[i].Load())
+		}
+	}
+	if first.Load() <= last.Load() {
+		t.Errorf("first chunk %d, last chunk %d: chunks should shrink", first.Load(), last.Load())
+	}
+	t.Logf("%d chunks", chunks.Load())
+}
+
+func TestCancel(t *testing.T) {
+	in := numbers(1_000_000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int64
+
+	_, err := Map(ctx, in, func(v int) int {
+		if calls.Add(1) == 1000 {
+			cancel()
+		}
+		return v
+	}, WithWorkers(4), WithMinChunk(100))
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if calls.Load() == int64(len(in)) {
+		t.Error("cancel did not stop the work")
+	}
+
+	if _, err := Sum(ctx, in); !errors.Is(err, context.Canceled) {
+		t.Errorf("Sum on cancelled context: err = %v", err)
+	}
+	if err := Sort(ctx, in, cmp.Compare[int]); !errors.Is(err, context.Canceled) {
+		t.Errorf("Sort on cancelled context: err = %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package parallel
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Files read to find the CPU quota, variables so tests can point them at
+// a fake cgroup tree.
+var (
+	cgroupRoot = "/sys/fs/cgroup"
+	procCgroup = "/proc/self/cgroup"
+)
+
+var quota = sync.OnceValues(cgroupCPUQuota)
+
+// Procs returns how many goroutines can run in parallel: GOMAXPROCS,
+// lowered to the cgroup CPU quota the way automaxprocs does. A container
+// limited to 0.5 CPUs gets 1, not the number of host cores. When the
+// GOMAXPROCS environment variable is set it is taken as is.
+func Procs() int {
+	procs := runtime.GOMAXPROCS(0)
+	if _, ok := os.LookupEnv("GOMAXPROCS"); ok {
+		return procs
+	}
+	if q, ok := quota(); ok && q < procs {
+		return q
+	}
+	return procs
+}
+
+// cgroupCPUQuota returns the CPU limit of the current cgroup rounded down,
+// at least 1. ok is false when there is no limit.
+func cgroupCPUQuota() (int, bool) {
+	cpus, ok := cgroupV2Quota()
+	if !ok {
+		cpus, ok = cgroupV1Quota()
+	}
+	if !ok {
+		return 0, false
+	}
+	return max(1, int(math.Floor(cpus))), true
+}
+
+// cgroupV2Quota reads cpu.max ("max 100000" or "50000 100000") of the
+// process cgroup, falling back to the root of the mount.
+func cgroupV2Quota() (float64, bool) {
+	dirs := []string{cgroupRoot}
+	if path, ok := cgroupPath(""); ok {
+		dirs = append([]string{filepath.Join(cgroupRoot, path)}, dirs...)
+	}
+
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "cpu.max"))
+		if err != nil {
+			continue
+		}
+
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0, false
+		}
+		return ratio(fields[0], fields[1])
+	}
+	return 0, false
+}
+
+// cgroupV1Quota reads cpu.cfs_quota_us and cpu.cfs_period_us, a quota of
+// -1 means unlimited.
+func cgroupV1Quota() (float64, bool) {
+	dirs := []string{filepath.Join(cgroupRoot, "cpu")}
+	if path, ok := cgroupPath("cpu"); ok {
+		dirs = append([]string{filepath.Join(cgroupRoot, "cpu", path)}, dirs...)
+	}
+
+	for _, dir := range dirs {
+		q, err := os.ReadFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+		if err != nil {
+			continue
+		}
+		p, err := os.ReadFile(filepath.Join(dir, "cpu.cfs_period_us"))
+		if err != nil {
+			continue
+		}
+		return ratio(strings.TrimSpace(string(q)), strings.TrimSpace(string(p)))
+	}
+	return 0, false
+}
+
+// cgroupPath returns the path of the process cgroup for controller, or of
+// the unified (v2) hierarchy when controller is empty.
+func cgroupPath(controller string) (string, bool) {
+	f, err := os.Open(procCgroup)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		if controller == "" {
+			if parts[0] == "0" && parts[1] == "" {
+				return parts[2], true
+			}
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			if c == controller {
+				return parts[2], true
+			}
+		}
+	}
+	return "", false
+}
+
+func ratio(quota, period string) (float64, bool) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return 0, false
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, false
+	}
+	return q / p, true
+}
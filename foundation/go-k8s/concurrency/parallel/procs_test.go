@@ -0,0 +1,96 @@
+package parallel
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func fakeCgroup(t *testing.T, self string, files map[string]string) {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	proc := filepath.Join(t.TempDir(), "cgroup")
+	if err := os.WriteFile(proc, []byte(self), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldRoot, oldProc := cgroupRoot, procCgroup
+	cgroupRoot, procCgroup = root, proc
+	t.Cleanup(func() { cgroupRoot, procCgroup = oldRoot, oldProc })
+}
+
+func TestCgroupCPUQuota(t *testing.T) {
+	tests := []struct {
+		name  string
+		self  string
+		files map[string]string
+		want  int
+		ok    bool
+	}{
+		{
+			name:  "v2 unlimited",
+			self:  "0::/\n",
+			files: map[string]string{"cpu.max": "max 100000\n"},
+		},
+		{
+			name:  "v2 half a cpu",
+			self:  "0::/\n",
+			files: map[string]string{"cpu.max": "50000 100000\n"},
+			want:  1, ok: true,
+		},
+		{
+			name:  "v2 nested cgroup",
+			self:  "0::/app.slice/go.scope\n",
+			files: map[string]string{"app.slice/go.scope/cpu.max": "250000 100000\n", "cpu.max": "max 100000\n"},
+			want:  2, ok: true,
+		},
+		{
+			name: "v1",
+			self: "12:cpu,cpuacct:/docker/abc\n",
+			files: map[string]string{
+				"cpu/docker/abc/cpu.cfs_quota_us":  "300000\n",
+				"cpu/docker/abc/cpu.cfs_period_us": "100000\n",
+			},
+			want: 3, ok: true,
+		},
+		{
+			name: "v1 unlimited",
+			self: "12:cpu,cpuacct:/\n",
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":  "-1\n",
+				"cpu/cpu.cfs_period_us": "100000\n",
+			},
+		},
+		{
+			name: "no cgroup",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCgroup(t, tt.self, tt.files)
+
+			got, ok := cgroupCPUQuota()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("cgroupCPUQuota() = %d, %v, want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestProcs(t *testing.T) {
+	if n := Procs(); n < 1 {
+		t.Errorf("Procs() = %d", n)
+	}
+}
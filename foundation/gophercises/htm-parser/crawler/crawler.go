@@ -0,0 +1,302 @@
+// Package crawler walks the pages of one site breadth first and collects
+// their links.
+//
+// Only URLs on the host of the start URL are followed, up to MaxDepth
+// links away from it. robots.txt is honoured unless disabled, including its
+// Crawl-delay, and requests are spaced at least Interval apart across all
+// workers.
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gophercises/html-parser/link"
+	"gophercises/html-parser/robots"
+)
+
+const (
+	DefaultWorkers   = 4
+	DefaultUserAgent = "sitemapper/1.0"
+
+	maxBodySize = 10 << 20
+)
+
+type Config struct {
+	// MaxDepth is how many links away from the start page to go, 0 only
+	// fetches the start page.
+	MaxDepth int
+	Workers  int
+	// Interval is the minimum time between two requests.
+	Interval  time.Duration
+	UserAgent string
+	Client    *http.Client
+	// IgnoreRobots skips robots.txt.
+	IgnoreRobots bool
+	// FollowNoFollow also follows links with rel="nofollow".
+	FollowNoFollow bool
+}
+
+// Page is one fetched URL.
+type Page struct {
+	URL          string
+	Depth        int
+	Status       int
+	LastModified time.Time
+	Links        []link.Link
+	Err          error
+}
+
+type Crawler struct {
+	cfg Config
+}
+
+func New(cfg Config) *Crawler {
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Crawler{cfg: cfg}
+}
+
+type task struct {
+	url   *url.URL
+	depth int
+}
+
+// Crawl fetches start and the pages it links to. Pages are returned in the
+// order they finished. When ctx is cancelled the pages fetched so far are
+// returned with ctx's error.
+func (c *Crawler) Crawl(ctx context.Context, start string) ([]Page, error) {
+	root, err := url.Parse(start)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := normalize(root)
+	if !ok {
+		return nil, fmt.Errorf("crawl %q: only absolute http and https URLs are supported", start)
+	}
+
+	rules, err := c.robots(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := c.cfg.Interval
+	if d := rules.CrawlDelay(c.cfg.UserAgent); d > interval {
+		interval = d
+	}
+	limit := &limiter{interval: interval}
+
+	jobs := make(chan task)
+	results := make(chan Page)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				results <- c.fetch(ctx, limit, t)
+			}
+		}()
+	}
+
+	seen := map[string]bool{root.String(): true}
+	var queue []task
+	if rules.Allowed(c.cfg.UserAgent, root.RequestURI()) {
+		queue = append(queue, task{url: root})
+	}
+
+	var pages []Page
+	inflight := 0
+
+loop:
+	for len(queue) > 0 || inflight > 0 {
+		// Sending is only enabled when there is work, a nil channel
+		// blocks forever in select.
+		var send chan task
+		var next task
+		if len(queue) > 0 {
+			send, next = jobs, queue[0]
+		}
+
+		select {
+		case send <- next:
+			queue = queue[1:]
+			inflight++
+		case page := <-results:
+			inflight--
+			pages = append(pages, page)
+			if page.Depth >= c.cfg.MaxDepth {
+				continue
+			}
+			for _, l := range page.Links {
+				if u, ok := c.follow(root, l, rules, seen); ok {
+					seen[u.String()] = true
+					queue = append(queue, task{url: u, depth: page.Depth + 1})
+				}
+			}
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	close(jobs)
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	// Let workers still fetching finish, their pages are dropped.
+	for range results {
+	}
+
+	return pages, ctx.Err()
+}
+
+// follow returns the normalized URL of l if it should be crawled.
+func (c *Crawler) follow(root *url.URL, l link.Link, rules *robots.Robots, seen map[string]bool) (*url.URL, bool) {
+	if l.NoFollow() && !c.cfg.FollowNoFollow {
+		return nil, false
+	}
+
+	u, err := url.Parse(l.Href)
+	if err != nil {
+		return nil, false
+	}
+	u, ok := normalize(u)
+	if !ok || u.Host != root.Host || seen[u.String()] {
+		return nil, false
+	}
+	if !rules.Allowed(c.cfg.UserAgent, u.RequestURI()) {
+		return nil, false
+	}
+	return u, true
+}
+
+func (c *Crawler) fetch(ctx context.Context, limit *limiter, t task) Page {
+	page := Page{URL: t.url.String(), Depth: t.depth}
+
+	if err := limit.wait(ctx); err != nil {
+		page.Err = err
+		return page
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, page.URL, nil)
+	if err != nil {
+		page.Err = err
+		return page
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+
+	resp, err := c.cfg.Client.Do(req)
+	if err != nil {
+		page.Err = err
+		return page
+	}
+	defer resp.Body.Close()
+
+	page.Status = resp.StatusCode
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		page.LastModified = lm
+	}
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return page
+	}
+
+	// Redirects are followed by the client, resolve links against where
+	// the page actually is.
+	page.Links, page.Err = link.Parse(io.LimitReader(resp.Body, maxBodySize), resp.Request.URL)
+	return page
+}
+
+// robots fetches robots.txt of root's host. A missing file allows
+// everything, a server error disallows everything as RFC 9309 asks.
+func (c *Crawler) robots(ctx context.Context, root *url.URL) (*robots.Robots, error) {
+	if c.cfg.IgnoreRobots {
+		return &robots.Robots{}, nil
+	}
+
+	robotsURL := &url.URL{Scheme: root.Scheme, Host: root.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+
+	resp, err := c.cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return robots.Parse(strings.NewReader("User-agent: *\nDisallow: /\n"))
+	case resp.StatusCode != http.StatusOK:
+		return &robots.Robots{}, nil
+	}
+	return robots.Parse(io.LimitReader(resp.Body, 500<<10))
+}
+
+// normalize drops the fragment and lower cases scheme and host, so the same
+// page is only crawled once.
+func normalize(u *url.URL) (*url.URL, bool) {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	n.Fragment = ""
+	n.RawFragment = ""
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	if (n.Scheme != "http" && n.Scheme != "https") || n.Host == "" {
+		return nil, false
+	}
+	return &n, true
+}
+
+// limiter spaces calls to wait at least interval apart.
+type limiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
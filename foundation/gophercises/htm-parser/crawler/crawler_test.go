@@ -0,0 +1,151 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// site serves a small link graph and records which paths were fetched.
+type site struct {
+	mu      sync.Mutex
+	fetched []string
+	pages   map[string]string
+	robots  string
+}
+
+func newSite(t *testing.T) (*site, *httptest.Server) {
+	s := &site{
+		robots: "User-agent: *\nDisallow: /private\n",
+		pages: map[string]string{
+			"/":                   `<a href="/about">About</a> <a href="products/">Products</a> <a href="https://elsewhere.example/">Out</a> <a href="/private/admin">Admin</a>`,
+			"/about":              `<a href="/">Home</a> <a href="/about#team">Team</a> <a href="/login" rel="nofollow">Login</a>`,
+			"/products/":          `<a href="/products/1">One</a> <a href="2">Two</a>`,
+			"/products/1":         `<a href="/products/1/reviews">Reviews</a>`,
+			"/products/2":         `<a href="/missing">Broken</a>`,
+			"/products/1/reviews": `deep page`,
+			"/login":              `login`,
+			"/private/admin":      `secret`,
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.fetched = append(s.fetched, r.URL.Path)
+		s.mu.Unlock()
+
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, s.robots)
+			return
+		}
+		body, ok := s.pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><body>%s</body></html>", body)
+	}))
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func paths(srv *httptest.Server, pages []Page) []string {
+	var out []string
+	for _, p := range pages {
+		out = append(out, strings.TrimPrefix(p.URL, srv.URL))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestCrawlFollowsSameHostWithinDepth(t *testing.T) {
+	s, srv := newSite(t)
+
+	pages, err := New(Config{MaxDepth: 2, Workers: 3}).Crawl(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(paths(srv, pages), " ")
+	want := "/ /about /products/ /products/1 /products/2"
+	if got != want {
+		t.Errorf("crawled %q, want %q", got, want)
+	}
+
+	for _, p := range s.fetched {
+		if strings.HasPrefix(p, "/private") || p == "/login" {
+			t.Errorf("fetched %s despite robots.txt or nofollow", p)
+		}
+	}
+}
+
+func TestCrawlDepthAndStatus(t *testing.T) {
+	_, srv := newSite(t)
+
+	pages, err := New(Config{MaxDepth: 4}).Crawl(context.Background(), srv.URL+"/products/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := map[string]int{}
+	for _, p := range pages {
+		status[strings.TrimPrefix(p.URL, srv.URL)] = p.Status
+	}
+	if status["/products/2"] != http.StatusOK || status["/missing"] != http.StatusNotFound {
+		t.Errorf("unexpected statuses %v", status)
+	}
+
+	pages, err = New(Config{MaxDepth: 0}).Crawl(context.Background(), srv.URL)
+	if err != nil || len(pages) != 1 {
+		t.Errorf("expected only the start page at depth 0, got %d pages, %v", len(pages), err)
+	}
+}
+
+func TestCrawlIgnoreRobots(t *testing.T) {
+	_, srv := newSite(t)
+
+	pages, err := New(Config{MaxDepth: 1, IgnoreRobots: true, FollowNoFollow: true}).Crawl(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(paths(srv, pages), " ")
+	if !strings.Contains(got, "/private/admin") {
+		t.Errorf("expected robots.txt to be ignored, crawled %q", got)
+	}
+}
+
+func TestCrawlRateLimit(t *testing.T) {
+	s, srv := newSite(t)
+	s.robots = "User-agent: *\nCrawl-delay: 0.05\n"
+
+	start := time.Now()
+	pages, err := New(Config{MaxDepth: 1, Workers: 8}).Crawl(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// n requests need at least n-1 delays, whatever the worker count.
+	if min := time.Duration(len(pages)-1) * 50 * time.Millisecond; time.Since(start) < min {
+		t.Errorf("crawled %d pages in %v, expected at least %v", len(pages), time.Since(start), min)
+	}
+}
+
+func TestCrawlCancel(t *testing.T) {
+	_, srv := newSite(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	_, err := New(Config{MaxDepth: 5, Interval: 20 * time.Millisecond}).Crawl(ctx, srv.URL)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected deadline error, got %v", err)
+	}
+}
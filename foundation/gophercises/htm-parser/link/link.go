@@ -0,0 +1,163 @@
+// Package link extracts the links of an HTML document.
+//
+// Href is resolved against the page URL, or the document's <base href> when
+// it has one. Text is all text nested inside the <a>, with whitespace
+// collapsed, so <a><b>New</b> arrivals</a> gives "New arrivals" and
+// <a>foo<b>bar</b></a> gives "foobar".
+package link
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type Link struct {
+	Href string
+	Text string
+	// Rel holds the space separated values of the rel attribute.
+	Rel []string
+}
+
+// NoFollow reports whether the link asks crawlers not to follow it.
+func (l Link) NoFollow() bool {
+	for _, r := range l.Rel {
+		if strings.EqualFold(r, "nofollow") {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse reads an HTML document and returns its links. base is the URL the
+// document was fetched from and may be nil, hrefs are then kept as written.
+func Parse(r io.Reader, base *url.URL) ([]Link, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	return Extract(doc, base), nil
+}
+
+// Extract returns the links of a parsed document in document order. Anchors
+// without an href, and hrefs that are not valid URLs, are skipped.
+func Extract(doc *html.Node, base *url.URL) []Link {
+	base = documentBase(doc, base)
+
+	var links []Link
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			if l, ok := newLink(n, base); ok {
+				links = append(links, l)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return links
+}
+
+func newLink(n *html.Node, base *url.URL) (Link, bool) {
+	href, ok := attr(n, "href")
+	if !ok {
+		return Link{}, false
+	}
+	href = strings.TrimSpace(href)
+
+	if base != nil {
+		u, err := url.Parse(href)
+		if err != nil {
+			return Link{}, false
+		}
+		href = base.ResolveReference(u).String()
+	}
+
+	rel, _ := attr(n, "rel")
+	return Link{Href: href, Text: text(n), Rel: strings.Fields(rel)}, true
+}
+
+// documentBase applies the first <base href> of doc to page.
+func documentBase(doc *html.Node, page *url.URL) *url.URL {
+	var found *html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if found != nil {
+			return
+		}
+		if n.Type == html.ElementNode && n.Data == "base" {
+			if _, ok := attr(n, "href"); ok {
+				found = n
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if found == nil {
+		return page
+	}
+
+	href, _ := attr(found, "href")
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return page
+	}
+	if page == nil {
+		if u.IsAbs() {
+			return u
+		}
+		return nil
+	}
+	return page.ResolveReference(u)
+}
+
+// text concatenates every text node below n, as written, and collapses the
+// whitespace between them. Only a <br> adds a separator of its own.
+func text(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			b.WriteString(n.Data)
+		case html.ElementNode:
+			switch n.Data {
+			case "script", "style":
+				return
+			case "br":
+				b.WriteByte(' ')
+			}
+		case html.CommentNode:
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return strings.Join(strings.FieldsFunc(b.String(), isSpace), " ")
+}
+
+// isSpace reports whether r is HTML whitespace. A no-break space is text.
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\f' || r == '\r'
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
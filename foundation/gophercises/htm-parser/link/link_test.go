@@ -0,0 +1,74 @@
+package link
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	page := `<html><body>
+		<a href="/products/1"><span>Product</span> <b>One</b></a>
+		<a href="details.html">  Spread
+			over   lines </a>
+		<a href="https://other.example/x" rel="nofollow noopener">External</a>
+		<a name="anchor">no href</a>
+		<a href="#top"></a>
+		<a href="/script"><script>var x;</script>Script</a>
+		<a href="/nested">foo<b>bar</b></a>
+		<a href="/br">Line<br>two&nbsp;parts</a>
+	</body></html>`
+
+	base, _ := url.Parse("https://shop.example/catalog/index.html")
+	links, err := Parse(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Link{
+		{Href: "https://shop.example/products/1", Text: "Product One"},
+		{Href: "https://shop.example/catalog/details.html", Text: "Spread over lines"},
+		{Href: "https://other.example/x", Text: "External", Rel: []string{"nofollow", "noopener"}},
+		{Href: "https://shop.example/catalog/index.html#top", Text: ""},
+		{Href: "https://shop.example/script", Text: "Script"},
+		{Href: "https://shop.example/nested", Text: "foobar"},
+		{Href: "https://shop.example/br", Text: "Line two\u00a0parts"},
+	}
+
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d: %+v", len(links), len(want), links)
+	}
+	for i := range want {
+		got := links[i]
+		if got.Href != want[i].Href || got.Text != want[i].Text || strings.Join(got.Rel, " ") != strings.Join(want[i].Rel, " ") {
+			t.Errorf("link %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	if !links[2].NoFollow() || links[0].NoFollow() {
+		t.Error("expected only the external link to be nofollow")
+	}
+}
+
+func TestParseBaseElement(t *testing.T) {
+	page := `<html><head><base href="/docs/"></head><body><a href="intro">Intro</a></body></html>`
+
+	base, _ := url.Parse("https://example.com/index.html")
+	links, err := Parse(strings.NewReader(page), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].Href != "https://example.com/docs/intro" {
+		t.Errorf("expected href resolved against <base>, got %+v", links)
+	}
+}
+
+func TestParseWithoutBaseKeepsHref(t *testing.T) {
+	links, err := Parse(strings.NewReader(`<a href="/x">X</a>`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].Href != "/x" {
+		t.Errorf("unexpected links %+v", links)
+	}
+}
@@ -1,61 +1,119 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
 
-	"golang.org/x/net/html"
+	"gophercises/html-parser/crawler"
+	"gophercises/html-parser/link"
+	"gophercises/html-parser/sitemap"
 )
 
-type Link struct {
-	Href string
-	Text string
-}
+func main() {
+	start := flag.String("url", "http://127.0.0.1:5500/gophercises/htm-parser/index.html", "page to start from")
+	linksOnly := flag.Bool("links", false, "only print the links of -url, without crawling")
+	depth := flag.Int("depth", 3, "how many links away from -url to crawl")
+	workers := flag.Int("workers", crawler.DefaultWorkers, "concurrent requests")
+	interval := flag.Duration("interval", 200*time.Millisecond, "minimum time between requests, raised to the robots.txt Crawl-delay")
+	ignoreRobots := flag.Bool("ignore-robots", false, "do not read robots.txt")
+	out := flag.String("o", "sitemap.xml", "sitemap file, - for stdout")
+	flag.Parse()
 
-var Links []Link
+	if *linksOnly {
+		if err := printLinks(*start); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-func main() {
-	url := "http://127.0.0.1:5500/gophercises/htm-parser/index.html"
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	resp, err := http.Get(url)
-	if err != nil {
+	c := crawler.New(crawler.Config{
+		MaxDepth:     *depth,
+		Workers:      *workers,
+		Interval:     *interval,
+		IgnoreRobots: *ignoreRobots,
+	})
+
+	pages, err := c.Crawl(ctx, *start)
+	if err != nil && ctx.Err() == nil {
 		fmt.Println("Error:", err)
-		return
+		os.Exit(1)
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "Interrupted, writing the pages crawled so far")
 	}
-	defer resp.Body.Close()
 
-	doc, err := html.Parse(resp.Body)
-	if err != nil {
+	if err := writeSitemap(*out, pages); err != nil {
 		fmt.Println("Error:", err)
-		return
+		os.Exit(1)
 	}
+}
 
-	processAllProducts(doc)
+func printLinks(pageURL string) error {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println(Links)
-}
+	resp, err := http.Get(pageURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-func processAllProducts(n *html.Node) {
-	if n.Type == html.ElementNode && n.Data == "a" {
-		link := processNode(n)
-		Links = append(Links, link)
+	links, err := link.Parse(resp.Body, base)
+	if err != nil {
+		return err
 	}
 
-	for c := n.FirstChild; c != nil; c = c.NextSibling {
-		processAllProducts(c)
+	for _, l := range links {
+		fmt.Printf("%s\t%s\n", l.Href, l.Text)
 	}
+	return nil
 }
 
-func processNode(n *html.Node) Link {
-	for _, a := range n.Attr {
-		if a.Key == "href" {
-			link := Link{
-				Href: a.Val,
-				Text: n.FirstChild.Data,
-			}
-			return link
+// writeSitemap lists every page that answered 200, sorted by URL.
+func writeSitemap(file string, pages []crawler.Page) error {
+	var urls []sitemap.URL
+	for _, p := range pages {
+		if p.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", p.URL, p.Err)
+			continue
 		}
+		if p.Status != http.StatusOK {
+			fmt.Fprintf(os.Stderr, "%s: %d\n", p.URL, p.Status)
+			continue
+		}
+		urls = append(urls, sitemap.NewURL(p.URL, p.LastModified))
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].Loc < urls[j].Loc })
+
+	var w io.Writer = os.Stdout
+	if file != "-" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
 	}
 
-	return Link{}
+	if err := sitemap.Write(w, urls); err != nil {
+		return err
+	}
+	if file != "-" {
+		fmt.Printf("Wrote %d URLs to %s\n", len(urls), file)
+	}
+	return nil
 }
@@ -0,0 +1,171 @@
+// Package robots parses robots.txt and answers whether a user agent may
+// fetch a path.
+//
+// Rules follow RFC 9309: the group with the most specific matching
+// user-agent applies, falling back to "*"; within it the longest matching
+// Allow or Disallow wins, Allow on a tie. "*" matches any characters and a
+// trailing "$" anchors the end of the path. Crawl-delay is read as well,
+// though it is not part of the RFC.
+package robots
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+type Robots struct {
+	groups []*group
+}
+
+// Parse reads a robots.txt file. Unknown lines are ignored, as the RFC
+// requires.
+func Parse(r io.Reader) (*Robots, error) {
+	robots := &Robots{}
+
+	var current *group
+	// lastWasAgent tracks consecutive user-agent lines, which share a group.
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !lastWasAgent {
+				current = &group{}
+				robots.groups = append(robots.groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+			continue
+		case "allow", "disallow":
+			// An empty Disallow allows everything and adds no rule.
+			if current != nil && value != "" {
+				current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if current != nil {
+				if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+					current.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+		lastWasAgent = false
+	}
+
+	return robots, scanner.Err()
+}
+
+// Allowed reports whether agent may fetch path, which includes the query.
+func (r *Robots) Allowed(agent, path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+
+	g := r.group(agent)
+	if g == nil {
+		return true
+	}
+
+	best := -1
+	allowed := true
+	for _, rl := range g.rules {
+		if !match(rl.pattern, path) {
+			continue
+		}
+		n := len(rl.pattern)
+		if n > best || (n == best && rl.allow) {
+			best = n
+			allowed = rl.allow
+		}
+	}
+	return allowed
+}
+
+// CrawlDelay returns the Crawl-delay of agent's group, or 0.
+func (r *Robots) CrawlDelay(agent string) time.Duration {
+	if g := r.group(agent); g != nil {
+		return g.crawlDelay
+	}
+	return 0
+}
+
+// group returns the group whose user-agent is the longest prefix of agent's
+// product token, or the "*" group.
+func (r *Robots) group(agent string) *group {
+	token := strings.ToLower(agent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	var best, star *group
+	bestLen := 0
+	for _, g := range r.groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*":
+				if star == nil {
+					star = g
+				}
+			case strings.HasPrefix(token, a) && len(a) > bestLen:
+				best, bestLen = g, len(a)
+			}
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+	return star
+}
+
+// match reports whether path matches a robots.txt pattern.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		if last && anchored {
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+
+	return !anchored || pos == len(path)
+}
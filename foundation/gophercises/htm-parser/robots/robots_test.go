@@ -0,0 +1,82 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const robotsTxt = `
+# comments are ignored
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 0.5
+
+User-agent: linkbot
+User-agent: otherbot
+Disallow: /
+Allow: /blog
+
+User-agent: greedy
+Disallow:
+`
+
+func TestAllowed(t *testing.T) {
+	r, err := Parse(strings.NewReader(robotsTxt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		agent, path string
+		want        bool
+	}{
+		{"sitemapper/1.0", "/", true},
+		{"sitemapper/1.0", "/private/secret", false},
+		{"sitemapper/1.0", "/private/public/page", true},
+		{"sitemapper/1.0", "/docs/manual.pdf", false},
+		{"sitemapper/1.0", "/docs/manual.pdf?download=1", true},
+		{"LinkBot/2.0", "/products", false},
+		{"LinkBot/2.0", "/blog/post", true},
+		{"otherbot", "/", false},
+		{"greedy", "/private/secret", true},
+		{"LinkBot/2.0", "/robots.txt", true},
+	}
+	for _, tt := range tests {
+		if got := r.Allowed(tt.agent, tt.path); got != tt.want {
+			t.Errorf("Allowed(%q, %q) = %v, want %v", tt.agent, tt.path, got, tt.want)
+		}
+	}
+
+	if d := r.CrawlDelay("sitemapper"); d != 500*time.Millisecond {
+		t.Errorf("crawl delay = %v, want 500ms", d)
+	}
+	if d := r.CrawlDelay("linkbot"); d != 0 {
+		t.Errorf("crawl delay of linkbot = %v, want 0", d)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/fish", "/fish.html", true},
+		{"/fish", "/Fish", false},
+		{"/fish*", "/fish/salmon", true},
+		{"/*.php", "/index.php?x=1", true},
+		{"/*.php$", "/index.php?x=1", false},
+		{"/*.php$", "/a/b.php", true},
+		{"/fish$", "/fish", true},
+		{"/fish$", "/fishy", false},
+		{"/a*b*c", "/axxbyyc", true},
+		{"/a*b*c", "/axxcyyb", false},
+	}
+	for _, tt := range tests {
+		if got := match(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("match(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
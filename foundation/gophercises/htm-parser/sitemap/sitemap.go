@@ -0,0 +1,53 @@
+// Package sitemap writes sitemap.xml files as described on sitemaps.org.
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+const Namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// MaxURLs is the most a single sitemap file may list.
+const MaxURLs = 50000
+
+type URL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlset struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []URL    `xml:"url"`
+}
+
+// NewURL returns the entry for loc, with lastMod as a date when it is set.
+func NewURL(loc string, lastMod time.Time) URL {
+	u := URL{Loc: loc}
+	if !lastMod.IsZero() {
+		u.LastMod = lastMod.UTC().Format(time.DateOnly)
+	}
+	return u
+}
+
+// Write encodes urls as an indented sitemap. Lists longer than MaxURLs are
+// cut, as search engines reject them.
+func Write(w io.Writer, urls []URL) error {
+	if len(urls) > MaxURLs {
+		urls = urls[:MaxURLs]
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(urlset{Xmlns: Namespace, URLs: urls}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,33 @@
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	urls := []URL{
+		NewURL("https://example.com/", time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)),
+		NewURL("https://example.com/a?x=1&y=2", time.Time{}),
+	}
+	if err := Write(&buf, urls); err != nil {
+		t.Fatal(err)
+	}
+
+	var got urlset
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid XML %s: %v", buf.String(), err)
+	}
+	if got.XMLName.Space != Namespace || len(got.URLs) != 2 {
+		t.Fatalf("unexpected sitemap %+v", got)
+	}
+	if got.URLs[0].LastMod != "2025-01-02" || got.URLs[1].LastMod != "" || got.URLs[1].Loc != "https://example.com/a?x=1&y=2" {
+		t.Errorf("unexpected entries %+v", got.URLs)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("&amp;")) {
+		t.Error("expected & to be escaped")
+	}
+}
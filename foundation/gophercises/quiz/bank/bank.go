@@ -0,0 +1,98 @@
+// Package bank loads quiz questions from CSV, JSON or YAML files.
+//
+// CSV rows are "question,answer", further columns are accepted as
+// alternative answers. JSON and YAML files hold a list of
+//
+//	{"question": "5+5", "answer": "10", "alternatives": ["ten"]}
+package bank
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Question struct {
+	Prompt       string   `json:"question" yaml:"question"`
+	Answer       string   `json:"answer" yaml:"answer"`
+	Alternatives []string `json:"alternatives,omitempty" yaml:"alternatives,omitempty"`
+}
+
+// Answers returns the answer followed by its alternatives.
+func (q Question) Answers() []string {
+	return append([]string{q.Answer}, q.Alternatives...)
+}
+
+// Load reads a question bank, picking the format from the extension.
+func Load(file string) ([]Question, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var questions []Question
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".csv":
+		questions, err = parseCSV(bytes.NewReader(data))
+	case ".json":
+		err = json.Unmarshal(data, &questions)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&questions)
+	default:
+		return nil, fmt.Errorf("%s: unsupported format %q, use .csv, .json or .yaml", file, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	for i, q := range questions {
+		if strings.TrimSpace(q.Prompt) == "" || strings.TrimSpace(q.Answer) == "" {
+			return nil, fmt.Errorf("%s: question %d needs a question and an answer", file, i+1)
+		}
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%s: no questions", file)
+	}
+	return questions, nil
+}
+
+func parseCSV(r io.Reader) ([]Question, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	questions := make([]Question, 0, len(records))
+	for i, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: want question,answer", i+1)
+		}
+		questions = append(questions, Question{
+			Prompt:       record[0],
+			Answer:       record[1],
+			Alternatives: record[2:],
+		})
+	}
+	return questions, nil
+}
+
+// Shuffle returns the questions in random order, leaving qs untouched.
+func Shuffle(qs []Question, rng *rand.Rand) []Question {
+	out := append([]Question(nil), qs...)
+	rng.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out
+}
@@ -0,0 +1,64 @@
+package bank
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func write(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoadFormats(t *testing.T) {
+	files := []string{
+		write(t, "q.csv", "5+5,10,ten\n\"what is 2, doubled\",4\n"),
+		write(t, "q.json", `[{"question": "5+5", "answer": "10", "alternatives": ["ten"]}, {"question": "what is 2, doubled", "answer": "4"}]`),
+		write(t, "q.yaml", "- question: 5+5\n  answer: \"10\"\n  alternatives: [ten]\n- question: what is 2, doubled\n  answer: \"4\"\n"),
+	}
+
+	for _, file := range files {
+		qs, err := Load(file)
+		if err != nil {
+			t.Fatalf("%s: %v", filepath.Ext(file), err)
+		}
+		if len(qs) != 2 || qs[0].Answer != "10" || len(qs[0].Answers()) != 2 || qs[1].Prompt != "what is 2, doubled" {
+			t.Errorf("%s: unexpected questions %+v", filepath.Ext(file), qs)
+		}
+	}
+}
+
+func TestLoadRejectsBadBanks(t *testing.T) {
+	for _, file := range []string{
+		write(t, "q.csv", "only a question\n"),
+		write(t, "q.json", `[{"question": "no answer"}]`),
+		write(t, "q.yaml", "[]\n"),
+		write(t, "q.txt", "5+5,10\n"),
+	} {
+		if _, err := Load(file); err == nil {
+			t.Errorf("expected %s to be rejected", filepath.Base(file))
+		}
+	}
+}
+
+func TestShuffleKeepsQuestions(t *testing.T) {
+	qs := []Question{{Prompt: "a"}, {Prompt: "b"}, {Prompt: "c"}, {Prompt: "d"}}
+	shuffled := Shuffle(qs, rand.New(rand.NewSource(1)))
+
+	if len(shuffled) != len(qs) || qs[0].Prompt != "a" {
+		t.Fatalf("expected the input to stay untouched, got %v", qs)
+	}
+	seen := map[string]bool{}
+	for _, q := range shuffled {
+		seen[q.Prompt] = true
+	}
+	if len(seen) != len(qs) {
+		t.Errorf("expected every question once, got %v", shuffled)
+	}
+}
@@ -0,0 +1,169 @@
+// Package game runs a quiz over an input and output stream.
+package game
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"gophercises/quiz/bank"
+)
+
+type Config struct {
+	// PerQuestion limits the time for each question, 0 means no limit.
+	PerQuestion time.Duration
+	// Total limits the whole quiz, 0 means no limit.
+	Total time.Duration
+}
+
+// Reasons the quiz ended.
+const (
+	Finished    = "finished"
+	TimeUp      = "time_up"
+	InputClosed = "input_closed"
+)
+
+type Answer struct {
+	Question string        `json:"question"`
+	Expected string        `json:"expected"`
+	Given    string        `json:"given"`
+	Correct  bool          `json:"correct"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+	Answered bool          `json:"answered"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+type Result struct {
+	Answers []Answer      `json:"answers"`
+	Correct int           `json:"correct"`
+	Total   int           `json:"total"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+	Reason  string        `json:"reason"`
+}
+
+// Run asks every question on out and reads one answer per line from in.
+// A question whose own time runs out counts as wrong and the quiz goes on;
+// when the total time runs out, or ctx is cancelled, the remaining
+// questions are left unanswered.
+//
+// in is read by a single goroutine for the whole quiz, one line per
+// question. A line the player finishes after their question timed out is
+// dropped instead of being taken as the answer to the next one. The
+// goroutine exits once in is closed or Run returns; a read blocked on a
+// terminal cannot be interrupted, so it may outlive Run by one line.
+func Run(ctx context.Context, questions []bank.Question, in io.Reader, out io.Writer, cfg Config) Result {
+	if cfg.Total > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Total)
+		defer cancel()
+	}
+
+	requests := make(chan int, 1)
+	defer close(requests)
+	lines := readLines(ctx, in, requests)
+	pending := false
+
+	res := Result{Total: len(questions), Reason: Finished}
+	start := time.Now()
+
+	for i, q := range questions {
+		fmt.Fprintf(out, "%d Ques: %s\nAns: ", i+1, q.Prompt)
+
+		ans := Answer{Question: q.Prompt, Expected: q.Answer}
+		asked := time.Now()
+
+		var perQuestion <-chan time.Time
+		var timer *time.Timer
+		if cfg.PerQuestion > 0 {
+			timer = time.NewTimer(cfg.PerQuestion)
+			perQuestion = timer.C
+		}
+
+	wait:
+		for {
+			// The line still being read for an earlier question is
+			// awaited and dropped before this one gets its own.
+			if !pending {
+				requests <- i
+				pending = true
+			}
+
+			select {
+			case <-ctx.Done():
+				fmt.Fprintln(out)
+				res.Reason = TimeUp
+			case <-perQuestion:
+				fmt.Fprintln(out, "\nTime's up for this question!")
+				ans.TimedOut = true
+			case l, ok := <-lines:
+				if !ok {
+					fmt.Fprintln(out)
+					res.Reason = InputClosed
+					break
+				}
+				pending = false
+				if l.question != i {
+					continue wait
+				}
+				ans.Given = l.text
+				ans.Answered = true
+				ans.Correct = Match(l.text, q.Answers()...)
+			}
+			break
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if res.Reason != Finished {
+			break
+		}
+
+		ans.Duration = time.Since(asked)
+		if ans.Correct {
+			res.Correct++
+		}
+		res.Answers = append(res.Answers, ans)
+	}
+
+	// Questions never reached are reported unanswered.
+	for _, q := range questions[len(res.Answers):] {
+		res.Answers = append(res.Answers, Answer{Question: q.Prompt, Expected: q.Answer})
+	}
+
+	res.Elapsed = time.Since(start)
+	return res
+}
+
+// line is a line of input and the question it was requested for.
+type line struct {
+	question int
+	text     string
+}
+
+// readLines reads one line of in for every question index received on
+// requests and sends it tagged with that index. It stops when in ends,
+// requests is closed or ctx is done.
+func readLines(ctx context.Context, in io.Reader, requests <-chan int) <-chan line {
+	lines := make(chan line)
+
+	go func() {
+		defer close(lines)
+
+		scanner := bufio.NewScanner(in)
+		for question := range requests {
+			if !scanner.Scan() {
+				return
+			}
+			select {
+			case lines <- line{question, scanner.Text()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return lines
+}
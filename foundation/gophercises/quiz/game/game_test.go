@@ -0,0 +1,127 @@
+package game
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"gophercises/quiz/bank"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		given    string
+		accepted []string
+		want     bool
+	}{
+		{"10", []string{"10"}, true},
+		{"  10 ", []string{"10"}, true},
+		{"10.0", []string{"10"}, true},
+		{"1e1", []string{"10"}, true},
+		{"20/2", []string{"10"}, true},
+		{"0.5", []string{"1/2"}, true},
+		{"11", []string{"10"}, false},
+		{"Paris", []string{"paris"}, true},
+		{"new   york", []string{"New York"}, true},
+		{"ten", []string{"10", "ten"}, true},
+		{"", []string{"10"}, false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.given, tt.accepted...); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.given, tt.accepted, got, tt.want)
+		}
+	}
+}
+
+var questions = []bank.Question{
+	{Prompt: "5+5", Answer: "10"},
+	{Prompt: "capital of France", Answer: "Paris"},
+	{Prompt: "1+1", Answer: "2"},
+}
+
+func TestRunScoresAnswers(t *testing.T) {
+	res := Run(context.Background(), questions, strings.NewReader("10.0\nparis\n3\n"), io.Discard, Config{})
+
+	if res.Correct != 2 || res.Total != 3 || res.Reason != Finished {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if !res.Answers[0].Correct || !res.Answers[1].Correct || res.Answers[2].Correct {
+		t.Errorf("unexpected answers %+v", res.Answers)
+	}
+}
+
+func TestRunPerQuestionLimit(t *testing.T) {
+	in, w := io.Pipe()
+	defer w.Close()
+
+	go func() {
+		w.Write([]byte("10\n"))
+		// Miss the second question, the late answer is dropped, then
+		// answer the third.
+		time.Sleep(70 * time.Millisecond)
+		w.Write([]byte("paris\n"))
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("2\n"))
+	}()
+
+	res := Run(context.Background(), questions, in, io.Discard, Config{PerQuestion: 50 * time.Millisecond})
+
+	if !res.Answers[1].TimedOut || res.Answers[1].Answered {
+		t.Errorf("expected the second question to time out, got %+v", res.Answers[1])
+	}
+	if res.Correct != 2 || res.Reason != Finished {
+		t.Errorf("expected the quiz to go on after a timeout, got %+v", res)
+	}
+}
+
+func TestRunDropsLateAnswer(t *testing.T) {
+	same := []bank.Question{
+		{Prompt: "1+1", Answer: "2"},
+		{Prompt: "4/2", Answer: "2"},
+	}
+
+	in, w := io.Pipe()
+	defer w.Close()
+
+	// The answer to the first question comes after its time is up, while
+	// the second is asked.
+	go func() {
+		time.Sleep(70 * time.Millisecond)
+		w.Write([]byte("2\n"))
+	}()
+
+	res := Run(context.Background(), same, in, io.Discard, Config{PerQuestion: 50 * time.Millisecond})
+
+	if !res.Answers[0].TimedOut {
+		t.Errorf("expected the first question to time out, got %+v", res.Answers[0])
+	}
+	if res.Answers[1].Answered || res.Correct != 0 {
+		t.Errorf("expected the late answer not to count for the second question, got %+v", res.Answers[1])
+	}
+}
+
+func TestRunTotalLimit(t *testing.T) {
+	in, w := io.Pipe()
+	defer w.Close()
+
+	go w.Write([]byte("10\n"))
+
+	res := Run(context.Background(), questions, in, io.Discard, Config{Total: 50 * time.Millisecond})
+
+	if res.Reason != TimeUp || res.Correct != 1 || len(res.Answers) != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.Answers[1].Answered || res.Answers[2].Answered {
+		t.Errorf("expected remaining questions to be unanswered, got %+v", res.Answers)
+	}
+}
+
+func TestRunInputClosed(t *testing.T) {
+	res := Run(context.Background(), questions, strings.NewReader("10\n"), io.Discard, Config{})
+
+	if res.Reason != InputClosed || res.Correct != 1 {
+		t.Errorf("unexpected result %+v", res)
+	}
+}
@@ -0,0 +1,38 @@
+package game
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Match reports whether given is one of the accepted answers. Answers are
+// compared after trimming, case folding and collapsing inner whitespace.
+// Numbers compare by value, so "10", "10.0", "1e1" and "20/2" are equal.
+func Match(given string, accepted ...string) bool {
+	g := normalize(given)
+	gNum, gIsNum := number(g)
+
+	for _, a := range accepted {
+		a = normalize(a)
+		if g == a {
+			return true
+		}
+		if aNum, ok := number(a); ok && gIsNum && gNum.Cmp(aNum) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// number parses integers, decimals, exponents and fractions exactly.
+func number(s string) (*big.Rat, bool) {
+	s = strings.ReplaceAll(s, " ", "")
+	if s == "" {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
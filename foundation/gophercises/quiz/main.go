@@ -1,98 +1,66 @@
 package main
 
 import (
-	"bytes"
-	"encoding/csv"
+	"context"
+	"flag"
 	"fmt"
-	"io"
+	"math/rand"
 	"os"
+	"os/signal"
 	"time"
+
+	"gophercises/quiz/bank"
+	"gophercises/quiz/game"
+	"gophercises/quiz/report"
 )
 
 func main() {
-	data, err := readCSVFile("problems.csv")
-
-	if err != nil {
-		fmt.Println("Error reading file:", err)
-		return
+	file := flag.String("file", "problems.csv", "question bank: .csv (question,answer), .json or .yaml")
+	limit := flag.Duration("limit", 30*time.Second, "time limit for the whole quiz, 0 for none")
+	perQuestion := flag.Duration("per-question", 0, "time limit for each question, 0 for none")
+	shuffle := flag.Bool("shuffle", false, "ask the questions in random order")
+	format := flag.String("report", "text", "results report format: text or json")
+	flag.Parse()
+
+	if *format != "text" && *format != "json" {
+		fmt.Println("Error: -report must be text or json")
+		os.Exit(2)
 	}
-	reader, err := parseCSV(data)
+
+	questions, err := bank.Load(*file)
 	if err != nil {
-		fmt.Println("Error creating CSV reader:", err)
-		return
+		fmt.Println("Error reading questions:", err)
+		os.Exit(1)
 	}
-	records := processCSV(reader)
-
-	fmt.Println("Math Quiz")
-
-	correctAns := 0
-
-	timer := time.NewTimer(time.Duration(5)*time.Second)
-
-	for ind, record := range records {
-		fmt.Println(ind+1, "Ques:", record[0])
 
-		answerChannel := make(chan string)
-
-		go func(){
-			answer := takeUserInput()
-			answerChannel <- answer
-		}()
-
-		select {
-		case <-timer.C:
-			fmt.Printf("\nYou got %d correct answers out of %d\n", correctAns, len(records))
-			return
-		case answer := <- answerChannel:
-			if answer == record[1] {
-				correctAns += 1
-			}
-		}
+	if *shuffle {
+		questions = bank.Shuffle(questions, rand.New(rand.NewSource(time.Now().UnixNano())))
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-}
-
-func readCSVFile(filename string) ([]byte, error) {
-	f, err := os.Open(filename)
-
-	if err != nil {
-		return nil, err
+	fmt.Printf("Quiz: %d questions", len(questions))
+	if *limit > 0 {
+		fmt.Printf(", %s in total", *limit)
 	}
-
-	defer f.Close()
-
-	data, err := io.ReadAll(f)
-
-	if err != nil {
-		return nil, err
+	if *perQuestion > 0 {
+		fmt.Printf(", %s per question", *perQuestion)
 	}
+	fmt.Println()
 
-	return data, nil
-
-}
-
-func parseCSV(data []byte) (*csv.Reader, error) {
-	reader := csv.NewReader(bytes.NewReader(data))
-
-	return reader, nil
-}
-
-func processCSV(reader *csv.Reader) [][]string {
-	record, err := reader.ReadAll()
+	res := game.Run(ctx, questions, os.Stdin, os.Stdout, game.Config{
+		PerQuestion: *perQuestion,
+		Total:       *limit,
+	})
 
+	if *format == "json" {
+		err = report.JSON(os.Stdout, res)
+	} else {
+		err = report.Text(os.Stdout, res)
+	}
 	if err != nil {
-		fmt.Println("Error reading CSV data:", err)
+		fmt.Println("Error writing report:", err)
+		os.Exit(1)
 	}
-
-	return record
-}
-
-func takeUserInput() string {
-	var userInput string
-
-	fmt.Print("Ans: ")
-	fmt.Scanf("%s", &userInput)
-
-	return userInput
 }
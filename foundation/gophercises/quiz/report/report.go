@@ -0,0 +1,66 @@
+// Package report prints the result of a quiz.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"gophercises/quiz/game"
+)
+
+// Text writes a table of every question followed by the score.
+func Text(w io.Writer, res game.Result) error {
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tQUESTION\tYOUR ANSWER\tEXPECTED\tRESULT\tTIME")
+	for i, a := range res.Answers {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, a.Question, a.Given, a.Expected, outcome(a), a.Duration.Round(time.Millisecond))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	percent := 0.0
+	if res.Total > 0 {
+		percent = float64(res.Correct) * 100 / float64(res.Total)
+	}
+
+	_, err := fmt.Fprintf(w, "\nYou got %d correct answers out of %d (%.0f%%) in %s, %s\n",
+		res.Correct, res.Total, percent, res.Elapsed.Round(time.Millisecond), reason(res.Reason))
+	return err
+}
+
+// JSON writes the result as indented JSON.
+func JSON(w io.Writer, res game.Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+func outcome(a game.Answer) string {
+	switch {
+	case a.Correct:
+		return "correct"
+	case a.TimedOut:
+		return "timed out"
+	case !a.Answered:
+		return "unanswered"
+	default:
+		return "wrong"
+	}
+}
+
+func reason(r string) string {
+	switch r {
+	case game.TimeUp:
+		return "time ran out"
+	case game.InputClosed:
+		return "input ended"
+	default:
+		return "all questions answered"
+	}
+}
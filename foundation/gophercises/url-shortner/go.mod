@@ -1,3 +1,10 @@
 module gophercises/url-shortner
 
 go 1.23.4
+
+require (
+	go.etcd.io/bbolt v1.3.11
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.4.0 // indirect
@@ -0,0 +1,22 @@
+package link
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireToken lets a request through to next only when it carries
+// "Authorization: Bearer <token>". An empty token rejects every request, so
+// a server started without one cannot be written to.
+func RequireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "a valid API token is required")
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,268 @@
+package link
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gophercises/url-shortner/models"
+	"gophercises/url-shortner/store"
+)
+
+const (
+	codeLength   = 6
+	codeAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	maxAttempts  = 5
+)
+
+// reserved codes would shadow routes of the server.
+var reserved = map[string]bool{"api": true}
+
+type Handler struct {
+	store   store.Store
+	baseURL string
+	now     func() time.Time
+	log     *slog.Logger
+}
+
+// New returns the link handlers. baseURL is used to build the short URL in
+// responses, e.g. "http://go".
+func New(s store.Store, baseURL string) *Handler {
+	return &Handler{
+		store:   s,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		now:     time.Now,
+		log:     slog.Default(),
+	}
+}
+
+type createRequest struct {
+	URL  string `json:"url"`
+	Code string `json:"code"`
+	// ExpiresAt and TTL are alternatives, TTL is a Go duration like "72h".
+	ExpiresAt *time.Time `json:"expires_at"`
+	TTL       string     `json:"ttl"`
+}
+
+type linkResponse struct {
+	models.Link
+	ShortURL string `json:"short_url"`
+}
+
+// Create handles POST /api/links. Without a code one is generated.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "failed to parse the data")
+		return
+	}
+
+	link := models.Link{
+		Code:      req.Code,
+		URL:       req.URL,
+		CreatedAt: h.now().UTC(),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	if req.TTL != "" {
+		ttl, err := time.ParseDuration(req.TTL)
+		if err != nil || ttl <= 0 {
+			writeError(w, http.StatusBadRequest, "ttl must be a positive duration like 72h")
+			return
+		}
+		expires := link.CreatedAt.Add(ttl)
+		link.ExpiresAt = &expires
+	}
+
+	if link.ExpiresAt != nil && link.Expired(link.CreatedAt) {
+		writeError(w, http.StatusBadRequest, "expires_at is in the past")
+		return
+	}
+
+	var err error
+	if link.Code != "" {
+		err = h.createCustom(r, &link)
+	} else {
+		err = h.createGenerated(r, &link)
+	}
+
+	switch {
+	case errors.Is(err, store.ErrExists):
+		writeError(w, http.StatusConflict, fmt.Sprintf("code %q is already in use", link.Code))
+	case errors.Is(err, errInvalid):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to save link")
+	default:
+		writeJSON(w, http.StatusCreated, h.response(link))
+	}
+}
+
+var errInvalid = errors.New("invalid link")
+
+func (h *Handler) createCustom(r *http.Request, link *models.Link) error {
+	if reserved[link.Code] {
+		return fmt.Errorf("%w: code %q is reserved", errInvalid, link.Code)
+	}
+	if err := link.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+	return h.store.Create(r.Context(), link)
+}
+
+func (h *Handler) createGenerated(r *http.Request, link *models.Link) error {
+	for i := 0; i < maxAttempts; i++ {
+		code, err := generateCode()
+		if err != nil {
+			return err
+		}
+		link.Code = code
+
+		if err := link.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", errInvalid, err)
+		}
+
+		err = h.store.Create(r.Context(), link)
+		if !errors.Is(err, store.ErrExists) {
+			return err
+		}
+	}
+	return errors.New("could not generate a free code")
+}
+
+func generateCode() (string, error) {
+	b := make([]byte, codeLength)
+	max := big.NewInt(int64(len(codeAlphabet)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = codeAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// List handles GET /api/links.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
+	links, err := h.store.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch links")
+		return
+	}
+
+	resp := make([]linkResponse, len(links))
+	for i, l := range links {
+		resp[i] = h.response(l)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Get handles GET /api/links/{code}.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	link, err := h.store.Get(r.Context(), r.PathValue("code"))
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "link not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch link")
+		return
+	}
+	writeJSON(w, http.StatusOK, h.response(*link))
+}
+
+// Delete handles DELETE /api/links/{code}.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	err := h.store.Delete(r.Context(), r.PathValue("code"))
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "link not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete link")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Stats handles GET /api/links/{code}/stats.
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.store.Stats(r.Context(), r.PathValue("code"))
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "link not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch stats")
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// Redirect sends /{code} to the link's URL and counts the click. Other
+// paths, and codes that do not exist, go to fallback. Expired links answer
+// 410 Gone.
+func (h *Handler) Redirect(fallback http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := strings.TrimPrefix(r.URL.Path, "/")
+		if code == "" || strings.Contains(code, "/") || reserved[code] {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		link, err := h.store.Get(r.Context(), code)
+		if errors.Is(err, store.ErrNotFound) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to fetch link", http.StatusInternalServerError)
+			return
+		}
+
+		now := h.now()
+		if link.Expired(now) {
+			http.Error(w, "link expired", http.StatusGone)
+			return
+		}
+
+		click := models.Click{Time: now, Referrer: referrer(r)}
+		if err := h.store.RecordClick(r.Context(), code, click); err != nil {
+			// A lost click must not break the redirect.
+			h.log.Warn("failed to record click", "code", code, "error", err)
+		}
+
+		http.Redirect(w, r, link.URL, http.StatusFound)
+	}
+}
+
+// referrer is the host of the Referer header, or "direct" without one.
+func referrer(r *http.Request) string {
+	u, err := url.Parse(r.Referer())
+	if err != nil || u.Host == "" {
+		return "direct"
+	}
+	return u.Host
+}
+
+func (h *Handler) response(l models.Link) linkResponse {
+	return linkResponse{Link: l, ShortURL: h.baseURL + "/" + l.Code}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,157 @@
+package link
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gophercises/url-shortner/store/bolt"
+)
+
+const testToken = "s3cret"
+
+var auth = []string{"Authorization", "Bearer " + testToken}
+
+func newServer(t *testing.T) (*Handler, http.Handler) {
+	t.Helper()
+
+	s, err := bolt.Open(filepath.Join(t.TempDir(), "links.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	h := New(s, "http://go/")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/links", RequireToken(testToken, h.Create))
+	mux.HandleFunc("GET /api/links/{code}", h.Get)
+	mux.HandleFunc("GET /api/links/{code}/stats", h.Stats)
+	mux.HandleFunc("DELETE /api/links/{code}", RequireToken(testToken, h.Delete))
+	return h, h.Redirect(mux)
+}
+
+func do(t *testing.T, h http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCreateAndRedirect(t *testing.T) {
+	_, srv := newServer(t)
+
+	w := do(t, srv, http.MethodPost, "/api/links", `{"url": "https://example.com/docs"}`, auth...)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+
+	var created linkResponse
+	json.NewDecoder(w.Body).Decode(&created)
+	if len(created.Code) != codeLength || created.ShortURL != "http://go/"+created.Code {
+		t.Fatalf("unexpected link %+v", created)
+	}
+
+	w = do(t, srv, http.MethodGet, "/"+created.Code, "", "Referer", "https://wiki.example.com/page")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/docs" {
+		t.Fatalf("redirect: %d %s", w.Code, w.Header().Get("Location"))
+	}
+
+	w = do(t, srv, http.MethodGet, "/api/links/"+created.Code+"/stats", "")
+	var stats struct {
+		Clicks    int64            `json:"clicks"`
+		Referrers map[string]int64 `json:"referrers"`
+	}
+	json.NewDecoder(w.Body).Decode(&stats)
+	if stats.Clicks != 1 || stats.Referrers["wiki.example.com"] != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestCreateCustomCode(t *testing.T) {
+	_, srv := newServer(t)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"url": "https://example.com", "code": "standup"}`, http.StatusCreated},
+		{`{"url": "https://example.org", "code": "standup"}`, http.StatusConflict},
+		{`{"url": "https://example.com", "code": "api"}`, http.StatusBadRequest},
+		{`{"url": "https://example.com", "code": "no spaces"}`, http.StatusBadRequest},
+		{`{"url": "ftp://example.com", "code": "ftp"}`, http.StatusBadRequest},
+		{`{"url": "https://example.com", "ttl": "-1h"}`, http.StatusBadRequest},
+		{`{"url": "https://example.com", "expires_at": "2000-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{`not json`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := do(t, srv, http.MethodPost, "/api/links", tt.body, auth...); w.Code != tt.want {
+			t.Errorf("%s: got %d %s, want %d", tt.body, w.Code, w.Body, tt.want)
+		}
+	}
+}
+
+func TestExpiredLinkIsGone(t *testing.T) {
+	h, srv := newServer(t)
+
+	w := do(t, srv, http.MethodPost, "/api/links", `{"url": "https://example.com", "code": "promo", "ttl": "1h"}`, auth...)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+
+	h.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	if w := do(t, srv, http.MethodGet, "/promo", ""); w.Code != http.StatusGone {
+		t.Errorf("expected 410 for an expired link, got %d", w.Code)
+	}
+}
+
+func TestUnknownCodeFallsThrough(t *testing.T) {
+	_, srv := newServer(t)
+
+	if w := do(t, srv, http.MethodGet, "/nope", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected fallback 404, got %d", w.Code)
+	}
+	if w := do(t, srv, http.MethodGet, "/api/links/nope", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 from the API, got %d", w.Code)
+	}
+}
+
+func TestWritesNeedToken(t *testing.T) {
+	_, srv := newServer(t)
+
+	body := `{"url": "https://example.com", "code": "docs"}`
+	for _, header := range [][]string{
+		nil,
+		{"Authorization", "Bearer wrong"},
+		{"Authorization", testToken},
+	} {
+		if w := do(t, srv, http.MethodPost, "/api/links", body, header...); w.Code != http.StatusUnauthorized {
+			t.Errorf("create with %v: got %d, want 401", header, w.Code)
+		}
+	}
+
+	if w := do(t, srv, http.MethodPost, "/api/links", body, auth...); w.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	if w := do(t, srv, http.MethodDelete, "/api/links/docs", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("delete without a token: got %d, want 401", w.Code)
+	}
+	if w := do(t, srv, http.MethodDelete, "/api/links/docs", "", auth...); w.Code != http.StatusNoContent {
+		t.Errorf("delete: got %d, want 204", w.Code)
+	}
+
+	// Without a configured token nobody can write.
+	closed := RequireToken("", func(w http.ResponseWriter, r *http.Request) { t.Error("reached the handler") })
+	if w := do(t, closed, http.MethodPost, "/api/links", body, "Authorization", "Bearer "); w.Code != http.StatusUnauthorized {
+		t.Errorf("empty token: got %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,76 @@
+// Package linkfile reads links from YAML or JSON files, so go-links can be
+// kept in version control and loaded at startup:
+//
+//   - code: github
+//     url: https://github.com/premgowda98
+//   - path: /standup
+//     url: https://meet.example.com/standup
+//     expires_at: 2025-12-31T00:00:00Z
+//
+// path is accepted as an alias of code for the gophercises format.
+package linkfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"gophercises/url-shortner/models"
+)
+
+type entry struct {
+	Code      string     `json:"code" yaml:"code"`
+	Path      string     `json:"path" yaml:"path"`
+	URL       string     `json:"url" yaml:"url"`
+	ExpiresAt *time.Time `json:"expires_at" yaml:"expires_at"`
+}
+
+// Load reads a .json file, or YAML for any other extension.
+func Load(file string) ([]models.Link, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	if strings.EqualFold(filepath.Ext(file), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&entries)
+		if err == nil && dec.More() {
+			err = fmt.Errorf("unexpected data after the list of links")
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", file, err)
+	}
+
+	links := make([]models.Link, 0, len(entries))
+	seen := map[string]bool{}
+	for i, e := range entries {
+		code := e.Code
+		if code == "" {
+			code = strings.TrimPrefix(e.Path, "/")
+		}
+		if code == "" || e.URL == "" {
+			return nil, fmt.Errorf("%s: entry %d needs a code and a url", file, i+1)
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("%s: duplicate code %q", file, code)
+		}
+		seen[code] = true
+
+		links = append(links, models.Link{Code: code, URL: e.URL, ExpiresAt: e.ExpiresAt})
+	}
+	return links, nil
+}
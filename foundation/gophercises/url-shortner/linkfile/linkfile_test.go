@@ -0,0 +1,58 @@
+package linkfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func write(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	yamlFile := write(t, "links.yaml", `
+- code: github
+  url: https://github.com
+- path: /standup
+  url: https://meet.example.com/standup
+  expires_at: 2030-01-01T00:00:00Z
+`)
+	jsonFile := write(t, "links.json", `[{"code": "github", "url": "https://github.com"}, {"path": "/standup", "url": "https://meet.example.com/standup", "expires_at": "2030-01-01T00:00:00Z"}]`)
+
+	for _, file := range []string{yamlFile, jsonFile} {
+		links, err := Load(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != 2 || links[1].Code != "standup" || links[1].ExpiresAt == nil || links[1].ExpiresAt.Year() != 2030 {
+			t.Errorf("%s: unexpected links %+v", filepath.Ext(file), links)
+		}
+	}
+}
+
+func TestLoadRejectsBadEntries(t *testing.T) {
+	for _, content := range []string{
+		"- url: https://example.com\n",
+		"- code: a\n  url: https://example.com\n- code: a\n  url: https://example.org\n",
+		"- code: a\n  target: https://example.com\n",
+	} {
+		if _, err := Load(write(t, "links.yaml", content)); err == nil {
+			t.Errorf("expected %q to be rejected", content)
+		}
+	}
+
+	for _, content := range []string{
+		`[{"code": "a", "target": "https://example.com"}]`,
+		`[{"code": "a", "url": "https://example.com"}] []`,
+	} {
+		if _, err := Load(write(t, "links.json", content)); err == nil {
+			t.Errorf("expected %q to be rejected", content)
+		}
+	}
+}
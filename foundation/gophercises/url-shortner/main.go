@@ -1,40 +1,94 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gophercises/url-shortner/handlers/link"
+	"gophercises/url-shortner/linkfile"
+	"gophercises/url-shortner/store"
+	"gophercises/url-shortner/store/bolt"
 )
 
 func main() {
-	urls := map[string]string{
-		"/prem":     "https://portfolio.premgowda.in",
-		"/github":   "https://github.com/premgowda98",
-		"/linkedin": "https://linkedin.com/in/premgowda98",
+	addr := flag.String("addr", ":8090", "listen address")
+	dbPath := flag.String("db", "links.db", "BoltDB file")
+	baseURL := flag.String("base-url", "http://localhost:8090", "base of the short URLs returned by the API")
+	files := flag.String("links", "links.yaml", "comma separated YAML or JSON link files loaded at startup")
+	token := flag.String("api-token", os.Getenv("SHORTENER_API_TOKEN"), "bearer token for creating and deleting links, $SHORTENER_API_TOKEN by default")
+	flag.Parse()
+
+	if *token == "" {
+		log.Println("no -api-token set, creating and deleting links through the API is disabled")
+	}
+
+	s, err := bolt.Open(*dbPath)
+	if err != nil {
+		log.Fatalf("could not open database: %v", err)
+	}
+	defer s.Close()
+
+	for _, file := range strings.Split(*files, ",") {
+		if file == "" {
+			continue
+		}
+		if err := loadLinks(s, file); err != nil {
+			log.Fatalf("could not load links: %v", err)
+		}
 	}
 
+	linkHandler := link.New(s, *baseURL)
+
 	router := http.NewServeMux()
 
-	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	router.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "Home Page")
 	})
 
-	handler := redirectHandler(urls, router)
+	router.HandleFunc("POST /api/links", link.RequireToken(*token, linkHandler.Create))
+	router.HandleFunc("GET /api/links", linkHandler.List)
+	router.HandleFunc("GET /api/links/{code}", linkHandler.Get)
+	router.HandleFunc("DELETE /api/links/{code}", link.RequireToken(*token, linkHandler.Delete))
+	router.HandleFunc("GET /api/links/{code}/stats", linkHandler.Stats)
 
-	fmt.Println("Server is listening at port 8090")
-	http.ListenAndServe(":8090", handler)
+	handler := linkHandler.Redirect(router)
+
+	fmt.Printf("Server is listening at %s\n", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
 }
 
-func redirectHandler(urls map[string]string, fallbach http.Handler) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path
+// loadLinks saves every link of file, replacing links with the same code.
+func loadLinks(s store.Link, file string) error {
+	links, err := linkfile.Load(file)
+	if err != nil {
+		return err
+	}
 
-		dest, ok := urls[path]
+	ctx := context.Background()
+	for i := range links {
+		l := &links[i]
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
 
-		if !ok {
-			fallbach.ServeHTTP(w, r)
-			return
+		// Keep the creation time of links that were already loaded.
+		if existing, err := s.Get(ctx, l.Code); err == nil {
+			l.CreatedAt = existing.CreatedAt
+		} else {
+			l.CreatedAt = time.Now().UTC()
 		}
 
-		http.Redirect(w, r, dest, http.StatusFound)
+		if err := s.Save(ctx, l); err != nil {
+			return err
+		}
 	}
+
+	fmt.Printf("Loaded %d links from %s\n", len(links), file)
+	return nil
 }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type Link struct {
+	Code      string     `json:"code"`
+	URL       string     `json:"url"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expired reports whether the link stopped redirecting at now.
+func (l *Link) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// Validate checks the code and that the URL is an absolute http(s) URL.
+func (l *Link) Validate() error {
+	if !codePattern.MatchString(l.Code) {
+		return fmt.Errorf("invalid code %q: use 1-64 letters, digits, '-' or '_'", l.Code)
+	}
+
+	u, err := url.Parse(l.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// Click is one redirect through a link.
+type Click struct {
+	Time     time.Time
+	Referrer string
+}
+
+// Stats are the click counters of a link. Hourly buckets are keyed
+// "2006-01-02T15" and daily buckets "2006-01-02", both in UTC.
+type Stats struct {
+	Code      string           `json:"code"`
+	Clicks    int64            `json:"clicks"`
+	LastClick *time.Time       `json:"last_click,omitempty"`
+	Referrers map[string]int64 `json:"referrers"`
+	Hourly    map[string]int64 `json:"hourly"`
+	Daily     map[string]int64 `json:"daily"`
+}
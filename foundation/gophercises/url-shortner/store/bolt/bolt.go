@@ -0,0 +1,272 @@
+// Package bolt stores links and their click stats in a BoltDB file.
+//
+// Links are JSON values in the "links" bucket keyed by code. Each link has
+// a bucket of the same name under "stats" holding the total and last click
+// and nested buckets of counters per referrer, hour and day.
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"gophercises/url-shortner/models"
+	"gophercises/url-shortner/store"
+)
+
+// HourlyRetention is how long hourly buckets are kept, daily buckets are
+// kept for the life of the link.
+const HourlyRetention = 7 * 24 * time.Hour
+
+const (
+	hourFormat = "2006-01-02T15"
+	dayFormat  = "2006-01-02"
+)
+
+var (
+	linksBucket = []byte("links")
+	statsBucket = []byte("stats")
+
+	totalKey     = []byte("total")
+	lastKey      = []byte("last")
+	referrersKey = []byte("referrers")
+	hourlyKey    = []byte("hourly")
+	dailyKey     = []byte("daily")
+)
+
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens or creates the database file.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{linksBucket, statsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Create(ctx context.Context, link *models.Link) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(linksBucket).Get([]byte(link.Code)) != nil {
+			return store.ErrExists
+		}
+		return put(tx, link)
+	})
+}
+
+func (s *Store) Save(ctx context.Context, link *models.Link) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return put(tx, link)
+	})
+}
+
+func put(tx *bolt.Tx, link *models.Link) error {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(linksBucket).Put([]byte(link.Code), data)
+}
+
+func (s *Store) Get(ctx context.Context, code string) (*models.Link, error) {
+	var link models.Link
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(linksBucket).Get([]byte(code))
+		if data == nil {
+			return store.ErrNotFound
+		}
+		return json.Unmarshal(data, &link)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (s *Store) List(ctx context.Context) ([]models.Link, error) {
+	links := []models.Link{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(linksBucket).ForEach(func(_, data []byte) error {
+			var link models.Link
+			if err := json.Unmarshal(data, &link); err != nil {
+				return err
+			}
+			links = append(links, link)
+			return nil
+		})
+	})
+
+	return links, err
+}
+
+func (s *Store) Delete(ctx context.Context, code string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		links := tx.Bucket(linksBucket)
+		if links.Get([]byte(code)) == nil {
+			return store.ErrNotFound
+		}
+		if err := links.Delete([]byte(code)); err != nil {
+			return err
+		}
+
+		err := tx.Bucket(statsBucket).DeleteBucket([]byte(code))
+		if err == bolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// RecordClick counts a click. Redirects call it on every hit, so it goes
+// through db.Batch: concurrent clicks share one transaction and one fsync
+// instead of paying for their own.
+func (s *Store) RecordClick(ctx context.Context, code string, click models.Click) error {
+	t := click.Time.UTC()
+
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		if tx.Bucket(linksBucket).Get([]byte(code)) == nil {
+			return store.ErrNotFound
+		}
+
+		b, err := tx.Bucket(statsBucket).CreateBucketIfNotExists([]byte(code))
+		if err != nil {
+			return err
+		}
+
+		if err := increment(b, totalKey); err != nil {
+			return err
+		}
+		if err := b.Put(lastKey, encode(uint64(t.UnixNano()))); err != nil {
+			return err
+		}
+
+		counters := []struct {
+			bucket []byte
+			key    string
+		}{
+			{referrersKey, click.Referrer},
+			{hourlyKey, t.Format(hourFormat)},
+			{dailyKey, t.Format(dayFormat)},
+		}
+		for _, c := range counters {
+			sub, err := b.CreateBucketIfNotExists(c.bucket)
+			if err != nil {
+				return err
+			}
+			if err := increment(sub, []byte(c.key)); err != nil {
+				return err
+			}
+		}
+
+		return pruneHourly(b.Bucket(hourlyKey), t.Add(-HourlyRetention))
+	})
+}
+
+// pruneHourly drops hourly buckets older than cutoff. Keys sort in time
+// order, so it stops at the first one that is recent enough.
+func pruneHourly(b *bolt.Bucket, cutoff time.Time) error {
+	limit := cutoff.Format(hourFormat)
+
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && string(k) < limit; k, _ = c.First() {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) Stats(ctx context.Context, code string) (*models.Stats, error) {
+	stats := &models.Stats{
+		Code:      code,
+		Referrers: map[string]int64{},
+		Hourly:    map[string]int64{},
+		Daily:     map[string]int64{},
+	}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(linksBucket).Get([]byte(code)) == nil {
+			return store.ErrNotFound
+		}
+
+		b := tx.Bucket(statsBucket).Bucket([]byte(code))
+		if b == nil {
+			return nil
+		}
+
+		stats.Clicks = int64(decode(b.Get(totalKey)))
+		if v := b.Get(lastKey); v != nil {
+			last := time.Unix(0, int64(decode(v))).UTC()
+			stats.LastClick = &last
+		}
+
+		for key, m := range map[string]map[string]int64{
+			string(referrersKey): stats.Referrers,
+			string(hourlyKey):    stats.Hourly,
+			string(dailyKey):     stats.Daily,
+		} {
+			if err := readCounters(b.Bucket([]byte(key)), m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func readCounters(b *bolt.Bucket, into map[string]int64) error {
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error {
+		into[string(k)] = int64(decode(v))
+		return nil
+	})
+}
+
+func increment(b *bolt.Bucket, key []byte) error {
+	return b.Put(key, encode(decode(b.Get(key))+1))
+}
+
+func encode(n uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, n)
+	return buf
+}
+
+func decode(b []byte) uint64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
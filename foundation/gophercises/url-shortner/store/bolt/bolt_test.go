@@ -0,0 +1,163 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gophercises/url-shortner/models"
+	"gophercises/url-shortner/store"
+)
+
+func newStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "links.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestCreateGetDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	link := &models.Link{Code: "docs", URL: "https://example.com/docs", CreatedAt: time.Now().UTC()}
+	if err := s.Create(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Create(ctx, link); !errors.Is(err, store.ErrExists) {
+		t.Errorf("expected ErrExists for a taken code, got %v", err)
+	}
+
+	got, err := s.Get(ctx, "docs")
+	if err != nil || got.URL != link.URL {
+		t.Fatalf("Get = %+v, %v", got, err)
+	}
+
+	link.URL = "https://example.com/v2"
+	if err := s.Save(ctx, link); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get(ctx, "docs"); got.URL != link.URL {
+		t.Errorf("expected Save to replace the URL, got %s", got.URL)
+	}
+
+	if links, err := s.List(ctx); err != nil || len(links) != 1 {
+		t.Errorf("List = %v, %v", links, err)
+	}
+
+	if err := s.Delete(ctx, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "docs"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := s.Delete(ctx, "docs"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestClickStats(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	if err := s.Create(ctx, &models.Link{Code: "go", URL: "https://go.dev"}); err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2025, 3, 1, 10, 15, 0, 0, time.UTC)
+	clicks := []models.Click{
+		{Time: base, Referrer: "direct"},
+		{Time: base.Add(10 * time.Minute), Referrer: "slack.com"},
+		{Time: base.Add(2 * time.Hour), Referrer: "slack.com"},
+		{Time: base.Add(30 * time.Hour), Referrer: "direct"},
+	}
+	for _, c := range clicks {
+		if err := s.RecordClick(ctx, "go", c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := s.Stats(ctx, "go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Clicks != 4 {
+		t.Errorf("clicks = %d, want 4", stats.Clicks)
+	}
+	if stats.Referrers["slack.com"] != 2 || stats.Referrers["direct"] != 2 {
+		t.Errorf("unexpected referrers %v", stats.Referrers)
+	}
+	if stats.Hourly["2025-03-01T10"] != 2 || stats.Hourly["2025-03-01T12"] != 1 {
+		t.Errorf("unexpected hourly buckets %v", stats.Hourly)
+	}
+	if stats.Daily["2025-03-01"] != 3 || stats.Daily["2025-03-02"] != 1 {
+		t.Errorf("unexpected daily buckets %v", stats.Daily)
+	}
+	if stats.LastClick == nil || !stats.LastClick.Equal(base.Add(30*time.Hour)) {
+		t.Errorf("last click = %v", stats.LastClick)
+	}
+
+	if err := s.RecordClick(ctx, "missing", clicks[0]); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown code, got %v", err)
+	}
+}
+
+func TestHourlyBucketsArePruned(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	if err := s.Create(ctx, &models.Link{Code: "old", URL: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.RecordClick(ctx, "old", models.Click{Time: first, Referrer: "direct"})
+	s.RecordClick(ctx, "old", models.Click{Time: first.Add(HourlyRetention + time.Hour), Referrer: "direct"})
+
+	stats, err := s.Stats(ctx, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := stats.Hourly["2025-01-01T00"]; ok {
+		t.Errorf("expected hourly bucket past the retention to be pruned, got %v", stats.Hourly)
+	}
+	if stats.Daily["2025-01-01"] != 1 || stats.Clicks != 2 {
+		t.Errorf("expected daily buckets and totals to be kept, got %+v", stats)
+	}
+}
+
+func TestConcurrentClicksAreBatched(t *testing.T) {
+	ctx := context.Background()
+	s := newStore(t)
+
+	if err := s.Create(ctx, &models.Link{Code: "hot", URL: "https://example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.RecordClick(ctx, "hot", models.Click{Time: time.Now(), Referrer: "direct"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats, err := s.Stats(ctx, "hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Clicks != 50 || stats.Referrers["direct"] != 50 {
+		t.Errorf("expected 50 clicks, got %+v", stats)
+	}
+}
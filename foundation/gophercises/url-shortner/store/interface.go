@@ -0,0 +1,36 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"gophercises/url-shortner/models"
+)
+
+var (
+	// ErrNotFound is returned when no link has the requested code.
+	ErrNotFound = errors.New("link not found")
+	// ErrExists is returned by Create when the code is already taken.
+	ErrExists = errors.New("code already in use")
+)
+
+type Link interface {
+	// Create adds a new link and fails with ErrExists if the code is taken.
+	Create(ctx context.Context, link *models.Link) error
+	// Save adds or replaces a link, keeping the stats of a replaced one.
+	Save(ctx context.Context, link *models.Link) error
+	Get(ctx context.Context, code string) (*models.Link, error)
+	List(ctx context.Context) ([]models.Link, error)
+	// Delete removes a link and its stats.
+	Delete(ctx context.Context, code string) error
+}
+
+type Stats interface {
+	RecordClick(ctx context.Context, code string, click models.Click) error
+	Stats(ctx context.Context, code string) (*models.Stats, error)
+}
+
+type Store interface {
+	Link
+	Stats
+}
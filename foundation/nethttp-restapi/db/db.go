@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -9,97 +10,83 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var DB *sql.DB
-
-func InitDB() {
-	fmt.Println("Initailizing database")
+const (
+	DriverSQLite = "sqlite3"
+	DriverMySQL  = "mysql"
+)
 
-	var err error
-	DB, err = sqliteDB()
-	// DB, err = mysqlDB()
+// Config selects the database backend. Driver is either DriverSQLite or
+// DriverMySQL, the remaining fields are only read by the matching driver.
+type Config struct {
+	Driver string
 
-	if err != nil {
-		panic("could not connect to database")
-	}
+	SQLitePath string
 
-	DB.SetMaxOpenConns(10)
-	DB.SetMaxOpenConns(5)
-
-	createTables()
+	User     string
+	Password string
+	Host     string
+	Name     string
 }
 
-func sqliteDB() (*sql.DB, error) {
-	var err error
-	DB, err = sql.Open("sqlite3", "api.db")
+// LoadConfig reads the database settings from the environment. DB_DRIVER
+// defaults to sqlite3 so the service runs without any setup.
+func LoadConfig() Config {
+	return Config{
+		Driver:     getEnv("DB_DRIVER", DriverSQLite),
+		SQLitePath: getEnv("DB_PATH", "api.db"),
+		User:       os.Getenv("DB_USER"),
+		Password:   os.Getenv("DB_PASSWORD"),
+		Host:       os.Getenv("DB_HOST"),
+		Name:       os.Getenv("DB_NAME"),
+	}
+}
 
-	if err != nil {
-		panic("could not connect to database")
+func (c Config) dsn() (string, error) {
+	switch c.Driver {
+	case DriverSQLite:
+		return c.SQLitePath, nil
+	case DriverMySQL:
+		return fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true", c.User, c.Password, c.Host, c.Name), nil
+	default:
+		return "", fmt.Errorf("unsupported database driver %q", c.Driver)
 	}
-	return DB, nil
 }
 
-func mysqlDB() (*sql.DB, error) {
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	dbName := os.Getenv("DB_NAME")
-	conn_str := fmt.Sprintf("%s:%s@tcp(%s)/%s", user, password, host, dbName)
+// InitDB opens the configured database and applies any pending migrations.
+func InitDB(ctx context.Context, cfg Config) (*sql.DB, error) {
+	fmt.Println("Initailizing database")
 
-	DB, err := sql.Open("mysql", conn_str)
+	dsn, err := cfg.dsn()
 
 	if err != nil {
-		panic("could not connect to database")
+		return nil, err
 	}
-	return DB, nil
-}
 
-func createTables() {
-	booksTable := `
-	CREATE TABLE IF NOT EXISTS books (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		isbn TEXT NOT NULL,
-		release_date DATETIME,
-		author_id INTEGER
-	)
-	`
-
-	// booksTable := `
-	// CREATE TABLE IF NOT EXISTS bookmanagement.books (
-	// 	id INT AUTO_INCREMENT PRIMARY KEY,
-	// 	name VARCHAR(255) NOT NULL,
-	// 	isbn VARCHAR(20) NOT NULL,
-	// 	release_date DATETIME,
-	// 	author_id INT
-	// );
-	// `
-
-	_, err := DB.Exec(booksTable)
+	db, err := sql.Open(cfg.Driver, dsn)
 
 	if err != nil {
-		panic("could not create books table")
+		return nil, err
 	}
 
-	authorsTable := `
-	CREATE TABLE IF NOT EXISTS authors (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT,
-		age INTEGER,
-		address TEXT
-	)
-	`
-
-	// authorsTable := `
-	// CREATE TABLE IF NOT EXISTS bookmanagement.authors (
-	// 	id INT AUTO_INCREMENT PRIMARY KEY ,
-	// 	name VARCHAR(250),
-	// 	age INT,
-	// 	address VARCHAR(250)
-	// )
-	// `
-	_, err = DB.Exec(authorsTable)
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
 
-	if err != nil {
-		panic("could not create authors table")
+	if err = db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err = Migrate(ctx, db, cfg.Driver); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
 	}
+	return fallback
 }
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Each dialect keeps its own DDL under migrations/<dir>. Files are applied in
+// name order, so they are prefixed with a zero padded version number, and
+// each file holds a single statement because MySQL rejects multi statements
+// by default.
+//
+//go:embed migrations
+var migrations embed.FS
+
+var migrationDirs = map[string]string{
+	DriverSQLite: "migrations/sqlite",
+	DriverMySQL:  "migrations/mysql",
+}
+
+// Migrate applies every migration for the given driver that is not yet
+// recorded in the schema_migrations table. Each migration runs in one
+// transaction with its schema_migrations row, so a failed one is neither
+// half applied nor recorded. MySQL commits DDL implicitly, there only the
+// recording is skipped when the statement fails.
+func Migrate(ctx context.Context, db *sql.DB, driver string) error {
+	dir, ok := migrationDirs[driver]
+
+	if !ok {
+		return fmt.Errorf("no migrations for driver %q", driver)
+	}
+
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version VARCHAR(255) PRIMARY KEY
+	)`)
+
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, db)
+
+	if err != nil {
+		return err
+	}
+
+	entries, err := fs.ReadDir(migrations, dir)
+
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(name, ".sql")
+
+		if applied[version] {
+			continue
+		}
+
+		query, err := migrations.ReadFile(path.Join(dir, name))
+
+		if err != nil {
+			return err
+		}
+
+		if err = apply(ctx, db, version, string(query)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, version, query string) error {
+	tx, err := db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("migration %s: %w", version, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+		return fmt.Errorf("record migration %s: %w", version, err)
+	}
+
+	return tx.Commit()
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS books (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	isbn VARCHAR(20) NOT NULL,
+	release_date DATETIME,
+	author_id INT
+)
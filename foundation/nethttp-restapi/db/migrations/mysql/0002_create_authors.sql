@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS authors (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(250),
+	age INT,
+	address VARCHAR(250)
+)
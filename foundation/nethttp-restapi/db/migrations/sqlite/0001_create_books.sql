@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS books (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	isbn TEXT NOT NULL,
+	release_date DATETIME,
+	author_id INTEGER
+)
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS authors (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT,
+	age INTEGER,
+	address TEXT
+)
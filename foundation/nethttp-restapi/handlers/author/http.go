@@ -2,15 +2,27 @@ package author
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
 	"zopsmart.com/nethttp-test/models"
+	"zopsmart.com/nethttp-test/store"
 )
 
-func Handler(w http.ResponseWriter, r *http.Request) {
+type Handler struct {
+	store store.Author
+}
+
+func New(store store.Author) *Handler {
+	return &Handler{
+		store: store,
+	}
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	switch method := r.Method; method {
 	case "GET":
 		id := r.PathValue("id")
@@ -23,14 +35,19 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		data, err := models.GetAuthor(parsedID)
+		data, err := h.store.GetAuthor(r.Context(), parsedID)
 
-		if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			io.WriteString(w, "author not found")
 			return
 		}
 
+		if err != nil {
+			http.Error(w, "failed to fetch author", http.StatusInternalServerError)
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
 
@@ -59,7 +76,7 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func HandlerCreate(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	switch method := r.Method; method {
 	case "POST":
@@ -72,7 +89,7 @@ func HandlerCreate(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		err = a.Save()
+		err = h.store.CreateAuthor(r.Context(), &a)
 
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -2,15 +2,27 @@ package book
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
 	"zopsmart.com/nethttp-test/models"
+	"zopsmart.com/nethttp-test/store"
 )
 
-func Handler(w http.ResponseWriter, r *http.Request) {
+type Handler struct {
+	store store.Store
+}
+
+func New(store store.Store) *Handler {
+	return &Handler{
+		store: store,
+	}
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	switch method := r.Method; method {
 	case "GET":
 		a_id := r.PathValue("a_id")
@@ -32,14 +44,19 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		data, err := models.GetBook(parsedAuthorID, parsedBookID)
+		data, err := h.store.GetBook(r.Context(), parsedAuthorID, parsedBookID)
 
-		if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			io.WriteString(w, "book not found")
 			return
 		}
 
+		if err != nil {
+			http.Error(w, "failed to fetch book", http.StatusInternalServerError)
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusAccepted)
 
@@ -60,7 +77,7 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func HandlerCreate(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 
 	switch method := r.Method; method {
 	case "POST":
@@ -74,14 +91,19 @@ func HandlerCreate(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		data, err := models.GetAuthor(parsedAuthorID)
+		data, err := h.store.GetAuthor(r.Context(), parsedAuthorID)
 
-		if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			io.WriteString(w, "author not found")
 			return
 		}
 
+		if err != nil {
+			http.Error(w, "failed to fetch author", http.StatusInternalServerError)
+			return
+		}
+
 		var b models.Book
 
 		err = json.NewDecoder(r.Body).Decode(&b)
@@ -92,7 +114,7 @@ func HandlerCreate(w http.ResponseWriter, r *http.Request) {
 		}
 
 		b.AuthorID = data.ID
-		err = b.Save()
+		err = h.store.CreateBook(r.Context(), &b)
 
 		if err != nil {
 			w.WriteHeader(http.StatusBadRequest)
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 
 	"github.com/joho/godotenv"
@@ -10,6 +12,7 @@ import (
 	"zopsmart.com/nethttp-test/db"
 	"zopsmart.com/nethttp-test/handlers/author"
 	"zopsmart.com/nethttp-test/handlers/book"
+	"zopsmart.com/nethttp-test/store/sqlstore"
 )
 
 func main() {
@@ -18,17 +21,31 @@ func main() {
 	if err != nil {
 		fmt.Println("failed to laod env file")
 	}
-	db.InitDB()
+
+	cfg := db.LoadConfig()
+
+	conn, err := db.InitDB(context.Background(), cfg)
+
+	if err != nil {
+		log.Fatalf("could not connect to database: %v", err)
+	}
+
+	defer conn.Close()
+
+	s := sqlstore.New(conn)
+
+	authorHandler := author.New(s)
+	bookHandler := book.New(s)
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Println("server healthy")
 		io.WriteString(w, "server healthy")
 	})
 
-	http.HandleFunc("/author", author.HandlerCreate)
-	http.HandleFunc("/author/{id}", author.Handler)
-	http.HandleFunc("/author/{a_id}/book", book.HandlerCreate)
-	http.HandleFunc("/author/{a_id}/book/{b_id}", book.Handler)
+	http.HandleFunc("/author", authorHandler.Create)
+	http.HandleFunc("/author/{id}", authorHandler.Get)
+	http.HandleFunc("/author/{a_id}/book", bookHandler.Create)
+	http.HandleFunc("/author/{a_id}/book/{b_id}", bookHandler.Get)
 
 	fmt.Println("Running http server on port 8000")
 	err = http.ListenAndServe(":8000", nil)
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"zopsmart.com/nethttp-test/models"
+)
+
+// ErrNotFound is returned when the requested row does not exist, so handlers
+// can tell a missing record from a failed query.
+var ErrNotFound = errors.New("record not found")
+
+type Author interface {
+	CreateAuthor(ctx context.Context, author *models.Author) error
+	GetAuthor(ctx context.Context, id int64) (*models.Author, error)
+}
+
+type Book interface {
+	CreateBook(ctx context.Context, book *models.Book) error
+	GetBook(ctx context.Context, authorID, bookID int64) (*models.Book, error)
+}
+
+// Store is implemented once, by sqlstore on database/sql. The dialect is
+// picked when package db opens the connection, not by the Store.
+type Store interface {
+	Author
+	Book
+}
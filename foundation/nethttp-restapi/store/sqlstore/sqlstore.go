@@ -0,0 +1,100 @@
+// Package sqlstore implements store.Store on top of database/sql. The queries
+// stick to SQL that SQLite and MySQL both accept, so one store serves every
+// driver of package db, the dialects only differ in their migrations.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"zopsmart.com/nethttp-test/models"
+	"zopsmart.com/nethttp-test/store"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+// New returns a store for a database opened and migrated by db.InitDB.
+func New(db *sql.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+func (s *Store) CreateAuthor(ctx context.Context, a *models.Author) error {
+	query := `INSERT INTO authors (name, age, address) VALUES (?, ?, ?)`
+
+	result, err := s.db.ExecContext(ctx, query, a.Name, a.Age, a.Address)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	a.ID = id
+
+	return nil
+}
+
+func (s *Store) GetAuthor(ctx context.Context, id int64) (*models.Author, error) {
+	query := `SELECT id, name, age, address FROM authors WHERE id=?`
+
+	var author models.Author
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&author.ID, &author.Name, &author.Age, &author.Address)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &author, nil
+}
+
+func (s *Store) CreateBook(ctx context.Context, b *models.Book) error {
+	query := `INSERT INTO books (name, isbn, release_date, author_id) VALUES (?, ?, ?, ?)`
+
+	// DATETIME carries no zone in MySQL, store UTC so it reads back unchanged
+	// with parseTime.
+	result, err := s.db.ExecContext(ctx, query, b.Name, b.ISBN, b.ReleaseDate.UTC(), b.AuthorID)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	b.ID = id
+
+	return nil
+}
+
+func (s *Store) GetBook(ctx context.Context, authorID, bookID int64) (*models.Book, error) {
+	query := `SELECT id, name, isbn, release_date, author_id FROM books WHERE id=? AND author_id=?`
+
+	var book models.Book
+
+	err := s.db.QueryRowContext(ctx, query, bookID, authorID).Scan(&book.ID, &book.Name, &book.ISBN, &book.ReleaseDate, &book.AuthorID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, store.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &book, nil
+}
@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"zopsmart.com/nethttp-test/db"
+	"zopsmart.com/nethttp-test/store"
+	"zopsmart.com/nethttp-test/store/storetest"
+)
+
+func TestSQLite(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		conn, err := db.InitDB(context.Background(), db.Config{
+			Driver:     db.DriverSQLite,
+			SQLitePath: filepath.Join(t.TempDir(), "test.db"),
+		})
+
+		if err != nil {
+			t.Fatalf("init database: %v", err)
+		}
+
+		t.Cleanup(func() { conn.Close() })
+
+		return New(conn)
+	})
+}
+
+// TestMySQL runs the conformance suite against a real server. It only runs
+// when MYSQL_TEST_HOST is set, e.g. against the docker-compose database:
+//
+//	MYSQL_TEST_HOST=localhost:3306 MYSQL_TEST_PASSWORD=admin go test ./store/sqlstore
+func TestMySQL(t *testing.T) {
+	host := os.Getenv("MYSQL_TEST_HOST")
+
+	if host == "" {
+		t.Skip("MYSQL_TEST_HOST not set")
+	}
+
+	cfg := db.Config{
+		Driver:   db.DriverMySQL,
+		Host:     host,
+		User:     "root",
+		Password: os.Getenv("MYSQL_TEST_PASSWORD"),
+		Name:     "bookmanagement",
+	}
+
+	storetest.Run(t, func(t *testing.T) store.Store {
+		conn, err := db.InitDB(context.Background(), cfg)
+
+		if err != nil {
+			t.Fatalf("init database: %v", err)
+		}
+
+		t.Cleanup(func() { conn.Close() })
+
+		for _, table := range []string{"books", "authors"} {
+			if _, err := conn.Exec("DELETE FROM " + table); err != nil {
+				t.Fatalf("truncate %s: %v", table, err)
+			}
+		}
+
+		return New(conn)
+	})
+}
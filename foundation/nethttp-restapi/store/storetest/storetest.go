@@ -0,0 +1,113 @@
+// Package storetest holds the conformance suite every store.Store
+// implementation has to pass. Backends call Run from their own tests with a
+// factory that hands out an empty, migrated store.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"zopsmart.com/nethttp-test/models"
+	"zopsmart.com/nethttp-test/store"
+)
+
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	t.Run("create and get author", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		author := &models.Author{Name: "Abhi", Age: 26, Address: "Nagarbhavi"}
+
+		if err := s.CreateAuthor(ctx, author); err != nil {
+			t.Fatalf("create author: %v", err)
+		}
+
+		if author.ID == 0 {
+			t.Fatal("expected author id to be set")
+		}
+
+		got, err := s.GetAuthor(ctx, author.ID)
+
+		if err != nil {
+			t.Fatalf("get author: %v", err)
+		}
+
+		if *got != *author {
+			t.Errorf("expected %+v, got %+v", *author, *got)
+		}
+	})
+
+	t.Run("missing author", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.GetAuthor(context.Background(), 404)
+
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("create and get book", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		author := &models.Author{Name: "Abhi"}
+
+		if err := s.CreateAuthor(ctx, author); err != nil {
+			t.Fatalf("create author: %v", err)
+		}
+
+		book := &models.Book{
+			Name:        "ThinkSchool",
+			ISBN:        "BSHNJK90890",
+			AuthorID:    author.ID,
+			ReleaseDate: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		}
+
+		if err := s.CreateBook(ctx, book); err != nil {
+			t.Fatalf("create book: %v", err)
+		}
+
+		got, err := s.GetBook(ctx, author.ID, book.ID)
+
+		if err != nil {
+			t.Fatalf("get book: %v", err)
+		}
+
+		if got.ID != book.ID || got.Name != book.Name || got.ISBN != book.ISBN || got.AuthorID != book.AuthorID {
+			t.Errorf("expected %+v, got %+v", *book, *got)
+		}
+
+		if !got.ReleaseDate.Equal(book.ReleaseDate) {
+			t.Errorf("expected release date %v, got %v", book.ReleaseDate, got.ReleaseDate)
+		}
+	})
+
+	t.Run("book of another author", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		first := &models.Author{Name: "first"}
+		second := &models.Author{Name: "second"}
+
+		for _, a := range []*models.Author{first, second} {
+			if err := s.CreateAuthor(ctx, a); err != nil {
+				t.Fatalf("create author: %v", err)
+			}
+		}
+
+		book := &models.Book{Name: "owned", ISBN: "1", AuthorID: first.ID, ReleaseDate: time.Now().UTC()}
+
+		if err := s.CreateBook(ctx, book); err != nil {
+			t.Fatalf("create book: %v", err)
+		}
+
+		_, err := s.GetBook(ctx, second.ID, book.ID)
+
+		if !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
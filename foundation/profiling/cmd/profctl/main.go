@@ -0,0 +1,183 @@
+// Command profctl lists the profiles kept by the profiler and compares two
+// of them by function.
+//
+//	profctl list -dir profiles -kind heap
+//	profctl diff -dir profiles -kind heap             # the two latest heap profiles
+//	profctl diff -normalize base.pb.gz new.pb.gz      # any two pprof files
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"profiling/profiler"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "list":
+		err = list(os.Args[2:])
+	case "diff":
+		err = diff(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "profctl:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: profctl list|diff [flags]")
+	os.Exit(2)
+}
+
+func list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	dir := fs.String("dir", "profiles", "profile directory")
+	kind := fs.String("kind", "", "only this kind (cpu, heap, mutex, block, goroutine)")
+	fs.Parse(args)
+
+	store := &profiler.Store{Dir: *dir}
+	entries, err := store.List(*kind)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tKIND\tREASON\tFILE")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Time.Local().Format(time.DateTime), e.Kind, e.Reason, e.Path)
+	}
+	return w.Flush()
+}
+
+func diff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	dir := fs.String("dir", "profiles", "profile directory, when no files are given")
+	kind := fs.String("kind", profiler.KindCPU, "kind of the two latest profiles to compare, when no files are given")
+	sample := fs.String("sample", "", "sample type, e.g. cpu, alloc_space, inuse_objects (default of the profile)")
+	top := fs.Int("top", 20, "number of functions to show, 0 for all")
+	byCum := fs.Bool("cum", false, "sort by cumulative change")
+	normalize := fs.Bool("normalize", false, "scale base to the total of new, compare shares")
+	fs.Parse(args)
+
+	var basePath, newPath string
+	switch fs.NArg() {
+	case 2:
+		basePath, newPath = fs.Arg(0), fs.Arg(1)
+	case 0:
+		entries, err := (&profiler.Store{Dir: *dir}).List(*kind)
+		if err != nil {
+			return err
+		}
+		if len(entries) < 2 {
+			return fmt.Errorf("need two %s profiles in %s, found %d", *kind, *dir, len(entries))
+		}
+		basePath, newPath = entries[len(entries)-2].Path, entries[len(entries)-1].Path
+	default:
+		return errors.New("diff takes no or two profile files")
+	}
+
+	base, err := profiler.Load(basePath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", basePath, err)
+	}
+	cur, err := profiler.Load(newPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", newPath, err)
+	}
+
+	d, err := profiler.Compare(base, cur, *sample, *normalize)
+	if err != nil {
+		return err
+	}
+	if *byCum {
+		d.SortByCum()
+	}
+
+	fmt.Printf("base %s\nnew  %s\n\n", basePath, newPath)
+	return printDiff(os.Stdout, d, *top)
+}
+
+func printDiff(out io.Writer, d *profiler.Diff, top int) error {
+	fmt.Fprintf(out, "%s: %s -> %s (%s)\n\n", d.SampleType,
+		format(d.BaseTotal, d.Unit), format(d.NewTotal, d.Unit), percent(d.NewTotal-d.BaseTotal, d.BaseTotal))
+
+	rows := d.Rows
+	if top > 0 && len(rows) > top {
+		rows = rows[:top]
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "FLAT BASE\tFLAT NEW\tFLAT DELTA\t\tCUM DELTA\t\t FUNCTION")
+	for _, r := range rows {
+		if r.FlatDelta() == 0 && r.CumDelta() == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t %s\n",
+			format(r.BaseFlat, d.Unit), format(r.NewFlat, d.Unit),
+			signed(r.FlatDelta(), d.Unit), percent(r.FlatDelta(), r.BaseFlat),
+			signed(r.CumDelta(), d.Unit), percent(r.CumDelta(), r.BaseCum),
+			r.Func)
+	}
+	return w.Flush()
+}
+
+func format(v int64, unit string) string {
+	switch unit {
+	case "nanoseconds":
+		return time.Duration(v).Round(time.Microsecond).String()
+	case "bytes":
+		return bytes(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func signed(v int64, unit string) string {
+	if v > 0 {
+		return "+" + format(v, unit)
+	}
+	return format(v, unit)
+}
+
+func bytes(v int64) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+
+	const unit = 1024
+	if v < unit {
+		return fmt.Sprintf("%s%dB", sign, v)
+	}
+	div, exp := int64(unit), 0
+	for n := v / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%s%.1f%cB", sign, float64(v)/float64(div), "KMGTPE"[exp])
+}
+
+func percent(delta, base int64) string {
+	if base == 0 {
+		if delta == 0 {
+			return "0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", float64(delta)/float64(base)*100)
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
-
 	"os"
+	"os/signal"
+	"runtime"
 	"runtime/pprof"
+	"sync"
+	"time"
 
 	_ "net/http/pprof"
+
+	"profiling/profiler"
 )
 
 func fib(n int) int {
@@ -17,28 +23,90 @@ func fib(n int) int {
 	return fib(n-1) + fib(n-2)
 }
 
+// profileFib writes a CPU profile covering exactly fib(35).
+func profileFib(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create CPU profile: %w", err)
+	}
+	defer f.Close()
+
+	if err := pprof.StartCPUProfile(f); err != nil {
+		return err
+	}
+
+	fmt.Println("Calculating fib(35)...")
+	result := fib(35)
+	fmt.Println("fib(35) =", result)
+
+	pprof.StopCPUProfile()
+	return nil
+}
+
+// burn keeps every CPU busy for d, enough to cross the CPU threshold.
+func burn(ctx context.Context, d time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				fib(30)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	// Start pprof HTTP server for live profiling
 	go func() {
 		fmt.Println("pprof server listening on :6060")
 		http.ListenAndServe(":6060", nil)
 	}()
 
-	f, err := os.Create("cpu.prof")
+	if err := profileFib("cpu.prof"); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Continuous profiling: every minute, and when the bursts below push
+	// CPU usage over 80%.
+	p, err := profiler.New(profiler.Config{
+		Dir:           "profiles",
+		Keep:          5,
+		Interval:      time.Minute,
+		CPUDuration:   5 * time.Second,
+		CPUThreshold:  0.8,
+		HeapThreshold: 512 << 20,
+		CheckInterval: time.Second,
+		Cooldown:      30 * time.Second,
+		MutexFraction: 5,
+		BlockRate:     int(time.Millisecond),
+	})
 	if err != nil {
-		fmt.Println("could not create CPU profile:", err)
+		fmt.Println(err)
 		return
 	}
-	defer f.Close()
-	pprof.StartCPUProfile(f)
-	defer pprof.StopCPUProfile()
 
-	// Run a CPU-intensive task
-	fmt.Println("Calculating fib(35)...")
-	result := fib(35)
-	fmt.Println("fib(35) =", result)
+	go func() {
+		for ctx.Err() == nil {
+			burn(ctx, 10*time.Second)
+			select {
+			case <-time.After(20 * time.Second):
+			case <-ctx.Done():
+			}
+		}
+	}()
 
-	// Prevent main from exiting so the pprof server stays up
+	fmt.Println("Profiles are written to ./profiles, compare the last two with:")
+	fmt.Println("  go run ./cmd/profctl diff -kind cpu")
 	fmt.Println("Press Ctrl+C to exit and stop the pprof server.")
-	// select {} // Block forever
+	p.Run(ctx)
 }
@@ -0,0 +1,197 @@
+package profiler
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/google/pprof/profile"
+)
+
+// Row is one function in a Diff. Flat counts samples where the function
+// itself was running, Cum those where it was anywhere on the stack.
+type Row struct {
+	Func     string
+	BaseFlat int64
+	NewFlat  int64
+	BaseCum  int64
+	NewCum   int64
+}
+
+func (r Row) FlatDelta() int64 { return r.NewFlat - r.BaseFlat }
+func (r Row) CumDelta() int64  { return r.NewCum - r.BaseCum }
+
+// Diff compares two profiles by function.
+type Diff struct {
+	SampleType string
+	Unit       string
+	BaseTotal  int64
+	NewTotal   int64
+	// Rows are sorted by the size of the flat change, largest first.
+	Rows []Row
+}
+
+// Load reads a profile written by the profiler, go test -cpuprofile or
+// /debug/pprof.
+func Load(path string) (*profile.Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return profile.Parse(f)
+}
+
+// Compare diffs base and cur on the sample type named sampleType, such as
+// "cpu" or "inuse_space", or on the profile's default type when empty.
+// With normalize, base values are scaled to the total of cur first, which
+// compares the share of each function instead of absolute values, as
+// needed for CPU profiles of different length.
+func Compare(base, cur *profile.Profile, sampleType string, normalize bool) (*Diff, error) {
+	if sampleType == "" {
+		sampleType = defaultSampleType(cur)
+	}
+
+	bi, err := sampleIndex(base, sampleType)
+	if err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+	ci, err := sampleIndex(cur, sampleType)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diff{SampleType: sampleType, Unit: cur.SampleType[ci].Unit}
+	rows := map[string]*Row{}
+	row := func(name string) *Row {
+		r, ok := rows[name]
+		if !ok {
+			r = &Row{Func: name}
+			rows[name] = r
+		}
+		return r
+	}
+
+	baseFlat, baseCum, baseTotal := byFunction(base, bi)
+	newFlat, newCum, newTotal := byFunction(cur, ci)
+	d.BaseTotal, d.NewTotal = baseTotal, newTotal
+
+	scale := 1.0
+	if normalize && baseTotal != 0 {
+		scale = float64(newTotal) / float64(baseTotal)
+		d.BaseTotal = newTotal
+	}
+	scaled := func(v int64) int64 { return int64(float64(v) * scale) }
+
+	for name, v := range baseFlat {
+		row(name).BaseFlat = scaled(v)
+	}
+	for name, v := range baseCum {
+		row(name).BaseCum = scaled(v)
+	}
+	for name, v := range newFlat {
+		row(name).NewFlat = v
+	}
+	for name, v := range newCum {
+		row(name).NewCum = v
+	}
+
+	for _, r := range rows {
+		d.Rows = append(d.Rows, *r)
+	}
+	slices.SortFunc(d.Rows, func(a, b Row) int {
+		if c := cmp.Compare(abs(b.FlatDelta()), abs(a.FlatDelta())); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(abs(b.CumDelta()), abs(a.CumDelta())); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Func, b.Func)
+	})
+	return d, nil
+}
+
+// SortByCum orders the rows by the size of the cumulative change.
+func (d *Diff) SortByCum() {
+	slices.SortStableFunc(d.Rows, func(a, b Row) int {
+		return cmp.Compare(abs(b.CumDelta()), abs(a.CumDelta()))
+	})
+}
+
+func defaultSampleType(p *profile.Profile) string {
+	if p.DefaultSampleType != "" {
+		return p.DefaultSampleType
+	}
+	if len(p.SampleType) == 0 {
+		return ""
+	}
+	return p.SampleType[len(p.SampleType)-1].Type
+}
+
+func sampleIndex(p *profile.Profile, sampleType string) (int, error) {
+	for i, st := range p.SampleType {
+		if st.Type == sampleType {
+			return i, nil
+		}
+	}
+
+	var types []string
+	for _, st := range p.SampleType {
+		types = append(types, st.Type)
+	}
+	return 0, fmt.Errorf("no sample type %q, profile has %v", sampleType, types)
+}
+
+// byFunction sums sample values per function. The leaf function of a
+// stack gets the flat value; every function on it gets the cumulative
+// value once, even when recursive.
+func byFunction(p *profile.Profile, index int) (flat, cum map[string]int64, total int64) {
+	flat, cum = map[string]int64{}, map[string]int64{}
+
+	for _, s := range p.Sample {
+		v := s.Value[index]
+		total += v
+
+		seen := map[string]bool{}
+		first := true
+		for _, loc := range s.Location {
+			for _, name := range functions(loc) {
+				if first {
+					flat[name] += v
+					first = false
+				}
+				if !seen[name] {
+					seen[name] = true
+					cum[name] += v
+				}
+			}
+		}
+	}
+	return flat, cum, total
+}
+
+// functions returns the function names at loc. Line[0] is the innermost
+// function inlined there, the last one the function it was inlined into.
+// Locations without symbols are named by address.
+func functions(loc *profile.Location) []string {
+	if len(loc.Line) == 0 {
+		return []string{fmt.Sprintf("0x%x", loc.Address)}
+	}
+
+	names := make([]string, len(loc.Line))
+	for i, line := range loc.Line {
+		names[i] = "?"
+		if line.Function != nil {
+			names[i] = line.Function.Name
+		}
+	}
+	return names
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
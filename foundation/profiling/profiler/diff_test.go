@@ -0,0 +1,140 @@
+package profiler
+
+import (
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+// build returns a CPU profile with one sample per stack, stacks listed
+// leaf first.
+func build(stacks map[string][]string, values map[string]int64) *profile.Profile {
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+	}
+
+	funcs := map[string]*profile.Function{}
+	fn := func(name string) *profile.Function {
+		if f, ok := funcs[name]; ok {
+			return f
+		}
+		f := &profile.Function{ID: uint64(len(funcs) + 1), Name: name}
+		funcs[name] = f
+		p.Function = append(p.Function, f)
+		return f
+	}
+
+	for key, stack := range stacks {
+		var locs []*profile.Location
+		for _, name := range stack {
+			loc := &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: fn(name)}}}
+			p.Location = append(p.Location, loc)
+			locs = append(locs, loc)
+		}
+		p.Sample = append(p.Sample, &profile.Sample{Location: locs, Value: []int64{1, values[key]}})
+	}
+	return p
+}
+
+func rowOf(t *testing.T, d *Diff, name string) Row {
+	t.Helper()
+	for _, r := range d.Rows {
+		if r.Func == name {
+			return r
+		}
+	}
+	t.Fatalf("no row for %s", name)
+	return Row{}
+}
+
+func TestCompare(t *testing.T) {
+	stacks := map[string][]string{
+		"fib":   {"main.fib", "main.fib", "main.main"},
+		"parse": {"json.Unmarshal", "main.handle", "main.main"},
+	}
+
+	base := build(stacks, map[string]int64{"fib": 100, "parse": 50})
+	cur := build(stacks, map[string]int64{"fib": 300, "parse": 40})
+
+	d, err := Compare(base, cur, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.SampleType != "cpu" || d.Unit != "nanoseconds" {
+		t.Errorf("sample type = %s %s, want the last one", d.SampleType, d.Unit)
+	}
+	if d.BaseTotal != 150 || d.NewTotal != 340 {
+		t.Errorf("totals = %d, %d", d.BaseTotal, d.NewTotal)
+	}
+	if d.Rows[0].Func != "main.fib" {
+		t.Errorf("first row = %s, want the largest change", d.Rows[0].Func)
+	}
+
+	// Recursion counts once in cum.
+	if r := rowOf(t, d, "main.fib"); r.FlatDelta() != 200 || r.BaseCum != 100 || r.NewCum != 300 {
+		t.Errorf("main.fib = %+v", r)
+	}
+	if r := rowOf(t, d, "main.main"); r.FlatDelta() != 0 || r.CumDelta() != 190 {
+		t.Errorf("main.main = %+v", r)
+	}
+	if r := rowOf(t, d, "json.Unmarshal"); r.FlatDelta() != -10 {
+		t.Errorf("json.Unmarshal = %+v", r)
+	}
+}
+
+func TestCompareNormalize(t *testing.T) {
+	stacks := map[string][]string{"a": {"a"}, "b": {"b"}}
+
+	// The new profile ran twice as long with the same mix.
+	base := build(stacks, map[string]int64{"a": 10, "b": 30})
+	cur := build(stacks, map[string]int64{"a": 20, "b": 60})
+
+	d, err := Compare(base, cur, "cpu", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range d.Rows {
+		if r.FlatDelta() != 0 {
+			t.Errorf("%s changed by %d after normalizing", r.Func, r.FlatDelta())
+		}
+	}
+}
+
+func TestCompareInlined(t *testing.T) {
+	p := &profile.Profile{SampleType: []*profile.ValueType{{Type: "cpu", Unit: "nanoseconds"}}}
+	inner := &profile.Function{ID: 1, Name: "strings.Index"}
+	outer := &profile.Function{ID: 2, Name: "main.find"}
+	loc := &profile.Location{ID: 1, Line: []profile.Line{{Function: inner}, {Function: outer}}}
+	p.Function = []*profile.Function{inner, outer}
+	p.Location = []*profile.Location{loc}
+	p.Sample = []*profile.Sample{{Location: []*profile.Location{loc}, Value: []int64{7}}}
+
+	empty := &profile.Profile{SampleType: p.SampleType}
+	d, err := Compare(empty, p, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := rowOf(t, d, "strings.Index"); r.NewFlat != 7 || r.NewCum != 7 {
+		t.Errorf("inlined leaf = %+v", r)
+	}
+	if r := rowOf(t, d, "main.find"); r.NewFlat != 0 || r.NewCum != 7 {
+		t.Errorf("caller = %+v", r)
+	}
+}
+
+func TestCompareSampleType(t *testing.T) {
+	p := build(map[string][]string{"a": {"a"}}, map[string]int64{"a": 1})
+
+	if _, err := Compare(p, p, "inuse_space", false); err == nil {
+		t.Error("Compare accepted a sample type the profiles don't have")
+	}
+	d, err := Compare(p, p, "samples", false)
+	if err != nil || d.Unit != "count" {
+		t.Errorf("Compare(samples) = %+v, %v", d, err)
+	}
+}
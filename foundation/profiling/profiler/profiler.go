@@ -0,0 +1,265 @@
+// Package profiler captures CPU, heap, mutex, block and goroutine profiles
+// of the running process into a rotating Store: on an interval, when CPU
+// or heap usage crosses a threshold, or on demand.
+//
+//	p, err := profiler.New(profiler.Config{Dir: "profiles", CPUThreshold: 0.8})
+//	...
+//	go p.Run(ctx)
+//
+// The files are regular pprof profiles, open them with go tool pprof or
+// compare two of them with cmd/profctl.
+package profiler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+const (
+	KindCPU       = "cpu"
+	KindHeap      = "heap"
+	KindMutex     = "mutex"
+	KindBlock     = "block"
+	KindGoroutine = "goroutine"
+)
+
+// Kinds are the profiles captured when Config.Kinds is empty.
+var Kinds = []string{KindCPU, KindHeap, KindMutex, KindBlock, KindGoroutine}
+
+// ErrCPUProfileBusy is returned when another CPU profile, such as one
+// requested from /debug/pprof/profile, is already running.
+var ErrCPUProfileBusy = errors.New("cpu profile already running")
+
+type Config struct {
+	// Dir holds the profiles, Keep of each kind (default 10).
+	Dir  string
+	Keep int
+	// Kinds to capture, all of Kinds when empty.
+	Kinds []string
+
+	// Interval between periodic captures, none when 0.
+	Interval time.Duration
+	// CPUDuration is how long the CPU profile records, 10s by default.
+	CPUDuration time.Duration
+
+	// CPUThreshold triggers a capture when the process uses more than
+	// this share of GOMAXPROCS (0.8 is 80%) over a CheckInterval.
+	// HeapThreshold triggers one when live heap objects exceed it, in
+	// bytes. 0 disables either.
+	CPUThreshold  float64
+	HeapThreshold uint64
+	// CheckInterval is how often usage is sampled, 5s by default.
+	// Cooldown is the minimum time between two triggered captures, 5m by
+	// default, so a service that stays hot is not profiled in a loop.
+	CheckInterval time.Duration
+	Cooldown      time.Duration
+
+	// MutexFraction and BlockRate are passed to
+	// runtime.SetMutexProfileFraction and runtime.SetBlockProfileRate
+	// when mutex or block profiles are captured; 0 keeps the current
+	// setting. Without them these profiles stay empty.
+	MutexFraction int
+	BlockRate     int
+
+	// Logf reports captures and errors, log.Printf when nil.
+	Logf func(format string, args ...any)
+}
+
+type Profiler struct {
+	cfg   Config
+	store *Store
+
+	// capture serializes captures, there can be one CPU profile at a
+	// time.
+	capture sync.Mutex
+
+	// usage samples the process, replaced in tests.
+	usage func() (cpu time.Duration, heap uint64, ok bool)
+}
+
+func New(cfg Config) (*Profiler, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("profiler: no directory")
+	}
+	if cfg.Keep <= 0 {
+		cfg.Keep = 10
+	}
+	if len(cfg.Kinds) == 0 {
+		cfg.Kinds = Kinds
+	}
+	for _, kind := range cfg.Kinds {
+		if kind != KindCPU && pprof.Lookup(kind) == nil {
+			return nil, fmt.Errorf("profiler: unknown profile %q", kind)
+		}
+	}
+	if cfg.CPUDuration <= 0 {
+		cfg.CPUDuration = 10 * time.Second
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = 5 * time.Second
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 5 * time.Minute
+	}
+	if cfg.Logf == nil {
+		cfg.Logf = log.Printf
+	}
+
+	store, err := NewStore(cfg.Dir, cfg.Keep)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.MutexFraction > 0 {
+		runtime.SetMutexProfileFraction(cfg.MutexFraction)
+	}
+	if cfg.BlockRate > 0 {
+		runtime.SetBlockProfileRate(cfg.BlockRate)
+	}
+
+	return &Profiler{cfg: cfg, store: store, usage: sampleUsage}, nil
+}
+
+func (p *Profiler) Store() *Store {
+	return p.store
+}
+
+// Run captures profiles on the interval and when a threshold is crossed,
+// until ctx is done.
+func (p *Profiler) Run(ctx context.Context) error {
+	var periodic <-chan time.Time
+	if p.cfg.Interval > 0 {
+		t := time.NewTicker(p.cfg.Interval)
+		defer t.Stop()
+		periodic = t.C
+	}
+
+	var check <-chan time.Time
+	if p.cfg.CPUThreshold > 0 || p.cfg.HeapThreshold > 0 {
+		t := time.NewTicker(p.cfg.CheckInterval)
+		defer t.Stop()
+		check = t.C
+	}
+
+	var (
+		lastCPU       time.Duration
+		lastCheck     time.Time
+		lastTriggered time.Time
+	)
+	if cpu, _, ok := p.usage(); ok {
+		lastCPU, lastCheck = cpu, time.Now()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-periodic:
+			p.Capture(ctx, "periodic")
+
+		case now := <-check:
+			cpu, heap, ok := p.usage()
+			if !ok {
+				continue
+			}
+
+			var reason string
+			if wall := now.Sub(lastCheck); p.cfg.CPUThreshold > 0 && !lastCheck.IsZero() && wall > 0 {
+				share := float64(cpu-lastCPU) / float64(wall) / float64(runtime.GOMAXPROCS(0))
+				if share > p.cfg.CPUThreshold {
+					reason = "cpu"
+				}
+			}
+			if reason == "" && p.cfg.HeapThreshold > 0 && heap > p.cfg.HeapThreshold {
+				reason = "heap"
+			}
+			lastCPU, lastCheck = cpu, now
+
+			if reason == "" || now.Sub(lastTriggered) < p.cfg.Cooldown {
+				continue
+			}
+			lastTriggered = now
+
+			p.cfg.Logf("profiler: %s threshold crossed, capturing", reason)
+			p.Capture(ctx, reason+"_threshold")
+
+			// The capture itself took time and CPU, start over.
+			if cpu, _, ok := p.usage(); ok {
+				lastCPU, lastCheck = cpu, time.Now()
+			}
+		}
+	}
+}
+
+// Capture writes one profile of each configured kind, tagged with reason.
+// Failures are logged and skipped, the entries saved are returned.
+func (p *Profiler) Capture(ctx context.Context, reason string) ([]Entry, error) {
+	p.capture.Lock()
+	defer p.capture.Unlock()
+
+	var (
+		entries []Entry
+		errs    []error
+	)
+	for _, kind := range p.cfg.Kinds {
+		e, err := p.store.Save(kind, reason, time.Now(), func(w io.Writer) error {
+			return p.write(ctx, kind, w)
+		})
+		if err != nil {
+			p.cfg.Logf("profiler: %s profile: %v", kind, err)
+			errs = append(errs, fmt.Errorf("%s: %w", kind, err))
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) > 0 {
+		p.cfg.Logf("profiler: saved %d profiles (%s)", len(entries), reason)
+	}
+	return entries, errors.Join(errs...)
+}
+
+func (p *Profiler) write(ctx context.Context, kind string, w io.Writer) error {
+	if kind != KindCPU {
+		return pprof.Lookup(kind).WriteTo(w, 0)
+	}
+
+	if err := pprof.StartCPUProfile(w); err != nil {
+		return ErrCPUProfileBusy
+	}
+
+	t := time.NewTimer(p.cfg.CPUDuration)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+
+	pprof.StopCPUProfile()
+	return nil
+}
+
+var heapMetric = []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+
+func sampleUsage() (time.Duration, uint64, bool) {
+	cpu, ok := cpuTime()
+
+	samples := make([]metrics.Sample, len(heapMetric))
+	copy(samples, heapMetric)
+	metrics.Read(samples)
+
+	var heap uint64
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		heap = samples[0].Value.Uint64()
+	}
+	return cpu, heap, ok || heap > 0
+}
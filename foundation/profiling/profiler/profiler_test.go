@@ -0,0 +1,195 @@
+package profiler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func quiet(string, ...any) {}
+
+func TestStoreRotates(t *testing.T) {
+	store, err := NewStore(t.TempDir(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		for _, kind := range []string{KindHeap, KindGoroutine} {
+			_, err := store.Save(kind, "periodic", start.Add(time.Duration(i)*time.Minute), func(w io.Writer) error {
+				_, err := io.WriteString(w, kind)
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	heap, err := store.List(KindHeap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(heap) != 3 {
+		t.Fatalf("kept %d heap profiles, want 3", len(heap))
+	}
+	if !heap[0].Time.Equal(start.Add(2*time.Minute)) || !heap[2].Time.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("kept %v .. %v, want the last three", heap[0].Time, heap[2].Time)
+	}
+	if heap[0].Reason != "periodic" || heap[0].Kind != KindHeap {
+		t.Errorf("entry = %+v", heap[0])
+	}
+
+	all, _ := store.List("")
+	if len(all) != 6 {
+		t.Errorf("kept %d profiles in total, want 6", len(all))
+	}
+}
+
+func TestStoreFailedWrite(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewStore(dir, 3)
+
+	errWrite := errors.New("disk full")
+	_, err := store.Save(KindHeap, "periodic", time.Now(), func(w io.Writer) error { return errWrite })
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("Save() = %v, want %v", err, errWrite)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 0 {
+		t.Errorf("failed save left %d files", len(files))
+	}
+}
+
+func TestCapture(t *testing.T) {
+	p, err := New(Config{Dir: t.TempDir(), CPUDuration: 50 * time.Millisecond, Logf: quiet})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := p.Capture(context.Background(), "manual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(Kinds) {
+		t.Fatalf("captured %d profiles, want %d", len(entries), len(Kinds))
+	}
+
+	for _, e := range entries {
+		prof, err := Load(e.Path)
+		if err != nil {
+			t.Errorf("%s: %v", e.Kind, err)
+			continue
+		}
+		if len(prof.SampleType) == 0 {
+			t.Errorf("%s: no sample types", e.Kind)
+		}
+	}
+}
+
+func TestCaptureCPUBusy(t *testing.T) {
+	if err := pprof.StartCPUProfile(io.Discard); err != nil {
+		t.Skip("CPU profile already running")
+	}
+	defer pprof.StopCPUProfile()
+
+	p, _ := New(Config{Dir: t.TempDir(), Kinds: []string{KindCPU, KindHeap}, Logf: quiet})
+	entries, err := p.Capture(context.Background(), "manual")
+
+	if !errors.Is(err, ErrCPUProfileBusy) {
+		t.Errorf("Capture() = %v, want %v", err, ErrCPUProfileBusy)
+	}
+	if len(entries) != 1 || entries[0].Kind != KindHeap {
+		t.Errorf("entries = %+v, want the heap profile", entries)
+	}
+}
+
+func TestUnknownKind(t *testing.T) {
+	if _, err := New(Config{Dir: t.TempDir(), Kinds: []string{"threads"}}); err == nil {
+		t.Error("New accepted an unknown profile")
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		cpu    time.Duration // CPU time used per check
+		heap   uint64
+		reason string
+	}{
+		{"cpu", Config{CPUThreshold: 0.5}, time.Hour, 0, "cpu_threshold"},
+		{"heap", Config{HeapThreshold: 1 << 20}, 0, 2 << 20, "heap_threshold"},
+		{"below", Config{CPUThreshold: 0.5, HeapThreshold: 1 << 20}, 0, 1 << 10, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Dir = t.TempDir()
+			cfg.Kinds = []string{KindGoroutine}
+			cfg.CheckInterval = 5 * time.Millisecond
+			cfg.Cooldown = time.Hour
+			cfg.Logf = quiet
+
+			p, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var cpu atomic.Int64
+			p.usage = func() (time.Duration, uint64, bool) {
+				return time.Duration(cpu.Add(int64(tt.cpu))), tt.heap, true
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			p.Run(ctx)
+
+			entries, _ := p.Store().List("")
+			if tt.reason == "" {
+				if len(entries) != 0 {
+					t.Errorf("captured %d profiles below the thresholds", len(entries))
+				}
+				return
+			}
+
+			// The cooldown allows a single capture.
+			if len(entries) != 1 || entries[0].Reason != tt.reason {
+				t.Errorf("entries = %+v, want one %s capture", entries, tt.reason)
+			}
+		})
+	}
+}
+
+func TestPeriodic(t *testing.T) {
+	p, _ := New(Config{
+		Dir:      t.TempDir(),
+		Kinds:    []string{KindHeap},
+		Keep:     2,
+		Interval: 10 * time.Millisecond,
+		Logf:     quiet,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := p.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v", err)
+	}
+
+	entries, _ := p.Store().List(KindHeap)
+	if len(entries) != 2 {
+		t.Fatalf("kept %d profiles, want 2", len(entries))
+	}
+	if !strings.HasSuffix(entries[0].Path, "-periodic.pb.gz") {
+		t.Errorf("path = %s", entries[0].Path)
+	}
+}
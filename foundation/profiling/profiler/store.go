@@ -0,0 +1,119 @@
+package profiler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry is a profile kept in a Store. Files are named
+// <kind>-<time>-<reason>.pb.gz, so the directory can be listed and
+// browsed without the Store.
+type Entry struct {
+	Path   string
+	Kind   string
+	Reason string
+	Time   time.Time
+}
+
+const timeFormat = "20060102T150405.000Z"
+
+// Store keeps the last Keep profiles of each kind in a directory.
+type Store struct {
+	Dir  string
+	Keep int
+}
+
+func NewStore(dir string, keep int) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir, Keep: keep}, nil
+}
+
+// Save writes a profile through write and deletes the oldest ones of the
+// same kind beyond Keep. The file only appears once it is complete.
+func (s *Store) Save(kind, reason string, t time.Time, write func(io.Writer) error) (Entry, error) {
+	e := Entry{Kind: kind, Reason: reason, Time: t.UTC()}
+	e.Path = filepath.Join(s.Dir, fmt.Sprintf("%s-%s-%s.pb.gz", kind, e.Time.Format(timeFormat), reason))
+
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-"+kind+"-*")
+	if err != nil {
+		return Entry{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return Entry{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Entry{}, err
+	}
+	if err := os.Rename(tmp.Name(), e.Path); err != nil {
+		return Entry{}, err
+	}
+
+	return e, s.rotate(kind)
+}
+
+func (s *Store) rotate(kind string) error {
+	if s.Keep <= 0 {
+		return nil
+	}
+
+	entries, err := s.List(kind)
+	if err != nil {
+		return err
+	}
+	for len(entries) > s.Keep {
+		if err := os.Remove(entries[0].Path); err != nil {
+			return err
+		}
+		entries = entries[1:]
+	}
+	return nil
+}
+
+// List returns the stored profiles of kind, or of every kind when kind is
+// empty, oldest first.
+func (s *Store) List(kind string) ([]Entry, error) {
+	files, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, f := range files {
+		e, ok := parseName(f.Name())
+		if !ok || (kind != "" && e.Kind != kind) {
+			continue
+		}
+		e.Path = filepath.Join(s.Dir, f.Name())
+		entries = append(entries, e)
+	}
+
+	slices.SortStableFunc(entries, func(a, b Entry) int { return a.Time.Compare(b.Time) })
+	return entries, nil
+}
+
+func parseName(name string) (Entry, bool) {
+	base, ok := strings.CutSuffix(name, ".pb.gz")
+	if !ok {
+		return Entry{}, false
+	}
+
+	parts := strings.SplitN(base, "-", 3)
+	if len(parts) != 3 {
+		return Entry{}, false
+	}
+	t, err := time.Parse(timeFormat, parts[1])
+	if err != nil {
+		return Entry{}, false
+	}
+	return Entry{Kind: parts[0], Time: t, Reason: parts[2]}, true
+}
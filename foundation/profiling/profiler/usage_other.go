@@ -0,0 +1,10 @@
+//go:build !unix
+
+package profiler
+
+import "time"
+
+// cpuTime is not available here, the CPU threshold never triggers.
+func cpuTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -2,57 +2,347 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"learn/project1/note"
+	"learn/project1/store"
 	"os"
 	"strings"
 )
 
+const usage = `usage: notes [-dir notes] <command> [args]
+
+commands:
+  add     [-title t] [-content c] [-tags a,b]   add a note (prompts for missing fields)
+  list    [-q text] [-title t] [-tag a,b] [-from date] [-to date]
+  search  same as list
+  show    [-history] <id>
+  edit    [-title t] [-content c] [-tags a,b] <id>
+  delete  <id>
+  export  [-o file] [filters of list]           write the notes as Markdown
+  import  <file.json...>                        add notes saved by the old version
+
+ids can be shortened to any unique prefix, dates are 2006-01-02 or RFC 3339.`
+
 func main() {
+	dir := flag.String("dir", "notes", "directory the notes are stored in")
+	flag.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	flag.Parse()
+
+	repo, err := store.NewDir(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		// Without a command, keep the original interactive behaviour.
+		args = []string{"add"}
+	}
+
+	if err := run(repo, args[0], args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
 
-	title, content := getNoteData()
+func run(repo store.Repository, cmd string, args []string) error {
+	switch cmd {
+	case "add":
+		return add(repo, args)
+	case "list", "search":
+		return list(repo, args)
+	case "show":
+		return show(repo, args)
+	case "edit":
+		return edit(repo, args)
+	case "delete":
+		return remove(repo, args)
+	case "export":
+		return export(repo, args)
+	case "import":
+		return importLegacy(repo, args)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", cmd, usage)
+	}
+}
+
+func add(repo store.Repository, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	title := fs.String("title", "", "note title")
+	content := fs.String("content", "", "note content")
+	tags := fs.String("tags", "", "comma separated tags")
+	fs.Parse(args)
 
-	userNote, err := note.New(title, content)
+	if *title == "" {
+		*title = getUserInput("Note Title: ")
+	}
+	if *content == "" {
+		*content = getUserInput("Note Content: ")
+	}
 
+	userNote, err := note.New(*title, *content, splitList(*tags)...)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
+	}
+
+	userNote, err = repo.Create(userNote)
+	if err != nil {
+		return fmt.Errorf("saving failed: %w", err)
+	}
+
+	fmt.Println("Saved successfully as", userNote.ID)
+	return nil
+}
+
+// queryFlags registers the filters shared by list and export.
+func queryFlags(fs *flag.FlagSet) func() (store.Query, error) {
+	text := fs.String("q", "", "text in the title, content or tags")
+	title := fs.String("title", "", "text in the title")
+	tags := fs.String("tag", "", "comma separated tags the notes must all have")
+	from := fs.String("from", "", "created on or after this date")
+	to := fs.String("to", "", "created on or before this date")
+
+	return func() (store.Query, error) {
+		q := store.Query{Text: *text, Title: *title, Tags: splitList(*tags)}
+
+		var err error
+		if *from != "" {
+			if q.From, err = store.ParseDate(*from, false); err != nil {
+				return q, fmt.Errorf("-from: %w", err)
+			}
+		}
+		if *to != "" {
+			if q.To, err = store.ParseDate(*to, true); err != nil {
+				return q, fmt.Errorf("-to: %w", err)
+			}
+		}
+		return q, nil
 	}
+}
 
-	// userNote.Display()
+func list(repo store.Repository, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	query := queryFlags(fs)
+	fs.Parse(args)
 
-	err = userNote.Save()
+	q, err := query()
+	if err != nil {
+		return err
+	}
 
+	notes, err := repo.List(q)
 	if err != nil {
-		fmt.Println("Saving failed")
-		return
+		return err
 	}
 
-	fmt.Println("Saved successfully")
+	for _, n := range notes {
+		tags := ""
+		if len(n.Tags) > 0 {
+			tags = " [" + strings.Join(n.Tags, ", ") + "]"
+		}
+		fmt.Printf("%s  %s  %s%s\n", n.ID[:min(8, len(n.ID))], n.CreatedDate.Format("2006-01-02"), n.Title, tags)
+	}
+	fmt.Printf("%d note(s)\n", len(notes))
+	return nil
 }
 
-func getNoteData() (string, string) {
-	title := getUserInput("Note Title: ")
+func show(repo store.Repository, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	history := fs.Bool("history", false, "print the earlier versions too")
+	fs.Parse(args)
 
-	content := getUserInput("Note Content: ")
+	id, err := oneID(fs)
+	if err != nil {
+		return err
+	}
+
+	n, err := repo.Get(id)
+	if err != nil {
+		return err
+	}
+	n.Display()
 
-	return title, content
+	if *history {
+		for i := len(n.History) - 1; i >= 0; i-- {
+			r := n.History[i]
+			fmt.Printf("\n--- version %d, %s\n", r.Version, r.UpdatedDate.Format("2006-01-02 15:04:05"))
+			fmt.Println("Title: ", r.Title)
+			if len(r.Tags) > 0 {
+				fmt.Println("Tags: ", strings.Join(r.Tags, ", "))
+			}
+			fmt.Println("Content: ", r.Content)
+		}
+	}
+	return nil
 }
 
+func edit(repo store.Repository, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	title := fs.String("title", "", "new title")
+	content := fs.String("content", "", "new content")
+	tags := fs.String("tags", "", "new comma separated tags, \"\" removes them")
+	fs.Parse(args)
+
+	id, err := oneID(fs)
+	if err != nil {
+		return err
+	}
+
+	var c store.Changes
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "title":
+			c.Title = title
+		case "content":
+			c.Content = content
+		case "tags":
+			t := splitList(*tags)
+			c.Tags = &t
+		}
+	})
+	if c == (store.Changes{}) {
+		return errors.New("edit: nothing to change, use -title, -content or -tags")
+	}
+
+	n, err := repo.Update(id, c)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated %s to version %d\n", n.ID, n.Version)
+	return nil
+}
+
+func remove(repo store.Repository, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	fs.Parse(args)
+
+	id, err := oneID(fs)
+	if err != nil {
+		return err
+	}
+
+	n, err := repo.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := repo.Delete(n.ID); err != nil {
+		return err
+	}
+
+	fmt.Println("Deleted", n.ID, n.Title)
+	return nil
+}
+
+func export(repo store.Repository, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	out := fs.String("o", "", "file to write, stdout by default")
+	query := queryFlags(fs)
+	fs.Parse(args)
+
+	q, err := query()
+	if err != nil {
+		return err
+	}
+
+	notes, err := repo.List(q)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	for i, n := range notes {
+		if i > 0 {
+			fmt.Fprint(w, "\n---\n\n")
+		}
+		fmt.Fprint(w, n.Markdown())
+	}
+
+	if *out != "" {
+		fmt.Printf("Exported %d note(s) to %s\n", len(notes), *out)
+	}
+	return nil
+}
+
+// importLegacy adds the files written by the old Save, named after the
+// note title and holding only title, content and created_at.
+func importLegacy(repo store.Repository, files []string) error {
+	if len(files) == 0 {
+		return errors.New("import: no files given")
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var old note.Note
+		if err := json.Unmarshal(data, &old); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		n, err := note.New(old.Title, old.Content, old.Tags...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		if !old.CreatedDate.IsZero() {
+			n.CreatedDate = old.CreatedDate
+		}
+
+		if n, err = repo.Create(n); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %s as %s\n", file, n.ID)
+	}
+	return nil
+}
+
+func oneID(fs *flag.FlagSet) (string, error) {
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%s: expected one note id", fs.Name())
+	}
+	return fs.Arg(0), nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// stdin is shared by every prompt, a reader per call would lose what
+// the previous one buffered.
+var stdin = bufio.NewReader(os.Stdin)
+
 func getUserInput(prompt string) string {
 	fmt.Print(prompt)
 	// var value string
 	// fmt.Scanln(&value) // Scan will not work for long text and also for space
 
-	reader := bufio.NewReader(os.Stdin)
+	text, err := stdin.ReadString('\n') // Since it is single character (rune) we should use '' not ""
 
-	text, err := reader.ReadString('\n') // Since it is single character (rune) we should use '' not ""
-
-	if err != nil {
+	if err != nil && text == "" {
 		return ""
 	}
 
 	text = strings.TrimSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\r")
 
 	return text
 }
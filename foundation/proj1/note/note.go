@@ -1,47 +1,132 @@
 package note
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"slices"
 	"strings"
 	"time"
 )
 
+var ErrInvalid = errors.New("note needs a title and content")
+
 type Note struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Tags        []string   `json:"tags,omitempty"`
+	CreatedDate time.Time  `json:"created_at"`
+	UpdatedDate time.Time  `json:"updated_at,omitempty"`
+	Version     int        `json:"version"`
+	History     []Revision `json:"history,omitempty"`
+}
+
+// Revision is an earlier version of a note, kept when it is updated.
+type Revision struct {
+	Version     int       `json:"version"`
 	Title       string    `json:"title"`
 	Content     string    `json:"content"`
-	CreatedDate time.Time `json:"created_at"`
+	Tags        []string  `json:"tags,omitempty"`
+	UpdatedDate time.Time `json:"updated_at"`
 }
 
-func New(title, content string) (Note, error) {
+func New(title, content string, tags ...string) (Note, error) {
+	title, content = strings.TrimSpace(title), strings.TrimSpace(content)
 
 	if title == "" || content == "" {
-		return Note{}, errors.New("Invalid")
+		return Note{}, ErrInvalid
 	}
 
 	return Note{
 		Title:       title,
 		Content:     content,
+		Tags:        NormalizeTags(tags),
 		CreatedDate: time.Now(),
+		Version:     1,
 	}, nil
 }
 
+// NormalizeTags lowercases and trims tags, drops empty ones and
+// duplicates, and sorts them.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// HasTag reports whether the note is tagged tag.
+func (n Note) HasTag(tag string) bool {
+	return slices.Contains(n.Tags, strings.ToLower(strings.TrimSpace(tag)))
+}
+
+// Revise returns the note with the changes applied and the current
+// version moved to the history.
+func (n Note) Revise(title, content string, tags []string, at time.Time) (Note, error) {
+	title, content = strings.TrimSpace(title), strings.TrimSpace(content)
+	if title == "" || content == "" {
+		return Note{}, ErrInvalid
+	}
+
+	updated := n
+	updated.History = append(slices.Clip(n.History), Revision{
+		Version:     n.Version,
+		Title:       n.Title,
+		Content:     n.Content,
+		Tags:        n.Tags,
+		UpdatedDate: n.LastChange(),
+	})
+	updated.Title = title
+	updated.Content = content
+	updated.Tags = NormalizeTags(tags)
+	updated.UpdatedDate = at
+	updated.Version = n.Version + 1
+	return updated, nil
+}
+
+// LastChange returns when the note was last updated, or created.
+func (n Note) LastChange() time.Time {
+	if n.UpdatedDate.IsZero() {
+		return n.CreatedDate
+	}
+	return n.UpdatedDate
+}
+
 func (n Note) Display() {
+	fmt.Println("ID: ", n.ID)
 	fmt.Println("Title: ", n.Title)
+	if len(n.Tags) > 0 {
+		fmt.Println("Tags: ", strings.Join(n.Tags, ", "))
+	}
+	fmt.Println("Created: ", n.CreatedDate.Format(time.DateTime))
+	if n.Version > 1 {
+		fmt.Printf("Updated:  %s (version %d)\n", n.UpdatedDate.Format(time.DateTime), n.Version)
+	}
 	fmt.Println("Content: ", n.Content)
 }
 
-func (n Note) Save() error {
-	fileName := strings.ReplaceAll(n.Title, " ", "_")
-	fileName = strings.ToLower(fileName) + ".json"
+// Markdown renders the note as a Markdown section.
+func (n Note) Markdown() string {
+	var b strings.Builder
 
-	json, err := json.Marshal(n) // this can write only those field which are exposed, i.e caps letter
-
-	if err != nil {
-		return err
+	fmt.Fprintf(&b, "# %s\n\n", n.Title)
+	fmt.Fprintf(&b, "- Created: %s\n", n.CreatedDate.Format(time.DateTime))
+	if n.Version > 1 {
+		fmt.Fprintf(&b, "- Updated: %s (version %d)\n", n.UpdatedDate.Format(time.DateTime), n.Version)
+	}
+	if len(n.Tags) > 0 {
+		tags := make([]string, len(n.Tags))
+		for i, tag := range n.Tags {
+			tags[i] = "`#" + tag + "`"
+		}
+		fmt.Fprintf(&b, "- Tags: %s\n", strings.Join(tags, " "))
 	}
+	fmt.Fprintf(&b, "\n%s\n", n.Content)
 
-	return os.WriteFile(fileName, json, 0644)
+	return b.String()
 }
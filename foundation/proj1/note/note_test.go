@@ -0,0 +1,71 @@
+package note
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	n, err := New("  Title ", "content\n", "Go", " go", "", "basics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Title != "Title" || n.Content != "content" || n.Version != 1 {
+		t.Errorf("New = %+v", n)
+	}
+	if want := []string{"basics", "go"}; !slices.Equal(n.Tags, want) {
+		t.Errorf("tags = %v, want %v", n.Tags, want)
+	}
+
+	if _, err := New("title", "  "); !errors.Is(err, ErrInvalid) {
+		t.Errorf("empty content: err = %v", err)
+	}
+}
+
+func TestRevise(t *testing.T) {
+	n, _ := New("a", "first", "x")
+	at := n.CreatedDate.Add(time.Hour)
+
+	v2, err := n.Revise("a", "second", []string{"Y"}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v3, err := v2.Revise("b", "third", nil, at.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v3.Version != 3 || v3.Title != "b" || v3.Tags != nil {
+		t.Errorf("v3 = %+v", v3)
+	}
+	if len(v3.History) != 2 {
+		t.Fatalf("history = %+v", v3.History)
+	}
+	if h := v3.History[0]; h.Version != 1 || h.Content != "first" || !h.UpdatedDate.Equal(n.CreatedDate) {
+		t.Errorf("history[0] = %+v", h)
+	}
+	if h := v3.History[1]; h.Version != 2 || h.Content != "second" || !h.UpdatedDate.Equal(at) {
+		t.Errorf("history[1] = %+v", h)
+	}
+	if len(v2.History) != 1 {
+		t.Errorf("revising v2 changed its history: %+v", v2.History)
+	}
+
+	if _, err := v3.Revise("", "x", nil, at); !errors.Is(err, ErrInvalid) {
+		t.Errorf("empty title: err = %v", err)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	n, _ := New("Maps", "maps are references", "go")
+	md := n.Markdown()
+
+	for _, want := range []string{"# Maps\n", "`#go`", "\nmaps are references\n"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"learn/project1/note"
+)
+
+// Dir keeps every note as <id>.json in one directory.
+type Dir struct {
+	path string
+	mu   sync.Mutex
+
+	// now is the clock for updates, replaced in tests.
+	now func() time.Time
+}
+
+func NewDir(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, err
+	}
+	return &Dir{path: path, now: time.Now}, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (d *Dir) file(id string) string {
+	return filepath.Join(d.path, id+".json")
+}
+
+func (d *Dir) Create(n note.Note) (note.Note, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for {
+		id, err := newID()
+		if err != nil {
+			return note.Note{}, err
+		}
+		if _, err := os.Stat(d.file(id)); errors.Is(err, os.ErrNotExist) {
+			n.ID = id
+			break
+		}
+	}
+
+	if n.Version == 0 {
+		n.Version = 1
+	}
+	return n, d.write(n)
+}
+
+func (d *Dir) Get(id string) (note.Note, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.get(id)
+}
+
+func (d *Dir) get(id string) (note.Note, error) {
+	id, err := d.resolve(id)
+	if err != nil {
+		return note.Note{}, err
+	}
+	return d.read(id)
+}
+
+// resolve turns an id or id prefix into the id of a stored note.
+func (d *Dir) resolve(prefix string) (string, error) {
+	if prefix == "" || strings.ContainsAny(prefix, `/\.`) {
+		return "", fmt.Errorf("%q: %w", prefix, ErrNotFound)
+	}
+	if _, err := os.Stat(d.file(prefix)); err == nil {
+		return prefix, nil
+	}
+
+	ids, err := d.ids()
+	if err != nil {
+		return "", err
+	}
+
+	var found []string
+	for _, id := range ids {
+		if strings.HasPrefix(id, prefix) {
+			found = append(found, id)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("%q: %w", prefix, ErrNotFound)
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("%q: %w: %s", prefix, ErrAmbiguous, strings.Join(found, ", "))
+	}
+}
+
+func (d *Dir) ids() ([]string, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, e := range entries {
+		if id, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() && !strings.HasPrefix(id, ".") {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (d *Dir) List(q Query) ([]note.Note, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ids, err := d.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []note.Note
+	for _, id := range ids {
+		n, err := d.read(id)
+		if err != nil {
+			return nil, err
+		}
+		if q.Match(n) {
+			notes = append(notes, n)
+		}
+	}
+
+	slices.SortFunc(notes, func(a, b note.Note) int {
+		if c := b.CreatedDate.Compare(a.CreatedDate); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return notes, nil
+}
+
+func (d *Dir) Update(id string, c Changes) (note.Note, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	n, err := d.get(id)
+	if err != nil {
+		return note.Note{}, err
+	}
+
+	title, content, tags := n.Title, n.Content, n.Tags
+	if c.Title != nil {
+		title = *c.Title
+	}
+	if c.Content != nil {
+		content = *c.Content
+	}
+	if c.Tags != nil {
+		tags = *c.Tags
+	}
+
+	updated, err := n.Revise(title, content, tags, d.now())
+	if err != nil {
+		return note.Note{}, err
+	}
+	return updated, d.write(updated)
+}
+
+func (d *Dir) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id, err := d.resolve(id)
+	if err != nil {
+		return err
+	}
+	return os.Remove(d.file(id))
+}
+
+func (d *Dir) read(id string) (note.Note, error) {
+	data, err := os.ReadFile(d.file(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return note.Note{}, fmt.Errorf("%q: %w", id, ErrNotFound)
+	}
+	if err != nil {
+		return note.Note{}, err
+	}
+
+	var n note.Note
+	if err := json.Unmarshal(data, &n); err != nil {
+		return note.Note{}, fmt.Errorf("%s: %w", d.file(id), err)
+	}
+	// The file name is what Get, Update and Delete look notes up by, an id
+	// in a hand-edited or copied body must not override it.
+	n.ID = id
+	return n, nil
+}
+
+// write replaces the note file in one step, a crash leaves the old
+// version or the new one.
+func (d *Dir) write(n note.Note) error {
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(d.path, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), d.file(n.ID))
+}
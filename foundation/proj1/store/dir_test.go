@@ -0,0 +1,218 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"learn/project1/note"
+)
+
+func newTestDir(t *testing.T) *Dir {
+	t.Helper()
+	d, err := NewDir(filepath.Join(t.TempDir(), "notes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func create(t *testing.T, d *Dir, title, content string, created time.Time, tags ...string) note.Note {
+	t.Helper()
+	n, err := note.New(title, content, tags...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.CreatedDate = created
+	n, err = d.Create(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func day(s string) time.Time {
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t.Add(12 * time.Hour)
+}
+
+func titles(notes []note.Note) []string {
+	var out []string
+	for _, n := range notes {
+		out = append(out, n.Title)
+	}
+	return out
+}
+
+func TestCreateGet(t *testing.T) {
+	d := newTestDir(t)
+	n := create(t, d, "Maps", "reference types", day("2024-01-02"), "go")
+
+	if len(n.ID) != 16 {
+		t.Fatalf("id = %q", n.ID)
+	}
+
+	for _, id := range []string{n.ID, n.ID[:4]} {
+		got, err := d.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", id, err)
+		}
+		if got.ID != n.ID || got.Title != "Maps" || !got.CreatedDate.Equal(n.CreatedDate) {
+			t.Errorf("Get(%q) = %+v", id, got)
+		}
+	}
+
+	for _, id := range []string{"", "zz", "../x", n.ID + "0"} {
+		if _, err := d.Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q): err = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestGetAmbiguous(t *testing.T) {
+	d := newTestDir(t)
+	for _, id := range []string{"abc1", "abc2"} {
+		n, _ := note.New(id, "x")
+		n.ID = id
+		if err := d.write(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := d.Get("abc"); !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("err = %v, want ErrAmbiguous", err)
+	}
+	if n, err := d.Get("abc2"); err != nil || n.Title != "abc2" {
+		t.Errorf("Get(abc2) = %+v, %v", n, err)
+	}
+}
+
+func TestReadTakesIDFromFileName(t *testing.T) {
+	d := newTestDir(t)
+	n := create(t, d, "Copied", "body", day("2024-01-02"))
+
+	// A copy of the file keeps the id of the original in its body.
+	data, err := os.ReadFile(d.file(n.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(d.file("ab"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.Get("ab")
+	if err != nil || got.ID != "ab" {
+		t.Fatalf("Get(ab) = %+v, %v", got, err)
+	}
+	if err := d.Delete(got.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Get(n.ID); err != nil {
+		t.Errorf("deleting the copy removed the original: %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	d := newTestDir(t)
+	create(t, d, "Maps", "reference types", day("2024-01-02"), "go")
+	create(t, d, "Slices", "backed by arrays", day("2024-02-10"), "go", "basics")
+	create(t, d, "Groceries", "milk, eggs", day("2024-03-05"), "home")
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"all newest first", Query{}, []string{"Groceries", "Slices", "Maps"}},
+		{"text in content", Query{Text: "ARRAYS"}, []string{"Slices"}},
+		{"text matches tag", Query{Text: "home"}, []string{"Groceries"}},
+		{"title", Query{Title: "ma"}, []string{"Maps"}},
+		{"all tags", Query{Tags: []string{"go", "basics"}}, []string{"Slices"}},
+		{"from", Query{From: day("2024-02-10")}, []string{"Groceries", "Slices"}},
+		{"range", Query{From: day("2024-01-01"), To: day("2024-02-10")}, []string{"Slices", "Maps"}},
+		{"nothing", Query{Tags: []string{"work"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes, err := d.List(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(notes); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateDelete(t *testing.T) {
+	d := newTestDir(t)
+	at := day("2024-05-01")
+	d.now = func() time.Time { return at }
+
+	n := create(t, d, "Maps", "refs", day("2024-01-02"), "go")
+
+	content := "reference types"
+	updated, err := d.Update(n.ID[:6], Changes{Content: &content})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Version != 2 || updated.Content != content || updated.Title != "Maps" || !updated.UpdatedDate.Equal(at) {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	tags := []string{}
+	if _, err := d.Update(n.ID, Changes{Tags: &tags}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := d.Get(n.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 3 || len(got.Tags) != 0 || len(got.History) != 2 || got.History[0].Content != "refs" {
+		t.Errorf("stored = %+v", got)
+	}
+
+	empty := " "
+	if _, err := d.Update(n.ID, Changes{Title: &empty}); !errors.Is(err, note.ErrInvalid) {
+		t.Errorf("empty title: err = %v", err)
+	}
+
+	if err := d.Delete(n.ID[:3]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Get(n.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("after delete: err = %v", err)
+	}
+
+	entries, _ := os.ReadDir(d.path)
+	if len(entries) != 0 {
+		t.Errorf("directory not empty: %v", entries)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	from, err := ParseDate("2024-02-10", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, _ := ParseDate("2024-02-10", true)
+	if !to.After(from) || to.Sub(from) != 24*time.Hour-time.Nanosecond {
+		t.Errorf("from %v to %v", from, to)
+	}
+
+	if _, err := ParseDate("2024-02-10T08:00:00Z", false); err != nil {
+		t.Error(err)
+	}
+	if _, err := ParseDate("10/02/2024", false); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"learn/project1/note"
+)
+
+var (
+	ErrNotFound  = errors.New("note not found")
+	ErrAmbiguous = errors.New("id prefix matches several notes")
+)
+
+// Query selects notes. Empty fields match every note.
+type Query struct {
+	// Text must appear in the title, content or tags, case-insensitive.
+	Text  string
+	Title string
+	// Tags must all be on the note.
+	Tags []string
+	// From and To bound the creation date, both inclusive.
+	From time.Time
+	To   time.Time
+}
+
+// Changes updates a note, nil fields are kept.
+type Changes struct {
+	Title   *string
+	Content *string
+	Tags    *[]string
+}
+
+type Repository interface {
+	// Create stores a new note and returns it with its ID.
+	Create(n note.Note) (note.Note, error)
+	// Get returns the note with id, or the only one whose id starts
+	// with it.
+	Get(id string) (note.Note, error)
+	// List returns the notes matching q, newest first.
+	List(q Query) ([]note.Note, error)
+	// Update applies the changes, keeping the previous version in the
+	// history.
+	Update(id string, c Changes) (note.Note, error)
+	Delete(id string) error
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"strings"
+	"time"
+
+	"learn/project1/note"
+)
+
+// Match reports whether n is selected by q.
+func (q Query) Match(n note.Note) bool {
+	if q.Title != "" && !containsFold(n.Title, q.Title) {
+		return false
+	}
+
+	if q.Text != "" && !containsFold(n.Title, q.Text) && !containsFold(n.Content, q.Text) && !n.HasTag(q.Text) {
+		return false
+	}
+
+	for _, tag := range q.Tags {
+		if !n.HasTag(tag) {
+			return false
+		}
+	}
+
+	if !q.From.IsZero() && n.CreatedDate.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && n.CreatedDate.After(q.To) {
+		return false
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// ParseDate reads a date for a Query, "2006-01-02" or RFC 3339. With end
+// set, a bare date is moved to the end of that day so To includes it.
+func ParseDate(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
@@ -7,4 +7,7 @@ require (
 	github.com/gorilla/mux v1.8.1
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	github.com/premgowda98/signals v0.0.0
 )
+
+replace github.com/premgowda98/signals => ../../signals
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"project/car-zone/db"
@@ -15,6 +16,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
+	"github.com/premgowda98/signals/lifecycle"
 )
 
 func main() {
@@ -45,6 +47,14 @@ func main() {
 	router.HandleFunc("/engine/{id}", engineHandler.GetEngineById).Methods("GET")
 	router.HandleFunc("/engine", engineHandler.CreateEngine).Methods("POST")
 
+	app := lifecycle.New(lifecycle.Config{})
+	app.Add(
+		lifecycle.Closer("db", db.Close),
+		lifecycle.HTTPServer("http", &http.Server{Addr: ":8080", Handler: router}),
+	)
+
 	log.Println("Server Running on port 8080")
-	http.ListenAndServe(":8080", router)
+	if err := app.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"project/user-management/internal/repository"
 	"project/user-management/internal/routes"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/premgowda98/signals/lifecycle"
 )
 
 func main() {
@@ -15,17 +18,23 @@ func main() {
 		log.Fatal(err)
 	}
 
-	db, err := repository.InitDB()
+	db, err := repository.InitDB(context.Background())
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer db.Close()
-
 	r := gin.Default()
 
 	routes.InitRoutes(r, db)
 
-	r.Run(":8080")
+	app := lifecycle.New(lifecycle.Config{})
+	app.Add(
+		lifecycle.Closer("db", db.Close),
+		lifecycle.HTTPServer("http", &http.Server{Addr: ":8080", Handler: r}),
+	)
+
+	if err := app.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 }
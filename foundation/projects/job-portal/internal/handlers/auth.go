@@ -7,6 +7,7 @@ import (
 	"project/user-management/internal/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/premgowda98/gintimeout"
 )
 
 func LoginHandler(db *sql.DB) gin.HandlerFunc {
@@ -18,7 +19,11 @@ func LoginHandler(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		token, err := services.LoginUser(db, *user.Username, *user.Password)
+		token, err := services.LoginUser(c.Request.Context(), db, *user.Username, *user.Password)
+
+		if gintimeout.Abort(c, err) {
+			return
+		}
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -39,7 +44,11 @@ func RegisterHandler(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		err := services.RegisterUser(db, &user)
+		err := services.RegisterUser(c.Request.Context(), db, &user)
+
+		if gintimeout.Abort(c, err) {
+			return
+		}
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
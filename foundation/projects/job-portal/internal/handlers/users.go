@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/premgowda98/gintimeout"
 )
 
 func GetUserHander(db *sql.DB) gin.HandlerFunc {
@@ -19,7 +20,11 @@ func GetUserHander(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		user, err := services.GetUserByID(db, id)
+		user, err := services.GetUserByID(c.Request.Context(), db, id)
+
+		if gintimeout.Abort(c, err) {
+			return
+		}
 
 		if err !=nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Something went wrong"})
@@ -40,7 +45,11 @@ func UpdateUserHandler(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		user, err := services.GetUserByID(db, id)
+		user, err := services.GetUserByID(c.Request.Context(), db, id)
+
+		if gintimeout.Abort(c, err) {
+			return
+		}
 
 		if user == nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
@@ -59,7 +68,11 @@ func UpdateUserHandler(db *sql.DB) gin.HandlerFunc {
 			return
 		}
 
-		user, err = services.UpdateUser(db, &userUpdate, id)
+		user, err = services.UpdateUser(c.Request.Context(), db, &userUpdate, id)
+
+		if gintimeout.Abort(c, err) {
+			return
+		}
 
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Something went wrong"})
@@ -1,19 +1,20 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 
 	_ "modernc.org/sqlite"
 )
 
-func InitDB() (*sql.DB, error) {
+func InitDB(ctx context.Context) (*sql.DB, error) {
 	db, err := sql.Open("sqlite", "./db.db")
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = createTable(db)
+	err = createTable(ctx, db)
 
 	if err != nil {
 		return nil, err
@@ -22,8 +23,8 @@ func InitDB() (*sql.DB, error) {
 	return db, nil
 }
 
-func createTable(db *sql.DB) error {
-	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS users (
+func createTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS users (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	username TEXT NOT NULL UNIQUE,
 	password TEXT NOT NULL,
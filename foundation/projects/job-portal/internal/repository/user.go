@@ -1,17 +1,18 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"project/user-management/internal/models"
 )
 
-func CreateUser(db *sql.DB, user *models.User) error {
-	_, err := db.Exec(`INSERT INTO users (username, password, email, is_admin) VALUES (?,?,?,?)`, user.Username, user.Password, user.Email, user.IsAdmin)
+func CreateUser(ctx context.Context, db *sql.DB, user *models.User) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO users (username, password, email, is_admin) VALUES (?,?,?,?)`, user.Username, user.Password, user.Email, user.IsAdmin)
 	return err
 }
 
-func GetUserByID(db *sql.DB, id int) (*models.User, error) {
-	row := db.QueryRow(`SELECT id, username, email, is_admin, created_at, updated_at FROM users WHERE id=?`, id)
+func GetUserByID(ctx context.Context, db *sql.DB, id int) (*models.User, error) {
+	row := db.QueryRowContext(ctx, `SELECT id, username, email, is_admin, created_at, updated_at FROM users WHERE id=?`, id)
 
 	user := &models.User{}
 
@@ -24,8 +25,8 @@ func GetUserByID(db *sql.DB, id int) (*models.User, error) {
 	return user, nil
 }
 
-func GetUserByUsername(db *sql.DB, username *string) (*models.User, error) {
-	row := db.QueryRow(`SELECT id, username, password FROM users WHERE username=?`, username)
+func GetUserByUsername(ctx context.Context, db *sql.DB, username *string) (*models.User, error) {
+	row := db.QueryRowContext(ctx, `SELECT id, username, password FROM users WHERE username=?`, username)
 	user := &models.User{}
 
 	err := row.Scan(&user.ID, &user.Username, &user.Password)
@@ -37,7 +38,7 @@ func GetUserByUsername(db *sql.DB, username *string) (*models.User, error) {
 	return user, nil
 }
 
-func UpdateUser(db *sql.DB, user *models.User, id int) (*models.User, error) {
+func UpdateUser(ctx context.Context, db *sql.DB, user *models.User, id int) (*models.User, error) {
 	query := `UPDATE users SET `
 
 	var values []any
@@ -55,7 +56,7 @@ func UpdateUser(db *sql.DB, user *models.User, id int) (*models.User, error) {
 	query += `WHERE id = ?`
 	values = append(values, id)
 
-	_, err := db.Exec(query, values...)
+	_, err := db.ExecContext(ctx, query, values...)
 
 	if err != nil {
 		return nil, err
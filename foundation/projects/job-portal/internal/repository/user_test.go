@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"project/user-management/internal/models"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := createTable(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestUserRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	err := CreateUser(ctx, db, &models.User{
+		Username: ptr("prem"),
+		Password: ptr("hash"),
+		Email:    ptr("prem@me.com"),
+		IsAdmin:  ptr(false),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName, err := GetUserByUsername(ctx, db, ptr("prem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UpdateUser(ctx, db, &models.User{Email: ptr("kumar@me.com")}, *byName.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := GetUserByID(ctx, db, *byName.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *user.Username != "prem" || *user.Email != "kumar@me.com" {
+		t.Errorf("user = %s %s", *user.Username, *user.Email)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	db := openTestDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := GetUserByID(ctx, db, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetUserByID: err = %v, want context.Canceled", err)
+	}
+	err := CreateUser(ctx, db, &models.User{Username: ptr("a"), Password: ptr("b"), Email: ptr("c")})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateUser: err = %v, want context.Canceled", err)
+	}
+
+	if _, err := GetUserByUsername(context.Background(), db, ptr("a")); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("cancelled insert wrote a row: err = %v", err)
+	}
+}
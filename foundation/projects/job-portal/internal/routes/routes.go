@@ -4,16 +4,29 @@ import (
 	"database/sql"
 	"project/user-management/internal/auth"
 	"project/user-management/internal/handlers"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/premgowda98/gintimeout"
 )
 
+// Login and register get the widest budget because they run bcrypt, the
+// user routes are a single row lookup or update.
+const (
+	authTimeout  = 5 * time.Second
+	readTimeout  = 2 * time.Second
+	writeTimeout = 3 * time.Second
+)
+
+// timedOut is the 504 body, in the {"error": ...} shape of the handlers.
+var timedOut = gin.H{"error": "request timed out"}
+
 func InitRoutes(r *gin.Engine, db *sql.DB) {
-	r.POST("/login", handlers.LoginHandler(db))
-	r.POST("/register", handlers.RegisterHandler(db))
+	r.POST("/login", gintimeout.Middleware(authTimeout, timedOut), handlers.LoginHandler(db))
+	r.POST("/register", gintimeout.Middleware(authTimeout, timedOut), handlers.RegisterHandler(db))
 
 	authenticated := r.Group("/")
 	authenticated.Use(auth.AuthMiddelware())
-	authenticated.GET("/users/:id", handlers.GetUserHander(db))
-	authenticated.PUT("/users/:id", handlers.UpdateUserHandler(db))
+	authenticated.GET("/users/:id", gintimeout.Middleware(readTimeout, timedOut), handlers.GetUserHander(db))
+	authenticated.PUT("/users/:id", gintimeout.Middleware(writeTimeout, timedOut), handlers.UpdateUserHandler(db))
 }
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"project/user-management/internal/models"
 	"project/user-management/internal/repository"
@@ -9,8 +10,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func LoginUser(db *sql.DB, username string, password string) (string, error) {
-	user, err := repository.GetUserByUsername(db, &username)
+func LoginUser(ctx context.Context, db *sql.DB, username string, password string) (string, error) {
+	user, err := repository.GetUserByUsername(ctx, db, &username)
 
 	if err != nil {
 		return "", err
@@ -25,7 +26,13 @@ func LoginUser(db *sql.DB, username string, password string) (string, error) {
 	return utils.GenerateJWTToken(username)
 }
 
-func RegisterUser(db *sql.DB, user *models.User) error {
+func RegisterUser(ctx context.Context, db *sql.DB, user *models.User) error {
+	// bcrypt at DefaultCost is the slowest step of a registration, a
+	// request that is already over does not start it
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	hashedPassword, err := HashPassword(user.Password)
 
 	if err != nil {
@@ -34,7 +41,7 @@ func RegisterUser(db *sql.DB, user *models.User) error {
 
 	user.Password = &hashedPassword
 
-	return repository.CreateUser(db, user)
+	return repository.CreateUser(ctx, db, user)
 }
 
 func HashPassword(password *string) (string, error) {
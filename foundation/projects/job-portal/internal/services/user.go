@@ -1,13 +1,14 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"project/user-management/internal/models"
 	"project/user-management/internal/repository"
 )
 
-func GetUserByID(db *sql.DB, id int) (*models.User, error) {
-	user, err := repository.GetUserByID(db, id)
+func GetUserByID(ctx context.Context, db *sql.DB, id int) (*models.User, error) {
+	user, err := repository.GetUserByID(ctx, db, id)
 
 	if err != nil {
 		return nil, err
@@ -17,8 +18,8 @@ func GetUserByID(db *sql.DB, id int) (*models.User, error) {
 
 }
 
-func UpdateUser(db *sql.DB, user *models.User, id int) (*models.User, error) {
-	user, err := repository.UpdateUser(db, user, id)
+func UpdateUser(ctx context.Context, db *sql.DB, user *models.User, id int) (*models.User, error) {
+	user, err := repository.UpdateUser(ctx, db, user, id)
 
 	if err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -8,7 +9,7 @@ import (
 
 var DB *sql.DB
 
-func InitDB() {
+func InitDB(ctx context.Context) {
 	var err error
 	DB, err = sql.Open("sqlite3", "api.db")
 
@@ -19,10 +20,10 @@ func InitDB() {
 	DB.SetMaxOpenConns(10)
 	DB.SetMaxOpenConns(5)
 
-	createTables()
+	createTables(ctx)
 }
 
-func createTables() {
+func createTables(ctx context.Context) {
 	createUsersTable := `
 	CREATE TABLE IF NOT EXISTS users (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -31,7 +32,7 @@ func createTables() {
 	)
 	`
 
-	_, err := DB.Exec(createUsersTable)
+	_, err := DB.ExecContext(ctx, createUsersTable)
 
 	if err != nil {
 		panic("could not create users table")
@@ -49,7 +50,7 @@ func createTables() {
 	)
 	`
 
-	_, err = DB.Exec(createEventsTable)
+	_, err = DB.ExecContext(ctx, createEventsTable)
 
 	if err != nil {
 		panic("could not create events table")
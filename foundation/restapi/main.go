@@ -1,16 +1,29 @@
 package main
 
 import (
+	"context"
+	"log"
+	"net/http"
 	"project/restapi/db"
 	"project/restapi/routes"
 
 	"github.com/gin-gonic/gin"
+	"github.com/premgowda98/signals/lifecycle"
 )
 
 func main() {
-	db.InitDB()
+	db.InitDB(context.Background())
 	server := gin.Default()
 
 	routes.RegisterRoutes(server)
-	server.Run(":8090")
+
+	app := lifecycle.New(lifecycle.Config{})
+	app.Add(
+		lifecycle.Closer("db", db.DB.Close),
+		lifecycle.HTTPServer("http", &http.Server{Addr: ":8090", Handler: server}),
+	)
+
+	if err := app.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"project/restapi/db"
 	"time"
 )
@@ -14,11 +15,11 @@ type Event struct {
 	UserID      int64
 }
 
-func (e *Event) Save() error {
+func (e *Event) Save(ctx context.Context) error {
 	query := `INSERT INTO events (name, description, location, dateTime, userID)
 	VALUES (?,?,?,?,?)
 	`
-	sql_smt, err := db.DB.Prepare(query)
+	sql_smt, err := db.DB.PrepareContext(ctx, query)
 
 	if err != nil {
 		return err
@@ -26,7 +27,7 @@ func (e *Event) Save() error {
 
 	defer sql_smt.Close()
 
-	result, err := sql_smt.Exec(e.Name, e.Description, e.Location, e.DateTime, e.UserID)
+	result, err := sql_smt.ExecContext(ctx, e.Name, e.Description, e.Location, e.DateTime, e.UserID)
 
 	if err != nil {
 		return err
@@ -38,14 +39,14 @@ func (e *Event) Save() error {
 	return err
 }
 
-func (e Event) Update() error {
+func (e Event) Update(ctx context.Context) error {
 	query := `
 	UPDATE events
 	SET name = ?, description = ?, location = ?, dateTime = ?
 	WHERE id = ?
 	`
 
-	sql_smt, err := db.DB.Prepare(query)
+	sql_smt, err := db.DB.PrepareContext(ctx, query)
 
 	if err != nil {
 		return err
@@ -53,16 +54,16 @@ func (e Event) Update() error {
 
 	defer sql_smt.Close()
 
-	_, err = sql_smt.Exec(e.Name, e.Description, e.Location, e.DateTime, e.ID)
+	_, err = sql_smt.ExecContext(ctx, e.Name, e.Description, e.Location, e.DateTime, e.ID)
 
 	return err
 
 }
 
-func GetAllEvents() ([]Event, error) {
+func GetAllEvents(ctx context.Context) ([]Event, error) {
 	query := `SELECT * FROM events`
 
-	rows, err := db.DB.Query(query)
+	rows, err := db.DB.QueryContext(ctx, query)
 
 	if err != nil {
 		return nil, err
@@ -84,13 +85,19 @@ func GetAllEvents() ([]Event, error) {
 		events = append(events, event)
 	}
 
+	// A query cancelled halfway ends the loop early, without this the
+	// events read so far would look like the full list
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return events, nil
 }
 
-func GetAllEventByID(id int64) (*Event, error) {
+func GetAllEventByID(ctx context.Context, id int64) (*Event, error) {
 	query := `SELECT * FROM events WHERE id = ?`
 
-	rows := db.DB.QueryRow(query, id)
+	rows := db.DB.QueryRowContext(ctx, query, id)
 
 	var event Event
 
@@ -103,10 +110,10 @@ func GetAllEventByID(id int64) (*Event, error) {
 	return &event, nil
 }
 
-func DeleteEventByID(id int64) error {
+func DeleteEventByID(ctx context.Context, id int64) error {
 	query := `DELETE FROM events WHERE id = ?`
 
-	sql_smt, err := db.DB.Prepare(query)
+	sql_smt, err := db.DB.PrepareContext(ctx, query)
 
 	if err != nil {
 		return err
@@ -114,7 +121,7 @@ func DeleteEventByID(id int64) error {
 
 	defer sql_smt.Close()
 
-	_, err = sql_smt.Exec(id)
+	_, err = sql_smt.ExecContext(ctx, id)
 
 	if err != nil {
 		return err
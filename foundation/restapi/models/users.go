@@ -1,6 +1,8 @@
 package models
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"project/restapi/db"
 	"project/restapi/utils"
@@ -12,9 +14,9 @@ type User struct {
 	Password string `binding:"required"`
 }
 
-func (u User) Save() error {
+func (u User) Save(ctx context.Context) error {
 	query := `INSERT INTO users (email, password) VALUES (?,?)`
-	sql_smt, err := db.DB.Prepare(query)
+	sql_smt, err := db.DB.PrepareContext(ctx, query)
 
 	if err != nil {
 		return err
@@ -22,13 +24,19 @@ func (u User) Save() error {
 
 	defer sql_smt.Close()
 
+	// utils.HashPassword runs bcrypt at cost 14, about a second of CPU, so
+	// a signup whose client has left stops here
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	hashedPassword, err := utils.HashPassword(u.Password)
 
 	if err != nil {
 		return err
 	}
 
-	_, err = sql_smt.Exec(u.Email, hashedPassword)
+	_, err = sql_smt.ExecContext(ctx, u.Email, hashedPassword)
 
 	if err != nil {
 		return err
@@ -37,16 +45,22 @@ func (u User) Save() error {
 	return err
 }
 
-func (u *User) ValidateCredentials() error {
+func (u *User) ValidateCredentials(ctx context.Context) error {
 	query := `SELECT id, password FROM users WHERE email = ?`
 
-	user := db.DB.QueryRow(query, u.Email)
+	user := db.DB.QueryRowContext(ctx, query, u.Email)
 	
 	var retrievedPassword string
 	err := user.Scan(&u.ID, &retrievedPassword)
 
-	if err != nil{
-		return nil
+	// A failed lookup must not log the user in, whether the email is
+	// unknown or the request was cancelled
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("invalid credentials")
+	}
+
+	if err != nil {
+		return err
 	}
 
 	passwordIsValid := utils.CheckHashPassword(retrievedPassword, u.Password)
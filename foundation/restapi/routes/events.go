@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/premgowda98/gintimeout"
 )
 
 func helloWorld(context *gin.Context) {
@@ -14,7 +15,11 @@ func helloWorld(context *gin.Context) {
 }
 
 func getAllEventes(context *gin.Context) {
-	events, err := models.GetAllEvents()
+	events, err := models.GetAllEvents(context.Request.Context())
+
+	if gintimeout.Abort(context, err) {
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve events"})
@@ -32,7 +37,11 @@ func getEventByID(context *gin.Context) {
 		return
 	}
 
-	event, err2 := models.GetAllEventByID(eventId)
+	event, err2 := models.GetAllEventByID(context.Request.Context(), eventId)
+
+	if gintimeout.Abort(context, err2) {
+		return
+	}
 
 	if err2 != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Could not get events"})
@@ -54,7 +63,11 @@ func createEvent(context *gin.Context) {
 
 	event.UserID = context.GetInt64("userId")
 
-	err = event.Save()
+	err = event.Save(context.Request.Context())
+
+	if gintimeout.Abort(context, err) {
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to save events"})
@@ -74,7 +87,11 @@ func updateEvent(context *gin.Context) {
 		return 
 	}
 
-	event, err := models.GetAllEventByID(eventId)
+	event, err := models.GetAllEventByID(context.Request.Context(), eventId)
+
+	if gintimeout.Abort(context, err) {
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusBadRequest, gin.H{"message": "Some fields are missing"})
@@ -90,7 +107,11 @@ func updateEvent(context *gin.Context) {
 	}
 
 	updatedEvent.ID = eventId
-	err = updatedEvent.Update()
+	err = updatedEvent.Update(context.Request.Context())
+
+	if gintimeout.Abort(context, err) {
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update events"})
@@ -109,7 +130,11 @@ func deleteEvent(context *gin.Context){
 		return 
 	}
 
-	err = models.DeleteEventByID(eventId)
+	err = models.DeleteEventByID(context.Request.Context(), eventId)
+
+	if gintimeout.Abort(context, err) {
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete events"})
@@ -2,27 +2,40 @@ package routes
 
 import (
 	"project/restapi/middelwares"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/premgowda98/gintimeout"
 )
 
+// Budgets of the route groups. signup and login spend about a second in
+// utils.HashPassword, the event routes are single sqlite statements.
+const (
+	authTimeout  = 10 * time.Second
+	readTimeout  = 2 * time.Second
+	writeTimeout = 3 * time.Second
+)
+
+// timedOut is the 504 body, in the {"message": ...} shape of the routes.
+var timedOut = gin.H{"message": "Request timed out"}
+
 func RegisterRoutes(server *gin.Engine) {
 	server.GET("/", helloWorld)
 
-	server.POST("/signup", singup)
+	server.POST("/signup", gintimeout.Middleware(authTimeout, timedOut), singup)
 
-	server.POST("/login", login)
+	server.POST("/login", gintimeout.Middleware(authTimeout, timedOut), login)
 
 	authenticatedRoutes := server.Group("/")
 	authenticatedRoutes.Use(middelwares.Authenticate)
 
-	authenticatedRoutes.GET("/events", getAllEventes)
+	authenticatedRoutes.GET("/events", gintimeout.Middleware(readTimeout, timedOut), getAllEventes)
 
-	authenticatedRoutes.GET("/events/:id", getEventByID)
+	authenticatedRoutes.GET("/events/:id", gintimeout.Middleware(readTimeout, timedOut), getEventByID)
 
-	authenticatedRoutes.POST("/events", createEvent)
+	authenticatedRoutes.POST("/events", gintimeout.Middleware(writeTimeout, timedOut), createEvent)
 
-	authenticatedRoutes.PUT("/events/:id", updateEvent)
+	authenticatedRoutes.PUT("/events/:id", gintimeout.Middleware(writeTimeout, timedOut), updateEvent)
 
-	authenticatedRoutes.DELETE("/events/:id", deleteEvent)
+	authenticatedRoutes.DELETE("/events/:id", gintimeout.Middleware(writeTimeout, timedOut), deleteEvent)
 }
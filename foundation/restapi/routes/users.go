@@ -6,6 +6,7 @@ import (
 	"project/restapi/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/premgowda98/gintimeout"
 )
 
 func singup(context *gin.Context) {
@@ -18,7 +19,11 @@ func singup(context *gin.Context) {
 		return
 	}
 
-	err = user.Save()
+	err = user.Save(context.Request.Context())
+
+	if gintimeout.Abort(context, err) {
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to save user"})
@@ -39,7 +44,11 @@ func login(context *gin.Context) {
 		return
 	}
 
-	err = user.ValidateCredentials()
+	err = user.ValidateCredentials(context.Request.Context())
+
+	if gintimeout.Abort(context, err) {
+		return
+	}
 
 	if err != nil {
 		context.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to validate user"})
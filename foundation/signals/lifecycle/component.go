@@ -0,0 +1,165 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ErrTaskReturned is reported when a Task returns nil before it was
+// stopped.
+var ErrTaskReturned = errors.New("task returned")
+
+type hook struct {
+	name        string
+	start, stop func(ctx context.Context) error
+}
+
+// Hook turns a pair of functions into a Component, nil functions are
+// skipped.
+func Hook(name string, start, stop func(ctx context.Context) error) Component {
+	return &hook{name: name, start: start, stop: stop}
+}
+
+// Closer is a Component that only needs closing at shutdown, such as a
+// database pool or a Kafka writer.
+func Closer(name string, close func() error) Component {
+	return Hook(name, nil, func(context.Context) error { return close() })
+}
+
+func (h *hook) Name() string { return h.name }
+
+func (h *hook) Start(ctx context.Context) error {
+	if h.start == nil {
+		return nil
+	}
+	return h.start(ctx)
+}
+
+func (h *hook) Stop(ctx context.Context) error {
+	if h.stop == nil {
+		return nil
+	}
+	return h.stop(ctx)
+}
+
+// Server runs an *http.Server as a Component.
+type Server struct {
+	name   string
+	srv    *http.Server
+	addr   net.Addr
+	failed chan error
+}
+
+// HTTPServer returns a Component serving srv. Start binds the address, so
+// a port in use fails the start instead of a later goroutine. Stop stops
+// accepting connections and waits for in-flight requests until the
+// shutdown deadline, then closes the remaining connections.
+func HTTPServer(name string, srv *http.Server) *Server {
+	return &Server{name: name, srv: srv, failed: make(chan error, 1)}
+}
+
+func (s *Server) Name() string         { return s.name }
+func (s *Server) Failed() <-chan error { return s.failed }
+
+// Addr returns the address the server listens on once Start returned.
+func (s *Server) Addr() net.Addr { return s.addr }
+
+func (s *Server) Start(ctx context.Context) error {
+	addr := s.srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.addr = ln.Addr()
+
+	go func() {
+		var err error
+		if s.srv.TLSConfig != nil {
+			err = s.srv.ServeTLS(ln, "", "")
+		} else {
+			err = s.srv.Serve(ln)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.failed <- err
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
+	if err != nil {
+		s.srv.Close()
+	}
+	return err
+}
+
+type task struct {
+	name   string
+	run    func(ctx context.Context) error
+	cancel context.CancelFunc
+	done   chan struct{}
+	failed chan error
+
+	mu  sync.Mutex
+	err error
+}
+
+// Task runs fn in a goroutine from Start until Stop cancels its context,
+// for background loops like a consumer or a replication stream. fn
+// returning before that, with or without an error, shuts the service
+// down. Stop waits for fn to return until the shutdown deadline.
+func Task(name string, fn func(ctx context.Context) error) Component {
+	return &task{name: name, run: fn, failed: make(chan error, 1)}
+}
+
+func (t *task) Name() string         { return t.name }
+func (t *task) Failed() <-chan error { return t.failed }
+
+func (t *task) Start(ctx context.Context) error {
+	// The task context is only cancelled by Stop, so tasks stop in their
+	// turn during shutdown and not as soon as it begins.
+	ctx, t.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	t.done = make(chan struct{})
+
+	go func() {
+		defer close(t.done)
+
+		err := t.run(ctx)
+		if ctx.Err() != nil {
+			if errors.Is(err, context.Canceled) {
+				err = nil
+			}
+			t.mu.Lock()
+			t.err = err
+			t.mu.Unlock()
+			return
+		}
+
+		if err == nil {
+			err = ErrTaskReturned
+		}
+		t.failed <- err
+	}()
+	return nil
+}
+
+func (t *task) Stop(ctx context.Context) error {
+	t.cancel()
+
+	select {
+	case <-t.done:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
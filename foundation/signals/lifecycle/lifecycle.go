@@ -0,0 +1,207 @@
+// Package lifecycle starts the parts of a service in order, keeps it
+// running until it is told to stop, and stops the parts in reverse order
+// within a deadline.
+//
+//	app := lifecycle.New(lifecycle.Config{ShutdownTimeout: 15 * time.Second})
+//	app.Add(
+//		lifecycle.Closer("db", db.Close),
+//		lifecycle.Task("consumer", consume),
+//		lifecycle.HTTPServer("http", &http.Server{Addr: ":8080", Handler: router}),
+//	)
+//	app.OnReload("config", reloadConfig)
+//	if err := app.Run(context.Background()); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// SIGINT and SIGTERM start the shutdown, SIGHUP runs the reload hooks. A
+// second SIGINT or SIGTERM during shutdown exits the process right away.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Component is one part of a service. Start must return once the
+// component is ready, long running work belongs in a goroutine. Stop gets
+// a context that expires at the shutdown deadline.
+type Component interface {
+	Name() string
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Failer is implemented by components that can fail after Start returned,
+// such as a server whose listener breaks. An error on the channel shuts
+// the service down. The channel is never closed.
+type Failer interface {
+	Failed() <-chan error
+}
+
+type Config struct {
+	// ShutdownTimeout bounds the time all components get to stop,
+	// DefaultShutdownTimeout when 0.
+	ShutdownTimeout time.Duration
+	// Logf logs start, stop and signals, log.Printf when nil.
+	Logf func(format string, args ...any)
+}
+
+type reloadHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type App struct {
+	cfg        Config
+	components []Component
+	reloads    []reloadHook
+}
+
+// exit ends the process on a forced shutdown, replaced in tests.
+var exit = os.Exit
+
+func New(cfg Config) *App {
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if cfg.Logf == nil {
+		cfg.Logf = log.Printf
+	}
+	return &App{cfg: cfg}
+}
+
+// Add registers components, they start in the order they are added and
+// stop in reverse.
+func (a *App) Add(components ...Component) {
+	a.components = append(a.components, components...)
+}
+
+// OnReload registers fn to run on SIGHUP. Hooks run in the order they were
+// registered; a failing hook is logged and the service keeps running.
+func (a *App) OnReload(name string, fn func(ctx context.Context) error) {
+	a.reloads = append(a.reloads, reloadHook{name: name, fn: fn})
+}
+
+// Run starts the components and blocks until ctx is done, a stop signal
+// arrives or a component fails, then stops them. The error joins the
+// failure that ended the run, if any, with the errors of Stop.
+func (a *App) Run(ctx context.Context) error {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	// runCtx is handed to components and ends the watchers once the run
+	// is over. Components end through Stop, in order, not through it.
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	failed := make(chan error, 1)
+
+	started := 0
+	var cause error
+	for _, c := range a.components {
+		if err := c.Start(runCtx); err != nil {
+			cause = fmt.Errorf("start %s: %w", c.Name(), err)
+			break
+		}
+		a.cfg.Logf("lifecycle: started %s", c.Name())
+		started++
+
+		if f, ok := c.(Failer); ok {
+			go watch(runCtx, c.Name(), f, failed)
+		}
+	}
+
+	if cause == nil {
+		cause = a.wait(runCtx, sigs, failed)
+	}
+
+	return errors.Join(cause, a.stop(sigs, a.components[:started]))
+}
+
+func watch(ctx context.Context, name string, f Failer, failed chan<- error) {
+	select {
+	case err := <-f.Failed():
+		select {
+		case failed <- fmt.Errorf("%s: %w", name, err):
+		default:
+		}
+	case <-ctx.Done():
+	}
+}
+
+// wait blocks until the service should stop and returns why, nil for a
+// signal or a cancelled parent context.
+func (a *App) wait(ctx context.Context, sigs <-chan os.Signal, failed <-chan error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			a.cfg.Logf("lifecycle: context done, shutting down")
+			return nil
+		case err := <-failed:
+			a.cfg.Logf("lifecycle: %v, shutting down", err)
+			return err
+		case sig := <-sigs:
+			if sig == syscall.SIGHUP {
+				a.reload(ctx)
+				continue
+			}
+			a.cfg.Logf("lifecycle: received %v, shutting down (again to force)", sig)
+			return nil
+		}
+	}
+}
+
+func (a *App) reload(ctx context.Context) {
+	for _, h := range a.reloads {
+		if err := h.fn(ctx); err != nil {
+			a.cfg.Logf("lifecycle: reload %s: %v", h.name, err)
+			continue
+		}
+		a.cfg.Logf("lifecycle: reloaded %s", h.name)
+	}
+}
+
+// stop stops components in reverse order, sharing one deadline. A stop
+// signal received meanwhile exits the process.
+func (a *App) stop(sigs <-chan os.Signal, components []Component) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if sig == syscall.SIGHUP {
+					continue
+				}
+				a.cfg.Logf("lifecycle: received %v during shutdown, exiting", sig)
+				exit(1)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		if err := c.Stop(ctx); err != nil {
+			a.cfg.Logf("lifecycle: stop %s: %v", c.Name(), err)
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.Name(), err))
+			continue
+		}
+		a.cfg.Logf("lifecycle: stopped %s", c.Name())
+	}
+	return errors.Join(errs...)
+}
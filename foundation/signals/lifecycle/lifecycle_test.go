@@ -0,0 +1,295 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recorder logs Start and Stop calls of named hooks.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) add(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+func (r *recorder) hook(name string, startErr error) Component {
+	return Hook(name,
+		func(context.Context) error {
+			r.add("start " + name)
+			return startErr
+		},
+		func(context.Context) error {
+			r.add("stop " + name)
+			return nil
+		})
+}
+
+func quiet(string, ...any) {}
+
+// started returns a component that closes ch when it starts, the point
+// from which signals are handled.
+func started(ch chan struct{}) Component {
+	return Hook("started", func(context.Context) error {
+		close(ch)
+		return nil
+	}, nil)
+}
+
+func TestOrder(t *testing.T) {
+	var r recorder
+	app := New(Config{Logf: quiet})
+	app.Add(r.hook("db", nil), r.hook("kafka", nil), r.hook("http", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := app.Run(ctx); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+
+	want := []string{"start db", "start kafka", "start http", "stop http", "stop kafka", "stop db"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestStartFailure(t *testing.T) {
+	var r recorder
+	errBroken := errors.New("broken")
+
+	app := New(Config{Logf: quiet})
+	app.Add(r.hook("db", nil), r.hook("kafka", errBroken), r.hook("http", nil))
+
+	err := app.Run(context.Background())
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("Run() = %v, want %v", err, errBroken)
+	}
+
+	// Only what started is stopped.
+	want := []string{"start db", "start kafka", "stop db"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestSignals(t *testing.T) {
+	var r recorder
+	ready := make(chan struct{})
+	reloaded := make(chan struct{}, 1)
+
+	app := New(Config{Logf: quiet})
+	app.Add(r.hook("db", nil), started(ready))
+	app.OnReload("config", func(context.Context) error {
+		reloaded <- struct{}{}
+		return nil
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- app.Run(context.Background()) }()
+	<-ready
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGHUP)
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGHUP did not run the reload hook")
+	}
+
+	select {
+	case err := <-errc:
+		t.Fatalf("Run returned after SIGHUP: %v", err)
+	default:
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGTERM)
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Errorf("Run() = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGTERM did not stop the app")
+	}
+
+	if got := r.get(); !slices.Contains(got, "stop db") {
+		t.Errorf("calls = %v, db was not stopped", got)
+	}
+}
+
+func TestSecondSignalForcesExit(t *testing.T) {
+	exited := make(chan int, 1)
+	oldExit := exit
+	exit = func(code int) { exited <- code }
+	t.Cleanup(func() { exit = oldExit })
+
+	ready := make(chan struct{})
+	stopping := make(chan struct{})
+	unstick := make(chan struct{})
+
+	app := New(Config{Logf: quiet, ShutdownTimeout: 5 * time.Second})
+	app.Add(Hook("stuck", nil, func(ctx context.Context) error {
+		close(stopping)
+		<-unstick
+		return nil
+	}), started(ready))
+
+	errc := make(chan error, 1)
+	go func() { errc <- app.Run(context.Background()) }()
+	<-ready
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+	<-stopping
+	syscall.Kill(syscall.Getpid(), syscall.SIGINT)
+
+	select {
+	case code := <-exited:
+		if code != 1 {
+			t.Errorf("exit code = %d, want 1", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("second signal did not force an exit")
+	}
+
+	// The fake exit returns, let the run finish before restoring it.
+	close(unstick)
+	<-errc
+}
+
+func TestHTTPServerDrains(t *testing.T) {
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(inFlight)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	srv := HTTPServer("http", &http.Server{Addr: "127.0.0.1:0", Handler: mux})
+	app := New(Config{Logf: quiet, ShutdownTimeout: 5 * time.Second})
+	ready := make(chan struct{})
+	app.Add(srv, started(ready))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- app.Run(ctx) }()
+	<-ready
+
+	type response struct {
+		body string
+		err  error
+	}
+	resc := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + srv.Addr().String() + "/slow")
+		if err != nil {
+			resc <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		resc <- response{string(body), err}
+	}()
+
+	<-inFlight
+	cancel()
+
+	select {
+	case err := <-errc:
+		t.Fatalf("Run returned with a request in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if res := <-resc; res.err != nil || res.body != "done" {
+		t.Errorf("in-flight request = %q, %v", res.body, res.err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("Run() = %v", err)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	app := New(Config{Logf: quiet, ShutdownTimeout: 50 * time.Millisecond})
+	app.Add(Task("stuck", func(ctx context.Context) error {
+		select {}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	err := app.Run(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("shutdown took %v", d)
+	}
+}
+
+func TestTaskFailureStopsApp(t *testing.T) {
+	var r recorder
+	errLost := errors.New("replication slot lost")
+
+	app := New(Config{Logf: quiet})
+	app.Add(r.hook("kafka", nil), Task("cdc", func(ctx context.Context) error {
+		return errLost
+	}))
+
+	done := make(chan error, 1)
+	go func() { done <- app.Run(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errLost) {
+			t.Errorf("Run() = %v, want %v", err, errLost)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed task did not stop the app")
+	}
+
+	if got := r.get(); !slices.Contains(got, "stop kafka") {
+		t.Errorf("calls = %v, kafka was not stopped", got)
+	}
+}
+
+func TestTaskStopsInTurn(t *testing.T) {
+	var r recorder
+
+	app := New(Config{Logf: quiet})
+	app.Add(r.hook("kafka", nil), Task("cdc", func(ctx context.Context) error {
+		<-ctx.Done()
+		r.add("stop cdc")
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := app.Run(ctx); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+
+	want := []string{"start kafka", "stop cdc", "stop kafka"}
+	if got := r.get(); !slices.Equal(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
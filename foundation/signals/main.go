@@ -1,17 +1,47 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"os/signal"
+	"log"
+	"time"
+
+	"github.com/premgowda98/signals/lifecycle"
 )
 
 func main() {
-	ch := make(chan os.Signal, 1)
 	fmt.Println("Setting up signal notification...")
-	signal.Notify(ch, os.Interrupt)
-	// signal.Notify(ch, syscall.SIGINT)
 
-	sig := <-ch
-	fmt.Println("Received signal:", sig)
+	app := lifecycle.New(lifecycle.Config{ShutdownTimeout: 5 * time.Second})
+
+	app.Add(
+		lifecycle.Closer("resource", func() error {
+			fmt.Println("Closing resource")
+			return nil
+		}),
+		lifecycle.Task("ticker", func(ctx context.Context) error {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case t := <-ticker.C:
+					fmt.Println("Working:", t.Format(time.TimeOnly))
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}),
+	)
+
+	app.OnReload("config", func(ctx context.Context) error {
+		fmt.Println("Reloading config")
+		return nil
+	})
+
+	// Ctrl+C stops, a second Ctrl+C while stopping exits right away.
+	// kill -HUP <pid> reloads.
+	if err := app.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 }
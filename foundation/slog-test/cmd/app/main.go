@@ -1,13 +1,42 @@
 package main
 
 import (
-	"log/slog"
+	"context"
+	"fmt"
+	"os"
 	"slogtest/internal/pkg/logger"
 	"slogtest/internal/pkg/service"
+	"time"
 )
 
 func main() {
-	logger.NewLogger("main")
+	cfg, err := logger.LoadConfig()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		cfg = logger.DefaultConfig()
+	}
+
+	logger.Init(cfg)
+	log := logger.NewLogger("main")
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := logger.Shutdown(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to flush logs:", err)
+		}
+	}()
+
+	stopReload := logger.ReloadOnSIGHUP()
+	defer stopReload()
+
+	if cfg.AdminAddr != "" {
+		admin := logger.StartAdmin(cfg.AdminAddr)
+		defer admin.Close()
+		log.Info("log admin listening", "addr", cfg.AdminAddr)
+	}
 
 	userService := service.NewUserService()
 
@@ -16,16 +45,20 @@ func main() {
 		Username: "john_doe",
 		Email:    "john@example.com",
 	}
-	slog.Info("starting user processing")
+	ctx := logger.WithTrace(context.Background(), logger.NewTrace())
+	ctx = logger.WithRequestID(ctx, "startup")
+	ctx = logger.WithUserID(ctx, user.ID)
+
+	log.InfoContext(ctx, "starting user processing")
 
-	err := userService.CreateUser(user)
+	err = userService.CreateUser(ctx, user)
 
 	if err != nil {
-		slog.Error("failed to create user",
+		log.ErrorContext(ctx, "failed to create user",
 			"error", err,
 			"user_id", user.ID,
 		)
 	}
 
-	slog.Info("application finished", "prem", "kumar")
+	log.InfoContext(ctx, "application finished", "prem", "kumar")
 }
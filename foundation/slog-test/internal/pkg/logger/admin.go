@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// AdminHandler exposes the module levels over HTTP.
+//
+//	GET  /log/level                          current levels as JSON
+//	PUT  /log/level?module=service&level=debug
+//	PUT  /log/level?level=warn               default level
+func AdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			level, err := ParseLevel(r.URL.Query().Get("level"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if module := r.URL.Query().Get("module"); module != "" {
+				SetLevel(module, level)
+			} else {
+				SetDefaultLevel(level)
+			}
+
+			slog.Info("log level changed", "target", r.URL.Query().Get("module"), "level", level.String())
+		default:
+			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Levels())
+	})
+}
+
+// StatsHandler reports the records dropped by the async writers, skipped by
+// sampling or folded by deduplication.
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(GetStats())
+	})
+}
+
+// StartAdmin serves AdminHandler on /log/level and StatsHandler on
+// /log/stats at addr in the background.
+func StartAdmin(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/log/level", AdminHandler())
+	mux.Handle("/log/stats", StatsHandler())
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("log admin server stopped", "error", err)
+		}
+	}()
+
+	return srv
+}
+
+// ReloadOnSIGHUP reloads the config with LoadConfig every time the process
+// receives SIGHUP and applies the new levels. The returned func stops it.
+func ReloadOnSIGHUP() (stop func()) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-sig:
+				cfg, err := LoadConfig()
+				if err != nil {
+					slog.Error("failed to reload log config", "error", err)
+					continue
+				}
+				ApplyConfig(cfg)
+				slog.Info("log config reloaded", "levels", Levels())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DropNewest discards the record being written when the queue is full.
+	DropNewest = "drop_newest"
+	// DropOldest discards the oldest queued record to make room.
+	DropOldest = "drop_oldest"
+	// Block waits for room, trading latency for completeness.
+	Block = "block"
+)
+
+var ErrWriterClosed = errors.New("logger: async writer closed")
+
+// AsyncWriter queues writes in a bounded channel and writes them to the
+// underlying writer from a single goroutine, so a slow disk does not stall
+// the caller.
+type AsyncWriter struct {
+	w      io.Writer
+	policy string
+	queue  chan []byte
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	// closing releases writes blocked on a full queue, so Close does not
+	// wait for the lock behind them when the underlying writer is stuck.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+func NewAsyncWriter(w io.Writer, size int, policy string) (*AsyncWriter, error) {
+	switch policy {
+	case "":
+		policy = DropNewest
+	case DropNewest, DropOldest, Block:
+	default:
+		return nil, fmt.Errorf("unknown drop policy %q", policy)
+	}
+
+	if size <= 0 {
+		size = 1024
+	}
+
+	a := &AsyncWriter{
+		w:       w,
+		policy:  policy,
+		queue:   make(chan []byte, size),
+		done:    make(chan struct{}),
+		closing: make(chan struct{}),
+	}
+
+	go a.run()
+
+	return a, nil
+}
+
+func (a *AsyncWriter) run() {
+	defer close(a.done)
+
+	for p := range a.queue {
+		if _, err := a.w.Write(p); err != nil {
+			a.failed.Add(1)
+		}
+	}
+}
+
+// Write copies p into the queue. It never returns an error for a dropped
+// record, drops are counted instead and reported through Stats.
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return 0, ErrWriterClosed
+	}
+
+	buf := make([]byte, len(p))
+	copy(buf, p)
+
+	switch a.policy {
+	case Block:
+		select {
+		case a.queue <- buf:
+		case <-a.closing:
+			return 0, ErrWriterClosed
+		}
+	case DropOldest:
+		for {
+			select {
+			case a.queue <- buf:
+				return len(p), nil
+			default:
+			}
+
+			select {
+			case <-a.queue:
+				a.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case a.queue <- buf:
+		default:
+			a.dropped.Add(1)
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close stops accepting writes and waits until the queue is written out or
+// ctx is done. The underlying writer is left open. Writes still blocked on a
+// full queue fail with ErrWriterClosed.
+func (a *AsyncWriter) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() { close(a.closing) })
+
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncWriter) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+func (a *AsyncWriter) Failed() uint64 {
+	return a.failed.Load()
+}
@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config controls where logs go and which levels are emitted. Levels holds
+// per-module overrides keyed by the name passed to NewLogger, modules without
+// an entry log at Level. Sinks defaults to DefaultSinks when empty.
+type Config struct {
+	LogDir     string                `json:"log_dir"`
+	MaxSize    int                   `json:"max_size"`
+	MaxBackups int                   `json:"max_backups"`
+	MaxAge     int                   `json:"max_age"`
+	Compress   bool                  `json:"compress"`
+	Level      slog.Level            `json:"level"`
+	Levels     map[string]slog.Level `json:"levels"`
+	AdminAddr  string                `json:"admin_addr"`
+	Sinks      []SinkConfig          `json:"sinks"`
+	Async      AsyncConfig           `json:"async"`
+	Sampling   SamplingConfig        `json:"sampling"`
+}
+
+// AsyncConfig moves sink writes onto a background goroutine with a queue of
+// QueueSize records. DropPolicy is DropNewest, DropOldest or Block.
+type AsyncConfig struct {
+	Enabled    bool   `json:"enabled"`
+	QueueSize  int    `json:"queue_size"`
+	DropPolicy string `json:"drop_policy"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		LogDir:     DefaultLogDir,
+		MaxSize:    DefaultMaxSize,
+		MaxBackups: DefaultMaxBackups,
+		MaxAge:     DefaultMaxAge,
+		Compress:   DefaultCompress,
+		Level:      DefaultLevel,
+		Levels:     map[string]slog.Level{},
+	}
+}
+
+// LoadConfig starts from DefaultConfig, applies the JSON file named by
+// LOG_CONFIG if set, and then the individual LOG_* variables on top:
+//
+//	LOG_DIR, LOG_MAX_SIZE, LOG_MAX_BACKUPS, LOG_MAX_AGE, LOG_COMPRESS
+//	LOG_LEVEL=info
+//	LOG_LEVELS=service=debug,main=warn
+//	LOG_ADMIN_ADDR=localhost:6061
+//	LOG_ASYNC=true, LOG_ASYNC_QUEUE=1024, LOG_DROP_POLICY=drop_oldest
+//	LOG_SAMPLE_INITIAL=100, LOG_SAMPLE_THEREAFTER=10, LOG_SAMPLE_INTERVAL=1s
+//	LOG_DEDUP_WINDOW=10s, LOG_SAMPLE_MAX_KEYS=10000
+func LoadConfig() (Config, error) {
+	cfg := DefaultConfig()
+
+	if path := os.Getenv("LOG_CONFIG"); path != "" {
+		fileCfg, err := LoadConfigFile(path)
+		if err != nil {
+			return cfg, err
+		}
+		cfg = fileCfg
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// LoadConfigFile reads a JSON config. Missing fields keep their defaults and
+// levels use the names slog understands ("debug", "INFO", "warn+2").
+func LoadConfigFile(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if cfg.Levels == nil {
+		cfg.Levels = map[string]slog.Level{}
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) applyEnv() error {
+	if v, ok := os.LookupEnv("LOG_DIR"); ok {
+		c.LogDir = v
+	}
+
+	for key, dst := range map[string]*int{
+		"LOG_MAX_SIZE":          &c.MaxSize,
+		"LOG_MAX_BACKUPS":       &c.MaxBackups,
+		"LOG_MAX_AGE":           &c.MaxAge,
+		"LOG_ASYNC_QUEUE":       &c.Async.QueueSize,
+		"LOG_SAMPLE_INITIAL":    &c.Sampling.Initial,
+		"LOG_SAMPLE_THEREAFTER": &c.Sampling.Thereafter,
+		"LOG_SAMPLE_MAX_KEYS":   &c.Sampling.MaxKeys,
+	} {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = n
+		}
+	}
+
+	for key, dst := range map[string]*bool{
+		"LOG_COMPRESS": &c.Compress,
+		"LOG_ASYNC":    &c.Async.Enabled,
+	} {
+		if v, ok := os.LookupEnv(key); ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = b
+		}
+	}
+
+	for key, dst := range map[string]*time.Duration{
+		"LOG_SAMPLE_INTERVAL": &c.Sampling.Interval,
+		"LOG_DEDUP_WINDOW":    &c.Sampling.DedupWindow,
+	} {
+		if v, ok := os.LookupEnv(key); ok {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = d
+		}
+	}
+
+	if v, ok := os.LookupEnv("LOG_DROP_POLICY"); ok {
+		c.Async.DropPolicy = v
+	}
+
+	if v, ok := os.LookupEnv("LOG_LEVEL"); ok {
+		level, err := ParseLevel(v)
+		if err != nil {
+			return err
+		}
+		c.Level = level
+	}
+
+	if v, ok := os.LookupEnv("LOG_LEVELS"); ok {
+		levels, err := ParseLevels(v)
+		if err != nil {
+			return err
+		}
+		for module, level := range levels {
+			c.Levels[module] = level
+		}
+	}
+
+	if v, ok := os.LookupEnv("LOG_ADMIN_ADDR"); ok {
+		c.AdminAddr = v
+	}
+
+	return nil
+}
+
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return level, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// ParseLevels parses a comma separated list of module=level pairs.
+func ParseLevels(s string) (map[string]slog.Level, error) {
+	levels := map[string]slog.Level{}
+
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		module, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid module level %q, expected module=level", pair)
+		}
+
+		level, err := ParseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+
+		levels[strings.TrimSpace(module)] = level
+	}
+
+	return levels, nil
+}
@@ -0,0 +1,147 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	userIDKey
+	traceKey
+)
+
+// TraceContext is the part of a W3C traceparent header the logs care about.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+	Flags   string
+}
+
+// NewTrace starts a new sampled trace with a fresh span.
+func NewTrace() TraceContext {
+	return TraceContext{TraceID: randomHex(16), SpanID: randomHex(8), Flags: "01"}
+}
+
+// ChildSpan keeps the trace ID and flags and gives the span a new ID, used
+// when a request enters this service.
+func (t TraceContext) ChildSpan() TraceContext {
+	t.SpanID = randomHex(8)
+	return t
+}
+
+// Traceparent renders the header value, version 00.
+func (t TraceContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%s", t.TraceID, t.SpanID, t.Flags)
+}
+
+// ParseTraceparent parses a version 00 traceparent header.
+func ParseTraceparent(header string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+
+	if len(parts) != 4 || parts[0] != "00" {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q", header)
+	}
+
+	t := TraceContext{TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}
+
+	if !isHex(t.TraceID, 32) || !isHex(t.SpanID, 16) || !isHex(t.Flags, 2) ||
+		t.TraceID == strings.Repeat("0", 32) || t.SpanID == strings.Repeat("0", 16) {
+		return TraceContext{}, fmt.Errorf("invalid traceparent %q", header)
+	}
+
+	return t, nil
+}
+
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey).(string)
+	return id
+}
+
+func WithTrace(ctx context.Context, t TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey, t)
+}
+
+func Trace(ctx context.Context) (TraceContext, bool) {
+	t, ok := ctx.Value(traceKey).(TraceContext)
+	return t, ok
+}
+
+// contextAttrs returns the IDs stored in ctx as log attributes.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+
+	var attrs []slog.Attr
+
+	if id := RequestID(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+
+	if id := UserID(ctx); id != "" {
+		attrs = append(attrs, slog.String("user_id", id))
+	}
+
+	if t, ok := Trace(ctx); ok {
+		attrs = append(attrs, slog.String("trace_id", t.TraceID), slog.String("span_id", t.SpanID))
+	}
+
+	return attrs
+}
+
+// ContextHandler adds the request, user and trace IDs found in the context of
+// each record, so callers only need the *Context logging methods. Like any
+// record attrs they end up inside the group opened with WithGroup, if any.
+type ContextHandler struct {
+	slog.Handler
+}
+
+func (h ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := contextAttrs(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return ContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h ContextHandler) WithGroup(name string) slog.Handler {
+	return ContextHandler{h.Handler.WithGroup(name)}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.SpanID != "00f067aa0ba902b7" || tc.Flags != "01" {
+		t.Errorf("unexpected trace context %+v", tc)
+	}
+
+	for _, bad := range []string{
+		"",
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestMiddlewarePropagatesIDs(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(ContextHandler{slog.NewTextHandler(&buf, nil)})
+
+	var seen context.Context
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Context()
+		log.InfoContext(r.Context(), "inside")
+	})
+	// Stands in for the authentication middleware.
+	auth := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(w, r.WithContext(WithUserID(r.Context(), "42")))
+	})
+	handler := Middleware(log)(auth)
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(HeaderRequestID, "req-1")
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	trace, ok := Trace(seen)
+
+	if !ok || trace.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || trace.SpanID == "00f067aa0ba902b7" {
+		t.Errorf("expected same trace with a new span, got %+v", trace)
+	}
+
+	if rr.Header().Get(HeaderRequestID) != "req-1" || rr.Header().Get(HeaderTraceparent) != trace.Traceparent() {
+		t.Errorf("unexpected response headers %v", rr.Header())
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		for _, want := range []string{"request_id=req-1", "trace_id=4bf92f3577b34da6a3ce929d0e0e4736", "span_id=" + trace.SpanID} {
+			if !strings.Contains(line, want) {
+				t.Errorf("expected %q in %q", want, line)
+			}
+		}
+	}
+
+	if !strings.Contains(buf.String(), "user_id=42") {
+		t.Errorf("expected the user ID set by auth in %q", buf.String())
+	}
+
+	out := http.Header{}
+	InjectHeaders(seen, out)
+
+	if out.Get(HeaderTraceparent) != trace.Traceparent() || out.Get(HeaderRequestID) != "req-1" {
+		t.Errorf("unexpected injected headers %v", out)
+	}
+}
+
+func TestMiddlewareDistrustsClientIDs(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(ContextHandler{slog.NewTextHandler(&buf, nil)})
+
+	var seen context.Context
+	handler := Middleware(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Context()
+	}))
+
+	for _, id := range []string{
+		"",
+		"req 1",
+		"req-1\nlevel=ERROR msg=forged",
+		strings.Repeat("a", maxRequestIDLen+1),
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(HeaderRequestID, id)
+		req.Header.Set("X-User-ID", "admin")
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		got := RequestID(seen)
+		if got == id || !validRequestID(got) || rr.Header().Get(HeaderRequestID) != got {
+			t.Errorf("expected a generated request ID for %q, got %q", id, got)
+		}
+
+		if UserID(seen) != "" {
+			t.Errorf("expected the X-User-ID header to be ignored, got %q", UserID(seen))
+		}
+	}
+
+	if strings.Contains(buf.String(), "forged") || strings.Contains(buf.String(), "admin") {
+		t.Errorf("client supplied IDs were logged:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,259 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CustomHandler writes one line per record in the compact text format used
+// by the log files:
+//
+//	I2025-01-02 15:04:05.000 source=service/user.go:42 user created module=service user.id=1
+//
+// Attributes added with WithAttrs and groups opened with WithGroup are kept,
+// group names prefix the keys they contain.
+type CustomHandler struct {
+	w    io.Writer
+	mu   *sync.Mutex
+	opts slog.HandlerOptions
+
+	// preformatted holds the attrs from WithAttrs already rendered with
+	// the group prefix that was open when they were added.
+	preformatted string
+	prefix       string
+}
+
+func NewCustomHandler(w io.Writer, opts *slog.HandlerOptions) *CustomHandler {
+	h := &CustomHandler{
+		w:  w,
+		mu: &sync.Mutex{},
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *CustomHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *CustomHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := *h
+	var b strings.Builder
+	b.WriteString(h.preformatted)
+	for _, a := range attrs {
+		h2.appendAttr(&b, h.prefix, a)
+	}
+	h2.preformatted = b.String()
+
+	return &h2
+}
+
+func (h *CustomHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	h2.prefix = h.prefix + name + "."
+
+	return &h2
+}
+
+func (h *CustomHandler) Handle(ctx context.Context, r slog.Record) error {
+	var b strings.Builder
+
+	level := strings.ToUpper(r.Level.String())
+	b.WriteString(level[:1])
+	b.WriteString(r.Time.Format("2006-01-02 15:04:05.000"))
+
+	if h.opts.AddSource {
+		if src := recordSource(r); src != nil {
+			fmt.Fprintf(&b, " source=%s:%d", src.File, src.Line)
+		}
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	b.WriteString(h.preformatted)
+
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(&b, h.prefix, a)
+		return true
+	})
+
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *CustomHandler) appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		var groups []string
+		if prefix != "" {
+			groups = strings.Split(strings.TrimSuffix(prefix, "."), ".")
+		}
+		a = h.opts.ReplaceAttr(groups, a)
+	}
+
+	a.Value = a.Value.Resolve()
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		if len(group) == 0 {
+			return
+		}
+
+		// An inline group (empty key) adds its attrs at the current level.
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range group {
+			h.appendAttr(b, prefix, ga)
+		}
+		return
+	}
+
+	b.WriteByte(' ')
+	b.WriteString(quoteIfNeeded(prefix + a.Key))
+	b.WriteByte('=')
+	b.WriteString(quoteIfNeeded(formatValue(a.Value)))
+}
+
+func formatValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return err.Error()
+		}
+	}
+	return v.String()
+}
+
+func quoteIfNeeded(s string) string {
+	if s == "" {
+		return `""`
+	}
+	if strings.ContainsAny(s, " =\"\t\r\n") || !strconv.CanBackquote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// recordSource resolves the call site from Record.PC, so it is correct no
+// matter how many handlers the record went through.
+func recordSource(r slog.Record) *slog.Source {
+	if r.PC == 0 {
+		return nil
+	}
+
+	frames := runtime.CallersFrames([]uintptr{r.PC})
+	frame, _ := frames.Next()
+
+	return &slog.Source{
+		Function: frame.Function,
+		File:     relativePath(frame.File),
+		Line:     frame.Line,
+	}
+}
+
+func relativePath(file string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+
+	if relPath, err := filepath.Rel(wd, file); err == nil {
+		return relPath
+	}
+
+	return file
+}
+
+// sinkHandler restricts a handler to the records in [min, max].
+type sinkHandler struct {
+	handler slog.Handler
+	min     slog.Level
+	max     slog.Level
+}
+
+func (s sinkHandler) accepts(level slog.Level) bool {
+	return level >= s.min && level <= s.max
+}
+
+// fanoutHandler sends every record to all sinks whose level range covers it.
+type fanoutHandler struct {
+	sinks []sinkHandler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, s := range h.sinks {
+		if s.accepts(level) && s.handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+
+	for _, s := range h.sinks {
+		if !s.accepts(r.Level) || !s.handler.Enabled(ctx, r.Level) {
+			continue
+		}
+
+		if err := s.handler.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sinks := make([]sinkHandler, len(h.sinks))
+	for i, s := range h.sinks {
+		s.handler = s.handler.WithAttrs(attrs)
+		sinks[i] = s
+	}
+	return &fanoutHandler{sinks: sinks}
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	sinks := make([]sinkHandler, len(h.sinks))
+	for i, s := range h.sinks {
+		s.handler = s.handler.WithGroup(name)
+		sinks[i] = s
+	}
+	return &fanoutHandler{sinks: sinks}
+}
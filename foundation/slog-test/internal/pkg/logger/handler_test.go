@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCustomHandlerKeepsAttrsAndGroups(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewCustomHandler(&buf, &slog.HandlerOptions{AddSource: true}))
+
+	log.With("module", "service").WithGroup("user").Info("user created", "id", 1, "name", "john doe")
+
+	line := buf.String()
+
+	for _, want := range []string{
+		"source=handler_test.go:",
+		" user created module=service user.id=1 ",
+		`user.name="john doe"`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected %q in %q", want, line)
+		}
+	}
+}
+
+func TestFanoutLevelRanges(t *testing.T) {
+	var all, errs, info bytes.Buffer
+
+	jsonHandler, _ := newFormatHandler(FormatJSON, &all)
+	errHandler, _ := newFormatHandler(FormatText, &errs)
+	infoHandler, _ := newFormatHandler(FormatLogfmt, &info)
+
+	fanout := &fanoutHandler{sinks: []sinkHandler{
+		{handler: jsonHandler, min: LevelDebug, max: LevelError},
+		{handler: errHandler, min: LevelError, max: LevelError},
+		{handler: infoHandler, min: LevelInfo, max: LevelWarn},
+	}}
+
+	log := slog.New(fanout).With("module", "api")
+	log.Info("hello")
+	log.Error("boom")
+
+	if n := strings.Count(all.String(), "\n"); n != 2 {
+		t.Errorf("expected 2 json lines, got %d", n)
+	}
+
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(strings.SplitN(all.String(), "\n", 2)[0]), &rec); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec["module"] != "api" || !strings.HasPrefix(rec["source"].(string), "handler_test.go:") {
+		t.Errorf("unexpected json record %v", rec)
+	}
+
+	if strings.Contains(errs.String(), "hello") || !strings.Contains(errs.String(), "boom module=api") {
+		t.Errorf("unexpected error sink output %q", errs.String())
+	}
+
+	if !strings.Contains(info.String(), "msg=hello module=api") || strings.Contains(info.String(), "boom") {
+		t.Errorf("unexpected info sink output %q", info.String())
+	}
+}
+
+func TestUserTimeAttr(t *testing.T) {
+	for _, format := range []string{FormatText, FormatJSON, FormatLogfmt} {
+		var buf bytes.Buffer
+		h, err := newFormatHandler(format, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slog.New(h).Info("hello", "time", "yesterday")
+
+		if !strings.Contains(buf.String(), "yesterday") {
+			t.Errorf("%s: string time attribute lost in %q", format, buf.String())
+		}
+	}
+}
@@ -2,22 +2,17 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"os"
-	"path/filepath"
-	"runtime"
-	"strings"
 	"sync"
-	"time"
-
-	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var (
-	instance *slog.Logger
-	once     sync.Once
+	root   *leveledHandler
+	active *pipeline
+	once   sync.Once
 )
 
 const (
@@ -36,184 +31,120 @@ const (
 	DefaultLevel      = LevelDebug
 )
 
-type Config struct {
-	LogDir     string
-	MaxSize    int
-	MaxBackups int
-	MaxAge     int
-	Compress   bool
-	Level      slog.Level
+// Init sets up the log files and the module levels from cfg and installs the
+// slog default logger. Only the first call has an effect, and NewLogger falls
+// back to LoadConfig when Init was never called.
+func Init(cfg Config) {
+	once.Do(func() { setup(cfg) })
 }
 
-func NewLogger(packageName string) *slog.Logger {
-	var logger *slog.Logger
+func setup(cfg Config) {
+	os.MkdirAll(cfg.LogDir, 0755)
 
-	//singleton
-	once.Do(func() {
-		cfg := Config{
-			LogDir:     DefaultLogDir,
-			MaxSize:    DefaultMaxSize,
-			MaxBackups: DefaultMaxBackups,
-			MaxAge:     DefaultMaxAge,
-			Compress:   DefaultCompress,
-			Level:      DefaultLevel,
-		}
+	p, err := newPipeline(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "logger: %v, using default sinks\n", err)
+		cfg.Sinks, cfg.Async = nil, AsyncConfig{}
+		p, _ = newPipeline(cfg)
+	}
 
-		os.MkdirAll(cfg.LogDir, 0755)
+	ApplyConfig(cfg)
 
-		errorLogger := &lumberjack.Logger{
-			Filename:   filepath.Join(cfg.LogDir, "error.log"),
-			MaxSize:    cfg.MaxSize,
-			MaxBackups: cfg.MaxBackups,
-			MaxAge:     cfg.MaxAge,
-			Compress:   cfg.Compress,
-		}
+	active = p
+	root = &leveledHandler{
+		next:  p.handler,
+		level: levels.fallback,
+	}
 
-		infoLogger := &lumberjack.Logger{
-			Filename:   filepath.Join(cfg.LogDir, "info.log"),
-			MaxSize:    cfg.MaxSize,
-			MaxBackups: cfg.MaxBackups,
-			MaxAge:     cfg.MaxAge,
-			Compress:   cfg.Compress,
-		}
+	slog.SetDefault(slog.New(root))
+}
 
-		debugLogger := &lumberjack.Logger{
-			Filename:   filepath.Join(cfg.LogDir, "debug.log"),
-			MaxSize:    cfg.MaxSize,
-			MaxBackups: cfg.MaxBackups,
-			MaxAge:     cfg.MaxAge,
-			Compress:   cfg.Compress,
+// NewLogger returns a logger for one module. Its records carry a "module"
+// attribute and are filtered by that module's level, see SetLevel.
+func NewLogger(packageName string) *slog.Logger {
+	once.Do(func() {
+		cfg, err := LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "logger: %v, using defaults\n", err)
+			cfg = DefaultConfig()
 		}
+		setup(cfg)
+	})
 
-		opts := &slog.HandlerOptions{
-			Level:     cfg.Level,
-			AddSource: true,
-			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-				if a.Key == slog.TimeKey {
-					a.Value = slog.StringValue(time.Now().Format(time.RFC3339))
-				}
-				return a
-			},
-		}
+	h := &leveledHandler{
+		next:  root.next,
+		level: levels.get(packageName),
+	}
 
-		handler := slog.NewJSONHandler(os.Stdout, opts)
-		logger = slog.New(&leveledHandler{
-			defaultHandler: handler,
-			errorWriter:    errorLogger,
-			infoWriter:     infoLogger,
-			debugWriter:    debugLogger,
-			opts:           opts,
-		})
-
-		instance = logger
-		slog.SetDefault(instance)
-	})
+	return slog.New(h).With("module", packageName)
+}
 
-	return instance
+// leveledHandler applies the module level in front of the sinks.
+type leveledHandler struct {
+	next  slog.Handler
+	level slog.Leveler
 }
 
-type CustomHandler struct {
-	slog.Handler
-	w    io.Writer
-	opts slog.HandlerOptions
+func (h *leveledHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.next.Enabled(ctx, level)
 }
 
-func NewCustomHandler(w io.Writer, opts *slog.HandlerOptions) *CustomHandler {
-	return &CustomHandler{
-		Handler: slog.NewTextHandler(w, opts),
-		w:       w,
-		opts:    *opts,
-	}
+func (h *leveledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &leveledHandler{next: h.next.WithAttrs(attrs), level: h.level}
 }
 
-func getCaller(skip int) *slog.Source {
-	_, file, line, ok := runtime.Caller(skip)
-	if !ok {
-		return nil
-	}
+func (h *leveledHandler) WithGroup(name string) slog.Handler {
+	return &leveledHandler{next: h.next.WithGroup(name), level: h.level}
+}
 
-	wd, err := os.Getwd()
-	if err == nil {
-		if relPath, err := filepath.Rel(wd, file); err == nil {
-			file = relPath
-		}
-	}
+func (h *leveledHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
 
-	return &slog.Source{
-		File: file,
-		Line: line,
+// Shutdown writes out pending deduplicated errors and drains the async
+// queues. Call it once before the process exits.
+func Shutdown(ctx context.Context) error {
+	if active == nil {
+		return nil
 	}
-}
 
-func (h *CustomHandler) Handle(ctx context.Context, r slog.Record) error {
-	level := strings.ToUpper(r.Level.String())
-	timeStr := r.Time.Format("2006-01-02 15:04:05.000")
-
-	var otherOpts string = ""
-	// fmt.Println(r.sou)
-	r.Attrs(func(a slog.Attr) bool {
-		otherOpts += a.Key
-		otherOpts += " "
-		otherOpts += a.Value.String()
-		return true
-	})
+	var errs []error
 
-	source := getCaller(2)
+	if active.sampler != nil {
+		errs = append(errs, active.sampler.Flush(ctx))
+	}
 
-	output := fmt.Sprintf("%s%s %s %s %s\n",
-		level[:1],
-		timeStr,
-		slog.String("source", fmt.Sprintf("%s:%d", source.File, source.Line)),
-		r.Message,
-		otherOpts,
-	)
+	for _, w := range active.writers {
+		errs = append(errs, w.Close(ctx))
+	}
 
-	_, err := h.w.Write([]byte(output))
-	return err
+	return errors.Join(errs...)
 }
 
-type leveledHandler struct {
-	defaultHandler slog.Handler
-	errorWriter    io.Writer
-	infoWriter     io.Writer
-	debugWriter    io.Writer
-	opts           *slog.HandlerOptions
+// Stats counts the records the pipeline did not write.
+type Stats struct {
+	Dropped      uint64 `json:"dropped"`
+	WriteErrors  uint64 `json:"write_errors"`
+	Sampled      uint64 `json:"sampled"`
+	Deduplicated uint64 `json:"deduplicated"`
 }
 
-func (h *leveledHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return h.defaultHandler.Enabled(ctx, level)
-}
+func GetStats() Stats {
+	var s Stats
 
-func (h *leveledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &leveledHandler{
-		defaultHandler: h.defaultHandler.WithAttrs(attrs),
-		errorWriter:    h.errorWriter,
-		infoWriter:     h.infoWriter,
-		debugWriter:    h.debugWriter,
-		opts:           h.opts,
+	if active == nil {
+		return s
 	}
-}
 
-func (h *leveledHandler) WithGroup(name string) slog.Handler {
-	return &leveledHandler{
-		defaultHandler: h.defaultHandler.WithGroup(name),
-		errorWriter:    h.errorWriter,
-		infoWriter:     h.infoWriter,
-		debugWriter:    h.debugWriter,
-		opts:           h.opts,
+	for _, w := range active.writers {
+		s.Dropped += w.Dropped()
+		s.WriteErrors += w.Failed()
 	}
-}
 
-func (h *leveledHandler) Handle(ctx context.Context, r slog.Record) error {
-	var handler slog.Handler
-	switch {
-	case r.Level >= LevelError:
-		handler = NewCustomHandler(h.errorWriter, h.opts)
-	case r.Level >= LevelInfo:
-		handler = NewCustomHandler(h.infoWriter, h.opts)
-	default:
-		handler = NewCustomHandler(h.debugWriter, h.opts)
+	if active.sampler != nil {
+		s.Sampled = active.sampler.state.sampled.Load()
+		s.Deduplicated = active.sampler.state.deduplicated.Load()
 	}
 
-	return handler.Handle(ctx, r)
+	return s
 }
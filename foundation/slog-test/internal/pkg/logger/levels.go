@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// levels hands out one LevelVar per module. Loggers keep a pointer to their
+// module's LevelVar, so changing it takes effect on the next record without
+// rebuilding any logger.
+var levels = &levelRegistry{
+	fallback: &slog.LevelVar{},
+	modules:  map[string]*slog.LevelVar{},
+}
+
+type levelRegistry struct {
+	mu        sync.Mutex
+	fallback  *slog.LevelVar
+	modules   map[string]*slog.LevelVar
+	overrides map[string]bool
+}
+
+func (r *levelRegistry) get(module string) *slog.LevelVar {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lv, ok := r.modules[module]
+	if !ok {
+		lv = &slog.LevelVar{}
+		lv.Set(r.fallback.Level())
+		r.modules[module] = lv
+	}
+
+	return lv
+}
+
+func (r *levelRegistry) set(module string, level slog.Level) {
+	lv := r.get(module)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.overrides == nil {
+		r.overrides = map[string]bool{}
+	}
+	r.overrides[module] = true
+	lv.Set(level)
+}
+
+// apply replaces the current levels with the ones in cfg. Modules that are
+// no longer overridden fall back to cfg.Level.
+func (r *levelRegistry) apply(cfg Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.fallback.Set(cfg.Level)
+	r.overrides = map[string]bool{}
+
+	for module := range cfg.Levels {
+		if _, ok := r.modules[module]; !ok {
+			r.modules[module] = &slog.LevelVar{}
+		}
+	}
+
+	for module, lv := range r.modules {
+		if level, ok := cfg.Levels[module]; ok {
+			lv.Set(level)
+			r.overrides[module] = true
+		} else {
+			lv.Set(cfg.Level)
+		}
+	}
+}
+
+func (r *levelRegistry) snapshot() map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := map[string]string{"default": r.fallback.Level().String()}
+	for module, lv := range r.modules {
+		out[module] = lv.Level().String()
+	}
+
+	return out
+}
+
+// SetLevel changes the level of one module at runtime.
+func SetLevel(module string, level slog.Level) {
+	levels.set(module, level)
+}
+
+// SetDefaultLevel changes the level of every module without an override.
+func SetDefaultLevel(level slog.Level) {
+	levels.mu.Lock()
+	defer levels.mu.Unlock()
+
+	levels.fallback.Set(level)
+	for module, lv := range levels.modules {
+		if !levels.overrides[module] {
+			lv.Set(level)
+		}
+	}
+}
+
+// Levels returns the current level of every known module plus "default".
+func Levels() map[string]string {
+	return levels.snapshot()
+}
+
+// ApplyConfig swaps in the levels from cfg, used when the config is reloaded.
+func ApplyConfig(cfg Config) {
+	levels.apply(cfg)
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	got, err := ParseLevels("service=debug, main=WARN")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got["service"] != slog.LevelDebug || got["main"] != slog.LevelWarn {
+		t.Errorf("unexpected levels %v", got)
+	}
+
+	if _, err := ParseLevels("service"); err == nil {
+		t.Error("expected error for missing level")
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	cfg := Config{LogDir: t.TempDir(), Level: slog.LevelInfo, Levels: map[string]slog.Level{"db": slog.LevelDebug}}
+	Init(cfg)
+	// Init only runs once per process, reset the levels left by earlier runs.
+	ApplyConfig(cfg)
+
+	ctx := context.Background()
+	api := NewLogger("api")
+	db := NewLogger("db")
+
+	if api.Enabled(ctx, slog.LevelDebug) {
+		t.Error("api should inherit the info default")
+	}
+
+	if !db.Enabled(ctx, slog.LevelDebug) {
+		t.Error("db override should enable debug")
+	}
+
+	SetLevel("api", slog.LevelDebug)
+
+	if !api.Enabled(ctx, slog.LevelDebug) {
+		t.Error("SetLevel should apply to existing loggers")
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/log/level?level=error", nil)
+	rr := httptest.NewRecorder()
+	AdminHandler().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	if NewLogger("other").Enabled(ctx, slog.LevelWarn) {
+		t.Error("default level change should apply to new modules")
+	}
+
+	if !api.Enabled(ctx, slog.LevelDebug) {
+		t.Error("default level change should keep module overrides")
+	}
+}
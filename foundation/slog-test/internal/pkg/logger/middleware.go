@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const (
+	HeaderRequestID   = "X-Request-ID"
+	HeaderTraceparent = "traceparent"
+)
+
+// maxRequestIDLen bounds request IDs taken from callers, a UUID or a 32
+// character hex ID fit with room to spare.
+const maxRequestIDLen = 128
+
+// Middleware stores the request ID and trace context of every request in its
+// context. IDs sent by the caller are kept if they are well formed, missing or
+// malformed ones are generated, and the span always gets a new ID for this hop.
+// The request ID and traceparent are echoed on the response so clients can
+// quote them.
+//
+// The user ID is never taken from the request, anyone could send one. The
+// authentication middleware sets it with WithUserID once it has verified who
+// the caller is.
+func Middleware(log *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx := r.Context()
+
+			requestID := r.Header.Get(HeaderRequestID)
+			if !validRequestID(requestID) {
+				requestID = randomHex(16)
+			}
+			ctx = WithRequestID(ctx, requestID)
+
+			trace, err := ParseTraceparent(r.Header.Get(HeaderTraceparent))
+			if err != nil {
+				trace = NewTrace()
+			} else {
+				trace = trace.ChildSpan()
+			}
+			ctx = WithTrace(ctx, trace)
+
+			w.Header().Set(HeaderRequestID, requestID)
+			w.Header().Set(HeaderTraceparent, trace.Traceparent())
+
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			log.InfoContext(ctx, "request completed",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", rec.status,
+				"duration", time.Since(start),
+			)
+		})
+	}
+}
+
+// InjectHeaders copies the IDs in ctx onto an outgoing request so the next
+// service logs with the same request and trace IDs. The user ID is left out,
+// the next service authenticates the caller itself.
+func InjectHeaders(ctx context.Context, h http.Header) {
+	if id := RequestID(ctx); id != "" {
+		h.Set(HeaderRequestID, id)
+	}
+
+	if t, ok := Trace(ctx); ok {
+		h.Set(HeaderTraceparent, t.Traceparent())
+	}
+}
+
+// validRequestID reports whether id is safe to echo and log: not empty, not
+// too long, and only letters, digits and "-", "_", ".", ":".
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,294 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingConfig limits how often the same message is logged. Within every
+// Interval the first Initial records of a message pass, after that only every
+// Thereafter-th one. Errors are not sampled but deduplicated: identical error
+// records within DedupWindow are dropped and the next one that passes carries
+// a "repeated" count. Zero values disable the respective feature.
+//
+// Counters are kept per message and dropped once their interval or window
+// has passed. At most MaxKeys messages and MaxKeys errors are tracked at a
+// time, records beyond that pass unsampled.
+type SamplingConfig struct {
+	Initial     int           `json:"initial"`
+	Thereafter  int           `json:"thereafter"`
+	Interval    time.Duration `json:"interval"`
+	DedupWindow time.Duration `json:"dedup_window"`
+	MaxKeys     int           `json:"max_keys"`
+}
+
+const DefaultSamplingMaxKeys = 10000
+
+func (c SamplingConfig) enabled() bool {
+	return (c.Initial > 0 && c.Interval > 0) || c.DedupWindow > 0
+}
+
+type sampleCounter struct {
+	start time.Time
+	count int
+}
+
+type dedupEntry struct {
+	first      time.Time
+	suppressed int
+	last       slog.Record
+	// ctx is the context of the last suppressed record, without its
+	// cancellation, so the summary keeps its request and trace IDs.
+	ctx     context.Context
+	handler slog.Handler
+}
+
+// summary returns the last suppressed record with its repeat count.
+func (e *dedupEntry) summary() slog.Record {
+	r := e.last.Clone()
+	r.AddAttrs(slog.Int("repeated", e.suppressed))
+	return r
+}
+
+// samplerState is shared by every handler derived through WithAttrs and
+// WithGroup, so the limits apply per message and not per logger instance.
+type samplerState struct {
+	cfg SamplingConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	samples   map[string]*sampleCounter
+	dedup     map[string]*dedupEntry
+	lastSweep time.Time
+
+	sampled      atomic.Uint64
+	deduplicated atomic.Uint64
+}
+
+type SamplingHandler struct {
+	next  slog.Handler
+	state *samplerState
+	// scope identifies attrs and groups added to this handler, so two
+	// loggers with different With attrs are sampled separately.
+	scope string
+}
+
+func NewSamplingHandler(next slog.Handler, cfg SamplingConfig) *SamplingHandler {
+	if cfg.MaxKeys <= 0 {
+		cfg.MaxKeys = DefaultSamplingMaxKeys
+	}
+
+	return &SamplingHandler{
+		next: next,
+		state: &samplerState{
+			cfg:     cfg,
+			now:     time.Now,
+			samples: map[string]*sampleCounter{},
+			dedup:   map[string]*dedupEntry{},
+		},
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.scope)
+	for _, a := range attrs {
+		b.WriteString(a.String())
+		b.WriteByte(' ')
+	}
+	return &SamplingHandler{next: h.next.WithAttrs(attrs), state: h.state, scope: b.String()}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{next: h.next.WithGroup(name), state: h.state, scope: h.scope + name + "."}
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= LevelError {
+		return h.dedupe(ctx, r)
+	}
+
+	pass, expired := h.sample(r)
+	if err := emit(expired); err != nil {
+		return err
+	}
+
+	if !pass {
+		h.state.sampled.Add(1)
+		return nil
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+// sample reports whether r passes, along with the dedup entries that expired
+// since the last sweep.
+func (h *SamplingHandler) sample(r slog.Record) (bool, []*dedupEntry) {
+	cfg := h.state.cfg
+	if cfg.Initial <= 0 || cfg.Interval <= 0 {
+		return true, nil
+	}
+
+	key := h.scope + r.Level.String() + "|" + r.Message
+	now := h.state.now()
+
+	h.state.mu.Lock()
+	defer h.state.mu.Unlock()
+
+	expired := h.state.sweep(now)
+
+	c, ok := h.state.samples[key]
+	if !ok && len(h.state.samples) >= cfg.MaxKeys {
+		return true, expired
+	}
+	if !ok || now.Sub(c.start) >= cfg.Interval {
+		c = &sampleCounter{start: now}
+		h.state.samples[key] = c
+	}
+
+	c.count++
+
+	if c.count <= cfg.Initial {
+		return true, expired
+	}
+
+	return cfg.Thereafter > 0 && (c.count-cfg.Initial)%cfg.Thereafter == 0, expired
+}
+
+func (h *SamplingHandler) dedupe(ctx context.Context, r slog.Record) error {
+	window := h.state.cfg.DedupWindow
+	if window <= 0 {
+		return h.next.Handle(ctx, r)
+	}
+
+	key := h.scope + recordKey(r)
+	now := h.state.now()
+
+	h.state.mu.Lock()
+	e, ok := h.state.dedup[key]
+	if ok && now.Sub(e.first) < window {
+		e.suppressed++
+		e.last = r.Clone()
+		e.ctx = context.WithoutCancel(ctx)
+		h.state.mu.Unlock()
+		h.state.deduplicated.Add(1)
+		return nil
+	}
+
+	suppressed := 0
+	if ok {
+		suppressed = e.suppressed
+		delete(h.state.dedup, key)
+	}
+	expired := h.state.sweep(now)
+	if len(h.state.dedup) < h.state.cfg.MaxKeys {
+		h.state.dedup[key] = &dedupEntry{first: now, handler: h.next}
+	}
+	h.state.mu.Unlock()
+
+	if err := emit(expired); err != nil {
+		return err
+	}
+
+	if suppressed > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int("repeated", suppressed))
+	}
+
+	return h.next.Handle(ctx, r)
+}
+
+// Flush writes the last suppressed record of every deduplicated error with
+// its repeat count, so repeats at shutdown are not lost. The records are
+// written with the context they were logged with, ctx only bounds Flush
+// itself.
+func (h *SamplingHandler) Flush(ctx context.Context) error {
+	h.state.mu.Lock()
+	pending := h.state.dedup
+	h.state.dedup = map[string]*dedupEntry{}
+	h.state.mu.Unlock()
+
+	for _, e := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := emit([]*dedupEntry{e}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sweep drops the counters whose interval or window has passed, at most once
+// per interval so the maps are not walked on every record. It returns the
+// dedup entries that still have suppressed records to report. The caller
+// holds mu.
+func (s *samplerState) sweep(now time.Time) []*dedupEntry {
+	every := s.cfg.Interval
+	if every <= 0 || (s.cfg.DedupWindow > 0 && s.cfg.DedupWindow < every) {
+		every = s.cfg.DedupWindow
+	}
+
+	if now.Sub(s.lastSweep) < every {
+		return nil
+	}
+
+	s.lastSweep = now
+
+	for key, c := range s.samples {
+		if now.Sub(c.start) >= s.cfg.Interval {
+			delete(s.samples, key)
+		}
+	}
+
+	var expired []*dedupEntry
+	for key, e := range s.dedup {
+		if now.Sub(e.first) >= s.cfg.DedupWindow {
+			delete(s.dedup, key)
+			if e.suppressed > 0 {
+				expired = append(expired, e)
+			}
+		}
+	}
+
+	return expired
+}
+
+// emit writes the repeat summary of every entry with suppressed records.
+func emit(entries []*dedupEntry) error {
+	for _, e := range entries {
+		if e.suppressed == 0 {
+			continue
+		}
+
+		if err := e.handler.Handle(e.ctx, e.summary()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordKey identifies an error by level, message and attrs, but not by time.
+func recordKey(r slog.Record) string {
+	var b strings.Builder
+	b.WriteString(r.Level.String())
+	b.WriteByte('|')
+	b.WriteString(r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		b.WriteByte(' ')
+		b.WriteString(a.String())
+		return true
+	})
+	return b.String()
+}
@@ -0,0 +1,246 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelDebug}), SamplingConfig{
+		Initial:    2,
+		Thereafter: 3,
+		Interval:   time.Minute,
+	})
+
+	now := time.Now()
+	h.state.now = func() time.Time { return now }
+
+	log := slog.New(h)
+	for i := 0; i < 8; i++ {
+		log.Debug("hot loop", "i", i)
+	}
+
+	// 2 initial, then every 3rd of the remaining 6.
+	if n := strings.Count(buf.String(), "hot loop"); n != 4 {
+		t.Errorf("expected 4 records, got %d:\n%s", n, buf.String())
+	}
+
+	if got := h.state.sampled.Load(); got != 4 {
+		t.Errorf("expected 4 sampled out, got %d", got)
+	}
+
+	now = now.Add(time.Minute)
+	log.Debug("hot loop", "i", 8)
+
+	if n := strings.Count(buf.String(), "hot loop"); n != 5 {
+		t.Errorf("expected a new interval to let the record through, got %d records", n)
+	}
+}
+
+func TestDedupRepeatedErrors(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(slog.NewTextHandler(&buf, nil), SamplingConfig{DedupWindow: time.Second})
+
+	now := time.Now()
+	h.state.now = func() time.Time { return now }
+
+	log := slog.New(h)
+	for i := 0; i < 5; i++ {
+		log.Error("db down", "host", "primary")
+	}
+
+	if n := strings.Count(buf.String(), "db down"); n != 1 {
+		t.Fatalf("expected 1 record inside the window, got %d", n)
+	}
+
+	now = now.Add(2 * time.Second)
+	log.Error("db down", "host", "primary")
+
+	if !strings.Contains(buf.String(), "repeated=4") {
+		t.Errorf("expected repeat count on next record, got:\n%s", buf.String())
+	}
+
+	log.Error("db down", "host", "primary")
+	log.Error("db down", "host", "primary")
+
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "repeated=2") {
+		t.Errorf("expected flush to report pending repeats, got:\n%s", buf.String())
+	}
+}
+
+func TestSamplerEvictsExpiredKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: LevelDebug}), SamplingConfig{
+		Initial:     1,
+		Interval:    time.Minute,
+		DedupWindow: time.Minute,
+		MaxKeys:     3,
+	})
+
+	now := time.Now()
+	h.state.now = func() time.Time { return now }
+
+	log := slog.New(h)
+	ctx := WithRequestID(context.Background(), "req-1")
+	log.ErrorContext(ctx, "db down")
+	log.ErrorContext(ctx, "db down")
+	for i := 0; i < 5; i++ {
+		log.Debug(fmt.Sprintf("message %d", i))
+		log.Debug(fmt.Sprintf("message %d", i))
+	}
+
+	// The cap stops tracking new messages, the last two pass unsampled.
+	if len(h.state.samples) != 3 {
+		t.Errorf("expected 3 tracked messages, got %d", len(h.state.samples))
+	}
+	if n := strings.Count(buf.String(), "message"); n != 7 {
+		t.Errorf("expected 3 sampled and 4 untracked records, got %d", n)
+	}
+
+	now = now.Add(time.Minute)
+	log.Debug("after the window")
+
+	if len(h.state.dedup) != 0 || len(h.state.samples) != 1 {
+		t.Errorf("expected expired keys to be evicted, got %d samples and %d dedup entries", len(h.state.samples), len(h.state.dedup))
+	}
+
+	// The evicted error still reports its repeats, with the request ID of
+	// the suppressed record.
+	h2 := NewSamplingHandler(ContextHandler{slog.NewTextHandler(&buf, nil)}, SamplingConfig{DedupWindow: time.Minute})
+	h2.state.now = h.state.now
+	log = slog.New(h2)
+	log.ErrorContext(ctx, "db down")
+	log.ErrorContext(ctx, "db down")
+	now = now.Add(time.Minute)
+	log.Error("unrelated")
+
+	if !strings.Contains(buf.String(), "repeated=1") || strings.Count(buf.String(), "request_id=req-1") != 2 {
+		t.Errorf("expected the evicted repeat with its request ID, got:\n%s", buf.String())
+	}
+}
+
+func TestDedupFlushKeepsContextIDs(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewSamplingHandler(ContextHandler{slog.NewTextHandler(&buf, nil)}, SamplingConfig{DedupWindow: time.Minute})
+
+	log := slog.New(h)
+	ctx, cancel := context.WithCancel(WithRequestID(context.Background(), "req-1"))
+	log.ErrorContext(ctx, "db down")
+	log.ErrorContext(ctx, "db down")
+	cancel()
+
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(buf.String(), "request_id=req-1"); n != 2 {
+		t.Errorf("expected the flushed repeat to keep the request ID, got:\n%s", buf.String())
+	}
+}
+
+type slowWriter struct {
+	mu      sync.Mutex
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriterDropsWhenFull(t *testing.T) {
+	sw := &slowWriter{release: make(chan struct{})}
+	aw, err := NewAsyncWriter(sw, 2, DropNewest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One record is taken by the writer goroutine, two fill the queue.
+	for i := 0; i < 10; i++ {
+		aw.Write([]byte("x\n"))
+	}
+
+	close(sw.release)
+
+	if err := aw.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	written := strings.Count(sw.buf.String(), "x")
+
+	if written+int(aw.Dropped()) != 10 || aw.Dropped() < 7 {
+		t.Errorf("expected 10 records split between written and dropped, got %d written, %d dropped", written, aw.Dropped())
+	}
+
+	if _, err := aw.Write([]byte("late")); err != ErrWriterClosed {
+		t.Errorf("expected ErrWriterClosed, got %v", err)
+	}
+}
+
+func TestAsyncWriterCloseHonoursDeadline(t *testing.T) {
+	sw := &slowWriter{release: make(chan struct{})}
+	defer close(sw.release)
+
+	aw, _ := NewAsyncWriter(sw, 1, Block)
+
+	// The first record is taken by the stuck writer, the second fills the
+	// queue and the third blocks.
+	aw.Write([]byte("x"))
+	aw.Write([]byte("x"))
+
+	blocked := make(chan error, 1)
+	go func() {
+		_, err := aw.Write([]byte("x"))
+		blocked <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := aw.Close(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Close took %v past its deadline", elapsed)
+	}
+
+	select {
+	case err := <-blocked:
+		if err != ErrWriterClosed {
+			t.Errorf("expected the blocked write to fail with ErrWriterClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("blocked write was not released by Close")
+	}
+}
+
+func TestAsyncWriterDropOldest(t *testing.T) {
+	sw := &slowWriter{release: make(chan struct{})}
+	close(sw.release)
+
+	aw, _ := NewAsyncWriter(sw, 4, DropOldest)
+	for i := 0; i < 100; i++ {
+		aw.Write([]byte("x"))
+	}
+	aw.Close(context.Background())
+
+	if got := strings.Count(sw.buf.String(), "x") + int(aw.Dropped()); got != 100 {
+		t.Errorf("expected every record written or dropped, got %d", got)
+	}
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	FormatText   = "text"
+	FormatJSON   = "json"
+	FormatLogfmt = "logfmt"
+)
+
+// SinkConfig describes one output. File is "stdout", "stderr" or a file name
+// inside Config.LogDir that is rotated with lumberjack. MinLevel and MaxLevel
+// bound the records the sink receives, a nil bound is open.
+type SinkConfig struct {
+	File     string      `json:"file"`
+	Format   string      `json:"format"`
+	MinLevel *slog.Level `json:"min_level,omitempty"`
+	MaxLevel *slog.Level `json:"max_level,omitempty"`
+}
+
+func levelPtr(l slog.Level) *slog.Level {
+	return &l
+}
+
+// DefaultSinks keeps the original layout: JSON on stdout plus one text file
+// per level band.
+func DefaultSinks() []SinkConfig {
+	return []SinkConfig{
+		{File: "stdout", Format: FormatJSON},
+		{File: "error.log", Format: FormatText, MinLevel: levelPtr(LevelError)},
+		{File: "info.log", Format: FormatText, MinLevel: levelPtr(LevelInfo), MaxLevel: levelPtr(LevelError - 1)},
+		{File: "debug.log", Format: FormatText, MaxLevel: levelPtr(LevelInfo - 1)},
+	}
+}
+
+func (c Config) writer(sink SinkConfig) io.Writer {
+	switch sink.File {
+	case "stdout":
+		return os.Stdout
+	case "stderr":
+		return os.Stderr
+	}
+
+	return &lumberjack.Logger{
+		Filename:   filepath.Join(c.LogDir, sink.File),
+		MaxSize:    c.MaxSize,
+		MaxBackups: c.MaxBackups,
+		MaxAge:     c.MaxAge,
+		Compress:   c.Compress,
+	}
+}
+
+// pipeline is everything setup builds from a Config and Shutdown tears down.
+type pipeline struct {
+	handler slog.Handler
+	sampler *SamplingHandler
+	writers []*AsyncWriter
+}
+
+func newFormatHandler(format string, w io.Writer) (slog.Handler, error) {
+	// Level filtering happens in leveledHandler and the sink bounds, the
+	// format handlers accept everything.
+	opts := &slog.HandlerOptions{
+		Level:     slog.Level(math.MinInt),
+		AddSource: true,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				// A user attribute can be called "time" too
+				if a.Value.Kind() == slog.KindTime {
+					a.Value = slog.StringValue(a.Value.Time().Format(time.RFC3339))
+				}
+			case slog.SourceKey:
+				if src, ok := a.Value.Any().(*slog.Source); ok {
+					a.Value = slog.StringValue(fmt.Sprintf("%s:%d", relativePath(src.File), src.Line))
+				}
+			}
+			return a
+		},
+	}
+
+	switch format {
+	case FormatText, "":
+		return NewCustomHandler(w, opts), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatLogfmt:
+		return slog.NewTextHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// newPipeline builds the handler chain for cfg: sampling, context IDs and
+// the fan-out to cfg.Sinks, optionally writing through AsyncWriters.
+func newPipeline(cfg Config) (*pipeline, error) {
+	sinks := cfg.Sinks
+	if len(sinks) == 0 {
+		sinks = DefaultSinks()
+	}
+
+	p := &pipeline{}
+	fanout := &fanoutHandler{}
+
+	for _, sc := range sinks {
+		w := cfg.writer(sc)
+
+		if cfg.Async.Enabled {
+			aw, err := NewAsyncWriter(w, cfg.Async.QueueSize, cfg.Async.DropPolicy)
+			if err != nil {
+				p.close()
+				return nil, err
+			}
+			p.writers = append(p.writers, aw)
+			w = aw
+		}
+
+		handler, err := newFormatHandler(sc.Format, w)
+		if err != nil {
+			p.close()
+			return nil, err
+		}
+
+		sh := sinkHandler{handler: handler, min: slog.Level(math.MinInt), max: slog.Level(math.MaxInt)}
+		if sc.MinLevel != nil {
+			sh.min = *sc.MinLevel
+		}
+		if sc.MaxLevel != nil {
+			sh.max = *sc.MaxLevel
+		}
+
+		fanout.sinks = append(fanout.sinks, sh)
+	}
+
+	p.handler = ContextHandler{fanout}
+
+	if cfg.Sampling.enabled() {
+		p.sampler = NewSamplingHandler(p.handler, cfg.Sampling)
+		p.handler = p.sampler
+	}
+
+	return p, nil
+}
+
+func (p *pipeline) close() {
+	for _, w := range p.writers {
+		w.Close(context.Background())
+	}
+}
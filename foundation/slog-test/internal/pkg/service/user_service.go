@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"slogtest/internal/pkg/logger"
 )
 
 type User struct {
@@ -12,30 +14,33 @@ type User struct {
 }
 
 type UserService struct {
+	log *slog.Logger
 }
 
 func NewUserService() *UserService {
-	return &UserService{}
+	return &UserService{
+		log: logger.NewLogger("service"),
+	}
 }
 
-func (s *UserService) CreateUser(user User) error {
+func (s *UserService) CreateUser(ctx context.Context, user User) error {
 
-	slog.Info("creating new user")
+	s.log.InfoContext(ctx, "creating new user")
 
 	if user.Username == "" {
-		slog.Error("failed to create user: username is required")
+		s.log.ErrorContext(ctx, "failed to create user: username is required")
 		return fmt.Errorf("username is required")
 	}
 
 	if user.Email == "" {
-		slog.Error("failed to create user: email is required")
+		s.log.ErrorContext(ctx, "failed to create user: email is required")
 		return fmt.Errorf("email is required")
 	}
 
-	slog.Debug("validating user data")
+	s.log.DebugContext(ctx, "validating user data")
 
-	slog.Error("failed here")
+	s.log.ErrorContext(ctx, "failed here")
 
-	slog.Info("user created successfully", "user", 123)
+	s.log.InfoContext(ctx, "user created successfully", "user", 123)
 	return fmt.Errorf("something went wrong")
 }
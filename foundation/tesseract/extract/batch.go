@@ -0,0 +1,70 @@
+package extract
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Job is one document of a batch.
+type Job struct {
+	Name string
+	Open func() (io.ReadCloser, error)
+}
+
+// FileJob is the Job for the file at path.
+func FileJob(path string) Job {
+	return Job{
+		Name: path,
+		Open: func() (io.ReadCloser, error) { return os.Open(path) },
+	}
+}
+
+// Batch extracts the jobs on Config.Workers goroutines and returns the
+// results in the order of jobs. Once ctx is done the running extractions
+// stop and the jobs not started yet fail with the context error.
+func (e *Extractor) Batch(ctx context.Context, jobs []Job) []Result {
+	results := make([]Result, len(jobs))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(e.cfg.Workers, len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = e.run(ctx, jobs[i])
+			}
+		}()
+	}
+
+	for i := range jobs {
+		select {
+		case next <- i:
+			continue
+		case <-ctx.Done():
+		}
+
+		for ; i < len(jobs); i++ {
+			results[i] = Result{Name: jobs[i].Name, Err: errors.Wrapf(ctx.Err(), "failed to read %s", jobs[i].Name)}
+		}
+		break
+	}
+	close(next)
+	wg.Wait()
+
+	return results
+}
+
+func (e *Extractor) run(ctx context.Context, job Job) Result {
+	r, err := job.Open()
+	if err != nil {
+		return Result{Name: job.Name, Err: err}
+	}
+	defer r.Close()
+
+	return e.Extract(ctx, job.Name, r)
+}
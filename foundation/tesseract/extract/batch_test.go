@@ -0,0 +1,167 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func bytesJob(name string, data []byte) Job {
+	return Job{Name: name, Open: func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}}
+}
+
+func TestBatchOrder(t *testing.T) {
+	ocr := &fakeOCR{delay: 20 * time.Millisecond}
+	e := New(Config{OCR: ocr, Workers: 4, OCRWorkers: 2})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	os.WriteFile(path, []byte("from disk"), 0o644)
+
+	jobs := []Job{FileJob(path), FileJob(filepath.Join(dir, "missing.txt"))}
+	for i := range 6 {
+		jobs = append(jobs, bytesJob(fmt.Sprintf("scan%d.png", i), pngHeader))
+	}
+
+	results := e.Batch(context.Background(), jobs)
+
+	if results[0].Content != "from disk" || results[0].Err != nil {
+		t.Errorf("results[0] = %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, os.ErrNotExist) {
+		t.Errorf("results[1].Err = %v", results[1].Err)
+	}
+	for i, r := range results[2:] {
+		if r.Name != fmt.Sprintf("scan%d.png", i) || r.Err != nil {
+			t.Errorf("results[%d] = %+v", i+2, r)
+		}
+	}
+	if peak := ocr.peak.Load(); peak > 2 {
+		t.Errorf("%d recognitions at once, OCRWorkers is 2", peak)
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	ocr := &fakeOCR{delay: time.Minute}
+	e := New(Config{OCR: ocr, Workers: 2})
+
+	jobs := make([]Job, 10)
+	for i := range jobs {
+		jobs[i] = bytesJob("scan.png", pngHeader)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	results := e.Batch(ctx, jobs)
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Batch returned after %v", d)
+	}
+
+	for i, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%d].Err = %v", i, r.Err)
+		}
+	}
+
+	// Recognitions that honor ctx stop instead of running on
+	time.Sleep(20 * time.Millisecond)
+	if n := ocr.running.Load(); n != 0 {
+		t.Errorf("%d recognitions still running", n)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler(New(Config{}), 1<<20))
+	defer server.Close()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, content := range map[string][]byte{"b.html": []byte("<p>two</p>"), "a.txt": []byte("one")} {
+		w, _ := form.CreateFormFile("file", name)
+		w.Write(content)
+	}
+	form.Close()
+
+	resp, err := http.Post(server.URL, form.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, got)
+	}
+	for _, want := range []string{`"content":"one"`, `"content":"two"`, `"kind":"html"`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("response %s misses %s", got, want)
+		}
+	}
+
+	resp, err = http.Post(server.URL+"?name=doc.pdf", "application/pdf", bytes.NewReader(makePDF("raw body")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(got), `"content":"raw body"`) {
+		t.Errorf("raw body response: %s", got)
+	}
+
+	resp, err = http.Post(server.URL, "text/plain", bytes.NewReader(make([]byte, 2<<20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(got), "too large") {
+		t.Errorf("oversized body: %s", got)
+	}
+
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d", resp.StatusCode)
+	}
+}
+
+func TestHandlerHOCR(t *testing.T) {
+	server := httptest.NewServer(Handler(New(Config{OCR: &fakeOCR{}}), 1<<20))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"?name=scan.png&format=hocr", "image/png", bytes.NewReader(noisyPNG(t, 40, 30)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(got), "<span class='ocrx_word' id='word_1_1' title='bbox 0 0 40 30; x_wconf 90'>text of PNG</span>") {
+		t.Errorf("status %d: %s", resp.StatusCode, got)
+	}
+
+	resp, err = http.Post(server.URL+"?name=a.txt&format=hocr", "text/plain", strings.NewReader("text"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("hocr of a text file: status %d", resp.StatusCode)
+	}
+}
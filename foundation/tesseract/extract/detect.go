@@ -0,0 +1,98 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+type Kind string
+
+const (
+	Unknown Kind = ""
+	Text    Kind = "text"
+	HTML    Kind = "html"
+	PDF     Kind = "pdf"
+	DOCX    Kind = "docx"
+	XLSX    Kind = "xlsx"
+	Image   Kind = "image"
+)
+
+var extensions = map[string]Kind{
+	".txt":  Text,
+	".text": Text,
+	".md":   Text,
+	".csv":  Text,
+	".tsv":  Text,
+	".log":  Text,
+	".json": Text,
+	".html": HTML,
+	".htm":  HTML,
+	".pdf":  PDF,
+	".docx": DOCX,
+	".xlsx": XLSX,
+	".png":  Image,
+	".jpg":  Image,
+	".jpeg": Image,
+	".gif":  Image,
+	".bmp":  Image,
+	".tif":  Image,
+	".tiff": Image,
+	".webp": Image,
+}
+
+// Detect returns the type of a document. Signatures in the content win,
+// then the extension of name, then a guess from the content.
+func Detect(name string, data []byte) Kind {
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return PDF
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		if kind := detectOffice(data); kind != Unknown {
+			return kind
+		}
+	}
+
+	contentType := http.DetectContentType(data)
+	if strings.HasPrefix(contentType, "image/") || isTIFF(data) {
+		return Image
+	}
+
+	if kind, ok := extensions[strings.ToLower(filepath.Ext(name))]; ok {
+		return kind
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "text/html"):
+		return HTML
+	case strings.HasPrefix(contentType, "text/"), utf8.Valid(data) && !bytes.ContainsRune(data, 0):
+		return Text
+	}
+	return Unknown
+}
+
+func detectOffice(data []byte) Kind {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Unknown
+	}
+
+	for _, f := range archive.File {
+		switch f.Name {
+		case "word/document.xml":
+			return DOCX
+		case "xl/workbook.xml":
+			return XLSX
+		}
+	}
+	return Unknown
+}
+
+// isTIFF checks the TIFF signatures, http.DetectContentType does not know
+// them.
+func isTIFF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*"))
+}
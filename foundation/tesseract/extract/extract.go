@@ -0,0 +1,200 @@
+// Package extract reads the text out of documents: plain text, HTML, the
+// text layer of PDFs, DOCX and XLSX files, and images through an OCR
+// engine.
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/errors"
+	"github.com/premgowda/98/tesseract/ocr"
+)
+
+var (
+	ErrTooLarge    = errors.New("file too large")
+	ErrUnsupported = errors.New("unsupported file type")
+	ErrNoOCR       = errors.New("no OCR engine configured")
+	ErrNoText      = errors.New("pdf has no text layer")
+)
+
+type Config struct {
+	// OCR reads images, without it images fail with ErrNoOCR.
+	OCR OCR
+	// MaxFileSize bounds every document and every part unpacked from
+	// one. Defaults to 50MB.
+	MaxFileSize int64
+	// MaxImageSize bounds the images given to OCR, larger ones are
+	// downscaled until they fit. Defaults to 10MB.
+	MaxImageSize int64
+	// Preprocess are the steps run on images before OCR. MaxPixels
+	// defaults to 25 million, MaxBytes is MaxImageSize.
+	Preprocess ocr.Preprocess
+	// Timeout bounds the extraction of one document. Defaults to 15s.
+	Timeout time.Duration
+	// Workers is the number of documents Batch extracts at once.
+	// Defaults to GOMAXPROCS.
+	Workers int
+	// OCRWorkers is the number of images recognized at once, OCR uses
+	// far more memory and CPU than the other formats. Defaults to half
+	// of GOMAXPROCS.
+	OCRWorkers int
+}
+
+type Extractor struct {
+	cfg Config
+
+	// ocrSlots bounds the recognitions in flight, abandoned ones included.
+	ocrSlots chan struct{}
+}
+
+func New(cfg Config) *Extractor {
+	if cfg.MaxFileSize <= 0 {
+		cfg.MaxFileSize = 50 * 1024 * 1024
+	}
+	if cfg.MaxImageSize <= 0 {
+		cfg.MaxImageSize = 10 * 1024 * 1024
+	}
+	if cfg.Preprocess.MaxPixels <= 0 {
+		cfg.Preprocess.MaxPixels = 25_000_000
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 15 * time.Second
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.GOMAXPROCS(0)
+	}
+	if cfg.OCRWorkers <= 0 {
+		cfg.OCRWorkers = max(1, runtime.GOMAXPROCS(0)/2)
+	}
+
+	return &Extractor{cfg: cfg, ocrSlots: make(chan struct{}, cfg.OCRWorkers)}
+}
+
+type Result struct {
+	Name    string
+	Kind    Kind
+	Content string
+	// OCR holds the lines and words of images, with their positions.
+	OCR      *ocr.Page
+	Err      error
+	Duration time.Duration
+}
+
+func (r Result) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Name       string    `json:"name"`
+		Kind       Kind      `json:"kind,omitempty"`
+		Content    string    `json:"content"`
+		OCR        *ocr.Page `json:"ocr,omitempty"`
+		Error      string    `json:"error,omitempty"`
+		DurationMS int64     `json:"duration_ms"`
+	}{
+		Name:       r.Name,
+		Kind:       r.Kind,
+		Content:    r.Content,
+		OCR:        r.OCR,
+		DurationMS: r.Duration.Milliseconds(),
+	}
+	if r.Err != nil {
+		out.Error = r.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// Extract reads the document from r and returns its text. name is used
+// for the file type when the content does not give it away.
+func (e *Extractor) Extract(ctx context.Context, name string, r io.Reader) Result {
+	start := time.Now()
+	result := Result{Name: name}
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+
+	data, err := readAll(ctx, r, e.cfg.MaxFileSize)
+	if err == nil {
+		result.Kind = Detect(name, data)
+		result.Content, result.OCR, err = e.extract(ctx, result.Kind, data)
+	}
+
+	if err != nil {
+		result.Err = errors.Wrapf(err, "failed to read %s", name)
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// ExtractFile is Extract for the file at path.
+func (e *Extractor) ExtractFile(ctx context.Context, path string) Result {
+	file, err := os.Open(path)
+	if err != nil {
+		return Result{Name: path, Err: err}
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Size() > e.cfg.MaxFileSize {
+		return Result{Name: path, Err: errors.Wrapf(ErrTooLarge, "%s is %s", path, humanize.Bytes(uint64(info.Size())))}
+	}
+
+	return e.Extract(ctx, path, file)
+}
+
+func (e *Extractor) extract(ctx context.Context, kind Kind, data []byte) (string, *ocr.Page, error) {
+	var text string
+	var err error
+
+	switch kind {
+	case Text:
+		return readText(data), nil, nil
+	case HTML:
+		text, err = readHTML(ctx, data)
+	case PDF:
+		text, err = readPDF(ctx, data)
+	case DOCX:
+		text, err = readDOCX(ctx, data, e.cfg.MaxFileSize)
+	case XLSX:
+		text, err = readXLSX(ctx, data, e.cfg.MaxFileSize)
+	case Image:
+		page, err := e.readImage(ctx, data)
+		if err != nil {
+			return "", nil, err
+		}
+		return page.Text(), page, nil
+	default:
+		return "", nil, ErrUnsupported
+	}
+	return text, nil, err
+}
+
+// readAll reads r up to limit bytes, stopping as soon as ctx is done.
+func readAll(ctx context.Context, r io.Reader, limit int64) ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(ctxReader{ctx, r}, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > limit {
+		return nil, errors.Wrapf(ErrTooLarge, "over %s", humanize.Bytes(uint64(limit)))
+	}
+	return buf.Bytes(), nil
+}
+
+// ctxReader fails reads once ctx is done, so the parsers reading through
+// it stop with ctx.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
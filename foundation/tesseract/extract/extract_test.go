@@ -0,0 +1,240 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/premgowda/98/tesseract/ocr"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Kind
+	}{
+		{"a.pdf", makePDF("x"), PDF},
+		{"renamed.txt", makePDF("x"), PDF},
+		{"a.docx", makeDOCX(t), DOCX},
+		{"noext", makeXLSX(t), XLSX},
+		{"scan.bin", pngHeader, Image},
+		{"scan.tif", []byte("II*\x00rest"), Image},
+		{"page.html", []byte("just text"), HTML},
+		{"page", []byte("<!DOCTYPE html><html><p>x</p></html>"), HTML},
+		{"notes.md", []byte("<html> in a markdown file"), Text},
+		{"README", []byte("plain words"), Text},
+		{"blob", []byte{0, 1, 2, 0xff}, Unknown},
+		{"other.zip", makeZip(t, map[string]string{"a.txt": "x"}), Unknown},
+	}
+
+	for _, tt := range tests {
+		if got := Detect(tt.name, tt.data); got != tt.want {
+			t.Errorf("Detect(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func extractString(t *testing.T, e *Extractor, name string, data []byte) Result {
+	t.Helper()
+	return e.Extract(context.Background(), name, bytes.NewReader(data))
+}
+
+func TestExtractFormats(t *testing.T) {
+	e := New(Config{})
+
+	tests := []struct {
+		name string
+		data []byte
+		kind Kind
+		want string
+	}{
+		{"a.txt", []byte("\xef\xbb\xbfhello\nworld"), Text, "hello\nworld"},
+		{"a.html", []byte(`<html><head><title>T</title><style>p{}</style><script>var x</script></head>
+<body><h1>Head  line</h1><p>one
+two</p><ul><li>a</li><li>b</li></ul><table><tr><td>x</td><td>y</td></tr></table><pre>  keep
+  this</pre></body></html>`), HTML, "T\nHead line\none two\na\nb\nx\ty\nkeep\nthis"},
+		{"a.pdf", makePDF("Hello PDF", "Second page"), PDF, "Hello PDF\n\nSecond page"},
+		{"a.docx", makeDOCX(t), DOCX, "Quarterly report\nName\tTotal\nLast line"},
+		{"a.xlsx", makeXLSX(t), XLSX, "## Totals\nsum\t2.5\n\n## Raw\nitem\tprice\ntea\t2.5\tTRUE"},
+		{"sparse.xlsx", makeSparseXLSX(t), XLSX, "## Sparse\na\t\tc\n\tb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := extractString(t, e, tt.name, tt.data)
+			if r.Err != nil {
+				t.Fatal(r.Err)
+			}
+			if r.Kind != tt.kind {
+				t.Errorf("kind = %q, want %q", r.Kind, tt.kind)
+			}
+			if r.Content != tt.want {
+				t.Errorf("content = %q, want %q", r.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	e := New(Config{MaxFileSize: 1024})
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"big.txt", bytes.Repeat([]byte("a"), 1025), ErrTooLarge},
+		{"blob", []byte{0, 1, 2}, ErrUnsupported},
+		{"scan.png", pngHeader, ErrNoOCR},
+		{"empty.pdf", makePDF(""), ErrNoText},
+		{"bomb.docx", makeZip(t, map[string]string{"word/document.xml": "<w>" + strings.Repeat(" ", 4096) + "</w>"}), ErrTooLarge},
+	}
+
+	for _, tt := range tests {
+		if r := extractString(t, e, tt.name, tt.data); !errors.Is(r.Err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, r.Err, tt.want)
+		}
+	}
+
+	if r := extractString(t, e, "broken.pdf", []byte("%PDF-1.4 nothing else")); r.Err == nil {
+		t.Error("broken.pdf: expected an error")
+	}
+}
+
+// fakeOCR finds one word covering the whole image, after delay unless
+// ctx ends first. stubborn ignores ctx, like tesseract in the middle of a
+// page.
+type fakeOCR struct {
+	delay    time.Duration
+	stubborn bool
+	running  atomic.Int32
+	peak     atomic.Int32
+	// sizes records the byte size of every image recognized.
+	sizes sync.Map
+}
+
+func (f *fakeOCR) Recognize(ctx context.Context, img []byte) (*ocr.Page, error) {
+	n := f.running.Add(1)
+	defer f.running.Add(-1)
+	for {
+		peak := f.peak.Load()
+		if n <= peak || f.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	f.sizes.Store(len(img), true)
+
+	if f.stubborn {
+		time.Sleep(f.delay)
+		return &ocr.Page{}, nil
+	}
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var width, height int
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(img)); err == nil {
+		width, height = cfg.Width, cfg.Height
+	}
+	word := ocr.Word{Text: "text of " + string(img[1:4]), Box: ocr.Box{X1: width, Y1: height}, Confidence: 90}
+	return ocr.NewPage(width, height, []ocr.LayoutWord{{Word: word, Block: 1, Paragraph: 1, Line: 1}}), nil
+}
+
+// noisyPNG encodes an image that compresses badly.
+func noisyPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.Uint32())
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestImageOCR(t *testing.T) {
+	fake := &fakeOCR{}
+	e := New(Config{OCR: fake})
+
+	r := extractString(t, e, "scan.png", pngHeader)
+	if r.Err != nil || r.Kind != Image || r.Content != "text of PNG" || r.OCR == nil {
+		t.Errorf("result = %+v", r)
+	}
+}
+
+func TestImageDownscale(t *testing.T) {
+	fake := &fakeOCR{}
+	e := New(Config{OCR: fake, MaxImageSize: 20_000})
+
+	data := noisyPNG(t, 400, 300)
+	r := extractString(t, e, "scan.png", data)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+
+	fake.sizes.Range(func(size, _ any) bool {
+		if size.(int) > 20_000 {
+			t.Errorf("engine got %d bytes, MaxImageSize is 20000", size)
+		}
+		return true
+	})
+
+	// Positions are reported in the original image
+	page := r.OCR
+	if page.Width != 400 || page.Height != 300 {
+		t.Errorf("page is %dx%d", page.Width, page.Height)
+	}
+	if box := page.Lines[0].Words[0].Box; box.X1 < 390 || box.X1 > 410 || box.Y1 < 290 || box.Y1 > 310 {
+		t.Errorf("word box %+v not scaled back", box)
+	}
+}
+
+func TestImageTimeout(t *testing.T) {
+	ocr := &fakeOCR{delay: 300 * time.Millisecond, stubborn: true}
+	e := New(Config{OCR: ocr, OCRWorkers: 1, Timeout: 20 * time.Millisecond})
+
+	start := time.Now()
+	r := extractString(t, e, "scan.png", pngHeader)
+	if !errors.Is(r.Err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", r.Err)
+	}
+	if d := time.Since(start); d > 200*time.Millisecond {
+		t.Errorf("Extract returned after %v, not at the deadline", d)
+	}
+
+	// The abandoned recognition keeps the only slot, the next image waits
+	// for it instead of running alongside
+	r = extractString(t, e, "scan.png", pngHeader)
+	if !errors.Is(r.Err, context.DeadlineExceeded) || ocr.peak.Load() != 1 {
+		t.Errorf("err = %v, peak = %d", r.Err, ocr.peak.Load())
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	data, err := json.Marshal(Result{Name: "a.png", Kind: Image, Err: ErrNoOCR, Duration: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"a.png","kind":"image","content":"","error":"no OCR engine configured","duration_ms":1500}`
+	if string(data) != want {
+		t.Errorf("got %s\nwant %s", data, want)
+	}
+}
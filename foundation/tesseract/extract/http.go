@@ -0,0 +1,107 @@
+package extract
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Handler serves POST requests with the documents to extract and answers
+// with {"results": [...]} in the order they were sent. A multipart form
+// is a batch of every file in it, any other body is one document named
+// by the name query parameter. Requests over maxRequest bytes fail.
+//
+// With format=hocr the answer is the hOCR page of the one image sent
+// instead.
+//
+// Extraction stops when the client disconnects.
+func Handler(e *Extractor, maxRequest int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		hocr := r.URL.Query().Get("format") == "hocr"
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequest)
+
+		var jobs []Job
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := r.ParseMultipartForm(32 << 20); err != nil {
+				http.Error(w, err.Error(), requestErrorStatus(err))
+				return
+			}
+			defer r.MultipartForm.RemoveAll()
+
+			for _, field := range slices.Sorted(maps.Keys(r.MultipartForm.File)) {
+				for _, fh := range r.MultipartForm.File[field] {
+					jobs = append(jobs, uploadJob(fh))
+				}
+			}
+		} else {
+			jobs = append(jobs, Job{
+				Name: r.URL.Query().Get("name"),
+				Open: func() (io.ReadCloser, error) { return r.Body, nil },
+			})
+		}
+
+		if len(jobs) == 0 {
+			http.Error(w, "no files in the form", http.StatusBadRequest)
+			return
+		}
+		if hocr && len(jobs) > 1 {
+			http.Error(w, "hocr takes one image", http.StatusBadRequest)
+			return
+		}
+
+		results := e.Batch(r.Context(), jobs)
+		if r.Context().Err() != nil {
+			// The client is gone, nobody reads the answer
+			return
+		}
+
+		if hocr {
+			writeHOCR(w, results[0])
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Results []Result `json:"results"`
+		}{results})
+	})
+}
+
+func writeHOCR(w http.ResponseWriter, result Result) {
+	switch {
+	case result.Err != nil:
+		http.Error(w, result.Err.Error(), http.StatusUnprocessableEntity)
+	case result.OCR == nil:
+		http.Error(w, "hocr needs an image, got "+string(result.Kind), http.StatusUnprocessableEntity)
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, result.OCR.HOCR())
+	}
+}
+
+func uploadJob(fh *multipart.FileHeader) Job {
+	return Job{
+		Name: fh.Filename,
+		Open: func() (io.ReadCloser, error) { return fh.Open() },
+	}
+}
+
+func requestErrorStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
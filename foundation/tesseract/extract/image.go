@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"context"
+
+	"github.com/premgowda/98/tesseract/ocr"
+)
+
+// OCR recognizes the text in an image.
+//
+// Engines should stop when ctx is done. Those that cannot, like an in
+// process tesseract in the middle of a page, still keep their OCRWorkers
+// slot until they return, so abandoned recognitions cannot pile up.
+type OCR interface {
+	Recognize(ctx context.Context, image []byte) (*ocr.Page, error)
+}
+
+// readImage preprocesses the image and recognizes it. The boxes of the
+// page are positions in the original image, whatever size the engine
+// was given.
+func (e *Extractor) readImage(ctx context.Context, data []byte) (*ocr.Page, error) {
+	if e.cfg.OCR == nil {
+		return nil, ErrNoOCR
+	}
+
+	select {
+	case e.ocrSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	type recognized struct {
+		page *ocr.Page
+		err  error
+	}
+	done := make(chan recognized, 1)
+
+	go func() {
+		defer func() { <-e.ocrSlots }()
+		page, err := e.recognize(ctx, data)
+		done <- recognized{page, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.page, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (e *Extractor) recognize(ctx context.Context, data []byte) (*ocr.Page, error) {
+	prep := e.cfg.Preprocess
+	prep.MaxBytes = e.cfg.MaxImageSize
+
+	prepared, err := ocr.Prepare(ctx, data, prep)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := e.cfg.OCR.Recognize(ctx, prepared.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	page.Scale(1 / prepared.Scale)
+	if prepared.Width > 0 {
+		page.Width, page.Height = prepared.Width, prepared.Height
+	}
+	page.Skew = prepared.Skew
+	return page, nil
+}
@@ -0,0 +1,305 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// readDOCX returns the paragraphs of word/document.xml, one per line.
+func readDOCX(ctx context.Context, data []byte, limit int64) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	inText := false
+
+	err = walkXML(ctx, archive, "word/document.xml", limit, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return tidy(b.String()), nil
+}
+
+// readXLSX returns every sheet of a workbook under its name, a row per
+// line with the cells separated by tabs.
+func readXLSX(ctx context.Context, data []byte, limit int64) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	shared, err := sharedStrings(ctx, archive, limit)
+	if err != nil {
+		return "", err
+	}
+
+	sheets, err := workbookSheets(ctx, archive, limit)
+	if err != nil {
+		return "", err
+	}
+
+	var out []string
+	for _, sheet := range sheets {
+		rows, err := sheetRows(ctx, archive, sheet.path, shared, limit)
+		if err != nil {
+			return "", errors.Wrapf(err, "sheet %s", sheet.name)
+		}
+		out = append(out, "## "+sheet.name+"\n"+strings.Join(rows, "\n"))
+	}
+	return strings.Join(out, "\n\n"), nil
+}
+
+func sharedStrings(ctx context.Context, archive *zip.Reader, limit int64) ([]string, error) {
+	var (
+		shared []string
+		cur    strings.Builder
+		inText bool
+	)
+
+	err := walkXML(ctx, archive, "xl/sharedStrings.xml", limit, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur.Reset()
+			case "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				shared = append(shared, cur.String())
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				cur.Write(t)
+			}
+		}
+	})
+	if errors.Is(err, errNoPart) {
+		// Workbooks holding only numbers have no shared strings
+		return nil, nil
+	}
+	return shared, err
+}
+
+type sheet struct {
+	name, path string
+}
+
+// workbookSheets returns the sheets in workbook order, with the part
+// holding each one taken from the workbook relationships.
+func workbookSheets(ctx context.Context, archive *zip.Reader, limit int64) ([]sheet, error) {
+	targets := make(map[string]string)
+	err := walkXML(ctx, archive, "xl/_rels/workbook.xml.rels", limit, func(tok xml.Token) {
+		if t, ok := tok.(xml.StartElement); ok && t.Name.Local == "Relationship" {
+			targets[attr(t, "Id")] = attr(t, "Target")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sheets []sheet
+	err = walkXML(ctx, archive, "xl/workbook.xml", limit, func(tok xml.Token) {
+		t, ok := tok.(xml.StartElement)
+		if !ok || t.Name.Local != "sheet" {
+			return
+		}
+
+		target := targets[attr(t, "id")]
+		if target == "" {
+			return
+		}
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		sheets = append(sheets, sheet{name: attr(t, "name"), path: target})
+	})
+	return sheets, err
+}
+
+func sheetRows(ctx context.Context, archive *zip.Reader, part string, shared []string, limit int64) ([]string, error) {
+	var (
+		rows     []string
+		row      []string
+		cellType string
+		value    strings.Builder
+		inValue  bool
+	)
+
+	err := walkXML(ctx, archive, part, limit, func(tok xml.Token) {
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				row = row[:0]
+			case "c":
+				cellType = attr(t, "t")
+				value.Reset()
+				// Empty cells are left out of the XML, pad up to the
+				// column named in the reference so the others keep
+				// their place.
+				if col, ok := cellColumn(attr(t, "r")); ok {
+					for len(row) < col {
+						row = append(row, "")
+					}
+				}
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				row = append(row, cellText(cellType, value.String(), shared))
+			case "row":
+				rows = append(rows, strings.TrimRight(strings.Join(row, "\t"), "\t"))
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	})
+	return rows, err
+}
+
+// maxColumns is the column count of a worksheet, XFD.
+const maxColumns = 16384
+
+// cellColumn returns the zero-based column of a cell reference like "C12".
+func cellColumn(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for n < len(ref) && ref[n] >= 'A' && ref[n] <= 'Z' {
+		col = col*26 + int(ref[n]-'A'+1)
+		if col > maxColumns {
+			return 0, false
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return col - 1, true
+}
+
+func cellText(cellType, value string, shared []string) string {
+	switch cellType {
+	case "s":
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "b":
+		if value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	default:
+		return value
+	}
+}
+
+func attr(t xml.StartElement, local string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+var errNoPart = errors.New("part missing from archive")
+
+// walkXML calls fn for every token of the XML part name. Parts are
+// unpacked up to limit bytes, a few KB of zip can expand to gigabytes.
+func walkXML(ctx context.Context, archive *zip.Reader, name string, limit int64, fn func(xml.Token)) error {
+	f, err := archive.Open(name)
+	if err != nil {
+		return errors.Wrap(errNoPart, name)
+	}
+	defer f.Close()
+
+	r := &limitedReader{r: ctxReader{ctx, f}, left: limit}
+	dec := xml.NewDecoder(r)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if r.err != nil {
+				return r.err
+			}
+			return errors.Wrap(err, name)
+		}
+		fn(tok)
+	}
+}
+
+// limitedReader is io.LimitReader failing with ErrTooLarge instead of
+// ending the data early.
+type limitedReader struct {
+	r    io.Reader
+	left int64
+	err  error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.left <= 0 {
+		l.err = ErrTooLarge
+		return 0, l.err
+	}
+	if int64(len(p)) > l.left {
+		p = p[:l.left]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if err != nil && err != io.EOF {
+		l.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,58 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/pkg/errors"
+)
+
+// readPDF returns the text layer of a PDF, page by page. Scanned PDFs
+// have none and fail with ErrNoText.
+func readPDF(ctx context.Context, data []byte) (text string, err error) {
+	// The pdf package panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", errors.Errorf("malformed pdf: %v", r)
+		}
+	}()
+
+	doc, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	var pages []string
+	fonts := make(map[string]*pdf.Font)
+
+	for i := 1; i <= doc.NumPage(); i++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		page := doc.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+
+		content, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", errors.Wrapf(err, "page %d", i)
+		}
+		pages = append(pages, strings.TrimSpace(content))
+	}
+
+	text = strings.TrimSpace(strings.Join(pages, "\n\n"))
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
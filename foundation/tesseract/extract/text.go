@@ -0,0 +1,138 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func readText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return strings.ToValidUTF8(string(data), "�")
+}
+
+// skipped holds the elements whose text is not part of the page.
+var skipped = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+}
+
+// blocks holds the elements that start on a new line.
+var blocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true,
+	atom.Br: true, atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Table: true,
+	atom.Title: true, atom.Tr: true, atom.Ul: true,
+}
+
+func readHTML(ctx context.Context, data []byte) (string, error) {
+	z := html.NewTokenizer(ctxReader{ctx, bytes.NewReader(data)})
+
+	var b strings.Builder
+	skip, pre := 0, 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return "", err
+			}
+			return tidy(b.String()), nil
+
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			tag := atom.Lookup(name)
+			start, end := tt == html.StartTagToken, tt == html.EndTagToken
+
+			switch {
+			case skipped[tag] && start:
+				skip++
+			case skipped[tag] && end:
+				skip = max(0, skip-1)
+			case tag == atom.Pre && start:
+				pre++
+			case tag == atom.Pre && end:
+				pre = max(0, pre-1)
+			case (tag == atom.Td || tag == atom.Th) && start:
+				b.WriteByte('\t')
+			}
+
+			if blocks[tag] {
+				newLine(&b)
+			}
+
+		case html.TextToken:
+			if skip > 0 {
+				continue
+			}
+			text := string(z.Text())
+			if pre == 0 {
+				text = collapseSpace(text)
+				if text == " " && atLineStart(&b) {
+					continue
+				}
+			}
+			b.WriteString(text)
+		}
+	}
+}
+
+func atLineStart(b *strings.Builder) bool {
+	return b.Len() == 0 || b.String()[b.Len()-1] == '\n'
+}
+
+func newLine(b *strings.Builder) {
+	if !atLineStart(b) {
+		b.WriteByte('\n')
+	}
+}
+
+// collapseSpace turns every run of white space into one space, the way
+// a browser renders text.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// tidy trims the lines of s and keeps at most one empty line in a row.
+func tidy(s string) string {
+	var lines []string
+	blank := true
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimFunc(line, unicode.IsSpace)
+		if line == "" {
+			if !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
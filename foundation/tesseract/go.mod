@@ -4,6 +4,9 @@ go 1.23.4
 
 require (
 	github.com/dustin/go-humanize v1.0.1
+	github.com/ledongthuc/pdf v0.0.0-20240201131950-da5b75280b06
 	github.com/otiai10/gosseract/v2 v2.4.1
 	github.com/pkg/errors v0.9.1
+	golang.org/x/image v0.24.0
+	golang.org/x/net v0.34.0
 )
@@ -1,137 +1,186 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/dustin/go-humanize"
-	"github.com/otiai10/gosseract/v2"
-	"github.com/pkg/errors"
+	"github.com/premgowda/98/tesseract/extract"
+	"github.com/premgowda/98/tesseract/ocr"
+	"github.com/premgowda/98/tesseract/ocr/tesseract"
 )
 
 var MaxContentReadTimeout = time.Duration(15) * time.Second
-var MaxImageSize int64 = 10 * 1024 * 1024 // 40MB
+var MaxImageSize int64 = 10 * 1024 * 1024 // 10MB
 
 func main() {
-
-	fPath := "./40mb.jpg"
-
-	_, err := readContentWithTimeout(fPath)
-
-	if err != nil {
-		slog.Error(fmt.Sprintf("readContentWithTimeout failed:%v", err))
+	addr := flag.String("http", "", "serve POST /extract on this address instead of reading files")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "documents extracted at once")
+	ocrWorkers := flag.Int("ocr-workers", max(1, runtime.GOMAXPROCS(0)/2), "images recognized at once")
+	timeout := flag.Duration("timeout", MaxContentReadTimeout, "time limit for one document")
+	maxFile := flag.String("max-file", "50MB", "largest document accepted")
+	maxImage := flag.String("max-image", humanize.IBytes(uint64(MaxImageSize)), "largest image given to OCR")
+	maxRequest := flag.String("max-request", "200MB", "largest HTTP request body")
+	lang := flag.String("lang", "eng", "tesseract languages, like eng+deu")
+	psm := flag.Int("psm", 0, "tesseract page segmentation mode, 0 for the default")
+	oem := flag.String("oem", "default", "tesseract engine mode: default, legacy, lstm or legacy+lstm")
+	dpi := flag.Int("dpi", 0, "resolution of images without one in their metadata")
+	whitelist := flag.String("whitelist", "", "only recognize these characters")
+	grayscale := flag.Bool("grayscale", false, "convert images to gray before OCR")
+	threshold := flag.Bool("threshold", false, "turn images black and white before OCR")
+	deskew := flag.Bool("deskew", false, "straighten rotated scans before OCR")
+	maxPixels := flag.Int("max-pixels", 25_000_000, "downscale larger images before OCR")
+	hocrDir := flag.String("hocr", "", "write the hOCR of every image to this directory")
+	asJSON := flag.Bool("json", false, "print the results as JSON, one per line")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] file...\n       %s [flags] -http :8080\n\n", os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	sizes := map[string]*string{"max-file": maxFile, "max-image": maxImage, "max-request": maxRequest}
+	limits := make(map[string]int64, len(sizes))
+	for name, value := range sizes {
+		n, err := humanize.ParseBytes(*value)
+		if err != nil {
+			slog.Error(fmt.Sprintf("-%s: %v", name, err))
+			os.Exit(2)
+		}
+		limits[name] = int64(n)
 	}
 
-	// slog.Info("Data", "content", data)
-
-}
-
-func readImageFile(fPath string) (string, error) {
-	time.Sleep(5 * time.Second)
-	slog.Info(fmt.Sprintf("reading fpath:%s, type:image", fPath))
-
-	file, err := os.Open(fPath)
+	engineMode, err := ocr.ParseEngineMode(*oem)
 	if err != nil {
-		slog.Error(fmt.Sprintf("readImageFile:open file error:%v", err))
-		return "", errors.Wrapf(err, "failed to open image file:%s", fPath)
+		slog.Error(fmt.Sprintf("-oem: %v", err))
+		os.Exit(2)
 	}
-	defer file.Close()
-	fileInfo, _ := file.Stat()
 
-	if fileInfo.Size() > MaxImageSize {
-		slog.Error(fmt.Sprintf("readImageFile:file size too large: %s", humanize.Bytes(uint64(fileInfo.Size()))))
-		return "", errors.Wrapf(err, "file size too large:%s", fPath)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	extractor := extract.New(extract.Config{
+		OCR: tesseract.New(ocr.Options{
+			Languages: strings.Split(*lang, "+"),
+			PSM:       *psm,
+			OEM:       engineMode,
+			DPI:       *dpi,
+			Whitelist: *whitelist,
+		}),
+		Preprocess: ocr.Preprocess{
+			Grayscale: *grayscale,
+			Threshold: *threshold,
+			Deskew:    *deskew,
+			MaxPixels: *maxPixels,
+		},
+		MaxFileSize:  limits["max-file"],
+		MaxImageSize: limits["max-image"],
+		Timeout:      *timeout,
+		Workers:      *workers,
+		OCRWorkers:   *ocrWorkers,
+	})
+
+	if *addr != "" {
+		if err := serve(ctx, *addr, extract.Handler(extractor, limits["max-request"])); err != nil {
+			slog.Error(fmt.Sprintf("serve failed:%v", err))
+			os.Exit(1)
+		}
+		return
 	}
 
-	data := make([]byte, fileInfo.Size())
-
-	buffer := bufio.NewReader(file)
-	_, err = buffer.Read(data)
-	if err != nil {
-		slog.Error(fmt.Sprintf("readImageFile:read file error:%v", err))
-		return "", errors.Wrapf(err, "failed to read image file:%s", fPath)
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	client := gosseract.NewClient()
-	defer client.Close()
-	err = client.SetImageFromBytes(data)
-	if err != nil {
-		slog.Error(fmt.Sprintf("readImageFile:image bytes read error:%v", err))
-		return "", errors.Wrapf(err, "failed to read image bytes:%s", fPath)
-	}
-	text, err := client.Text()
-	if err != nil {
-		slog.Error(fmt.Sprintf("readImageFile:text extract failed:%v", err))
-		return "", errors.Wrapf(err, "failed to extract image text:%s", fPath)
+	jobs := make([]extract.Job, flag.NArg())
+	for i, path := range flag.Args() {
+		jobs[i] = extract.FileJob(path)
 	}
 
-	gosseract.ClearPersistentCache()
-
-	slog.Info(fmt.Sprintf("read fpath:%s, type:image", fPath))
-	slog.Debug(fmt.Sprintf("readImageFile:%s, content:%s", fPath, text))
-
-	return text, nil
-}
-
-type ContentResult struct {
-	Content string
-	Error   error
-}
-
-func readContent(fPath string) ContentResult {
-	slog.Info(fmt.Sprintf("processing file:%s read request", fPath))
+	if *hocrDir != "" {
+		if err := os.MkdirAll(*hocrDir, 0o755); err != nil {
+			slog.Error(fmt.Sprintf("-hocr: %v", err))
+			os.Exit(1)
+		}
+	}
 
-	var err error
-	var result ContentResult
+	failed := 0
+	encoder := json.NewEncoder(os.Stdout)
 
-	// record time taken
-	startTime := time.Now()
-	fType := strings.TrimPrefix(filepath.Ext(fPath), ".")
-	defer func(err error) {
-		timeTaken := time.Since(startTime).Seconds()
-		if err == nil {
-			slog.Info(fmt.Sprintf("fpath:%s, type:%s, read took %.2fs", fPath, fType, timeTaken))
+	hocrNames := map[string]bool{}
+	for _, result := range extractor.Batch(ctx, jobs) {
+		if result.Err != nil {
+			failed++
+			slog.Error(fmt.Sprintf("readContent failed:%v", result.Err))
 		} else {
-			slog.Info(fmt.Sprintf("fpath:%s, type:%s, read took %.2fs with err=%v", fPath, fType, timeTaken, result.Error))
+			slog.Info(fmt.Sprintf("fpath:%s, type:%s, read took %.2fs", result.Name, result.Kind, result.Duration.Seconds()))
 		}
-	}(err)
 
-	result.Content, err = readImageFile(fPath)
-	if err != nil {
-		result.Error = errors.Wrapf(err, "failed to read file:%s", fPath)
+		if *hocrDir != "" && result.OCR != nil {
+			if err := writeHOCR(*hocrDir, result, hocrNames); err != nil {
+				failed++
+				slog.Error(fmt.Sprintf("writeHOCR failed:%v", err))
+			}
+		}
+
+		switch {
+		case *asJSON:
+			encoder.Encode(result)
+		case result.Err == nil:
+			fmt.Printf("==> %s <==\n%s\n\n", result.Name, result.Content)
+		}
 	}
 
-	// read successful
-	slog.Info(fmt.Sprintf("processed file:%s read request", fPath))
-	return result
+	if failed > 0 {
+		os.Exit(1)
+	}
 }
 
-func readContentWithTimeout(fPath string) (*ContentResult, error) {
-	slog.Info(fmt.Sprintf("processing file:%s read request with %s timeout", fPath, MaxContentReadTimeout.String()))
+// writeHOCR saves the page of an image as dir/<file name>.hocr, e.g.
+// x.png.hocr. Images with the same file name in different directories get
+// a counter, x.png-2.hocr, so one does not overwrite the other. used holds
+// the names written so far.
+func writeHOCR(dir string, result extract.Result, used map[string]bool) error {
+	base := filepath.Base(result.Name)
+	name := base + ".hocr"
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s-%d.hocr", base, i)
+	}
+	used[name] = true
+	return os.WriteFile(filepath.Join(dir, name), []byte(result.OCR.HOCR()), 0o644)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), MaxContentReadTimeout)
-	defer cancel() // Ensure resources are released
+func serve(ctx context.Context, addr string, handler http.Handler) error {
+	mux := http.NewServeMux()
+	mux.Handle("/extract", handler)
 
-	done := make(chan ContentResult, 1)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 
+	errs := make(chan error, 1)
 	go func() {
-		done <- readContent(fPath)
+		slog.Info(fmt.Sprintf("serving POST /extract on %s", addr))
+		errs <- server.ListenAndServe()
 	}()
 
 	select {
-	case result := <-done:
-		slog.Info(fmt.Sprintf("successfully read the content of fpath:%s", fPath))
-		return &result, nil
-
+	case err := <-errs:
+		return err
 	case <-ctx.Done():
-		// handle error when context is cancelled or timeout
-		slog.Warn(fmt.Sprintf("fpath:%s content read has timed out.", fPath))
-		return &ContentResult{}, fmt.Errorf("timed out")
 	}
+
+	slog.Info("shutting down, waiting for the running extractions")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), MaxContentReadTimeout)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
 }
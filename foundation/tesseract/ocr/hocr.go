@@ -0,0 +1,80 @@
+package ocr
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+)
+
+// HOCR renders the page as an hOCR document, the HTML based format OCR
+// viewers and search indexers read positions from.
+func (p *Page) HOCR() string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en" lang="en">
+ <head>
+  <title></title>
+  <meta http-equiv="Content-Type" content="text/html;charset=utf-8"/>
+  <meta name="ocr-system" content="tesseract"/>
+  <meta name="ocr-capabilities" content="ocr_page ocr_carea ocr_par ocr_line ocrx_word"/>
+ </head>
+ <body>
+`)
+
+	page := Box{0, 0, p.Width, p.Height}
+	fmt.Fprintf(&b, "  <div class='ocr_page' id='page_1' title='%s'>\n", bbox(page))
+
+	word := 0
+	for i := 0; i < len(p.Lines); {
+		block := p.Lines[i].Block
+		end := i
+		for end < len(p.Lines) && p.Lines[end].Block == block {
+			end++
+		}
+
+		fmt.Fprintf(&b, "   <div class='ocr_carea' id='block_1_%d' title='%s'>\n", block, bbox(linesBox(p.Lines[i:end])))
+		for j := i; j < end; {
+			par := p.Lines[j].Paragraph
+			parEnd := j
+			for parEnd < end && p.Lines[parEnd].Paragraph == par {
+				parEnd++
+			}
+
+			fmt.Fprintf(&b, "    <p class='ocr_par' id='par_1_%d_%d' title='%s'>\n", block, par, bbox(linesBox(p.Lines[j:parEnd])))
+			for k := j; k < parEnd; k++ {
+				line := p.Lines[k]
+				fmt.Fprintf(&b, "     <span class='ocr_line' id='line_1_%d' title='%s'>", k+1, bbox(line.Box))
+				for n, w := range line.Words {
+					word++
+					if n > 0 {
+						b.WriteByte(' ')
+					}
+					fmt.Fprintf(&b, "<span class='ocrx_word' id='word_1_%d' title='%s; x_wconf %d'>%s</span>",
+						word, bbox(w.Box), int(math.Round(w.Confidence)), html.EscapeString(w.Text))
+				}
+				b.WriteString("</span>\n")
+			}
+			b.WriteString("    </p>\n")
+			j = parEnd
+		}
+		b.WriteString("   </div>\n")
+		i = end
+	}
+
+	b.WriteString("  </div>\n </body>\n</html>\n")
+	return b.String()
+}
+
+func bbox(b Box) string {
+	return fmt.Sprintf("bbox %d %d %d %d", b.X0, b.Y0, b.X1, b.Y1)
+}
+
+func linesBox(lines []Line) Box {
+	var box Box
+	for _, line := range lines {
+		box = box.Union(line.Box)
+	}
+	return box
+}
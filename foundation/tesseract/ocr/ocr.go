@@ -0,0 +1,226 @@
+// Package ocr holds what OCR engines share: the recognition options, the
+// recognized page with the position of every line and word, and the image
+// preprocessing run before recognition. The tesseract engine lives in
+// ocr/tesseract.
+package ocr
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EngineMode picks the tesseract recognizer (--oem).
+type EngineMode int
+
+const (
+	OEMDefault EngineMode = iota
+	OEMLegacy
+	OEMLSTM
+	OEMLegacyLSTM
+)
+
+// Tesseract returns the --oem number of m.
+func (m EngineMode) Tesseract() int {
+	switch m {
+	case OEMLegacy:
+		return 0
+	case OEMLSTM:
+		return 1
+	case OEMLegacyLSTM:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// ParseEngineMode reads a mode by name, default, legacy, lstm or
+// legacy+lstm, or by its --oem number.
+func ParseEngineMode(s string) (EngineMode, error) {
+	for _, m := range []EngineMode{OEMDefault, OEMLegacy, OEMLSTM, OEMLegacyLSTM} {
+		if s == m.String() || s == strconv.Itoa(m.Tesseract()) {
+			return m, nil
+		}
+	}
+	return OEMDefault, errors.Errorf("unknown engine mode %q", s)
+}
+
+func (m EngineMode) String() string {
+	switch m {
+	case OEMLegacy:
+		return "legacy"
+	case OEMLSTM:
+		return "lstm"
+	case OEMLegacyLSTM:
+		return "legacy+lstm"
+	default:
+		return "default"
+	}
+}
+
+type Options struct {
+	// Languages are the language packs to use, "eng" when empty.
+	Languages []string
+	// PSM is the tesseract page segmentation mode (--psm), 0 leaves the
+	// engine default. Orientation only mode is not supported, it
+	// recognizes no text.
+	PSM int
+	OEM EngineMode
+	// DPI is the resolution of the image, for scans without it in their
+	// metadata. 0 lets the engine guess.
+	DPI int
+	// Whitelist restricts the recognized characters, "0123456789" for
+	// digits only.
+	Whitelist string
+}
+
+// Box is a rectangle in pixels, X1 and Y1 excluded.
+type Box struct {
+	X0 int `json:"x0"`
+	Y0 int `json:"y0"`
+	X1 int `json:"x1"`
+	Y1 int `json:"y1"`
+}
+
+func (b Box) Empty() bool {
+	return b.X0 >= b.X1 || b.Y0 >= b.Y1
+}
+
+// Union returns the smallest box holding b and o.
+func (b Box) Union(o Box) Box {
+	if b.Empty() {
+		return o
+	}
+	if o.Empty() {
+		return b
+	}
+	return Box{min(b.X0, o.X0), min(b.Y0, o.Y0), max(b.X1, o.X1), max(b.Y1, o.Y1)}
+}
+
+func (b Box) scale(f float64) Box {
+	return Box{
+		X0: int(math.Floor(float64(b.X0) * f)),
+		Y0: int(math.Floor(float64(b.Y0) * f)),
+		X1: int(math.Ceil(float64(b.X1) * f)),
+		Y1: int(math.Ceil(float64(b.Y1) * f)),
+	}
+}
+
+type Word struct {
+	Text string `json:"text"`
+	Box  Box    `json:"box"`
+	// Confidence goes from 0 to 100.
+	Confidence float64 `json:"confidence"`
+}
+
+type Line struct {
+	Text       string  `json:"text"`
+	Box        Box     `json:"box"`
+	Confidence float64 `json:"confidence"`
+	// Block and Paragraph number the layout regions from 1, in reading
+	// order.
+	Block     int    `json:"block"`
+	Paragraph int    `json:"paragraph"`
+	Words     []Word `json:"words"`
+}
+
+type Page struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	// Skew is the rotation in degrees removed by preprocessing. Boxes
+	// are positions in the straightened image.
+	Skew       float64 `json:"skew,omitempty"`
+	Confidence float64 `json:"confidence"`
+	Lines      []Line  `json:"lines"`
+}
+
+// LayoutWord is a word as engines report it, numbered within its line,
+// paragraph and block.
+type LayoutWord struct {
+	Word
+	Block, Paragraph, Line int
+}
+
+// NewPage groups words, given in reading order, into lines.
+func NewPage(width, height int, words []LayoutWord) *Page {
+	page := &Page{Width: width, Height: height}
+
+	type key struct{ block, paragraph, line int }
+	var last key
+
+	for _, w := range words {
+		if strings.TrimSpace(w.Text) == "" {
+			continue
+		}
+
+		k := key{w.Block, w.Paragraph, w.Line}
+		if len(page.Lines) == 0 || k != last {
+			page.Lines = append(page.Lines, Line{Block: w.Block, Paragraph: w.Paragraph})
+			last = k
+		}
+
+		line := &page.Lines[len(page.Lines)-1]
+		line.Words = append(line.Words, w.Word)
+		line.Box = line.Box.Union(w.Box)
+	}
+
+	var total float64
+	var count int
+	for i := range page.Lines {
+		line := &page.Lines[i]
+		texts := make([]string, len(line.Words))
+		var sum float64
+		for j, w := range line.Words {
+			texts[j] = w.Text
+			sum += w.Confidence
+		}
+		line.Text = strings.Join(texts, " ")
+		line.Confidence = round(sum / float64(len(line.Words)))
+		total += sum
+		count += len(line.Words)
+	}
+	if count > 0 {
+		page.Confidence = round(total / float64(count))
+	}
+	return page
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// Text returns the lines of the page, with an empty line between
+// paragraphs.
+func (p *Page) Text() string {
+	var b strings.Builder
+	for i, line := range p.Lines {
+		if i > 0 {
+			prev := p.Lines[i-1]
+			if prev.Block != line.Block || prev.Paragraph != line.Paragraph {
+				b.WriteByte('\n')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(line.Text)
+	}
+	return b.String()
+}
+
+// Scale multiplies every position of the page by f, to report boxes
+// found on a resized image in the coordinates of the original.
+func (p *Page) Scale(f float64) {
+	if f == 1 {
+		return
+	}
+	p.Width = int(math.Round(float64(p.Width) * f))
+	p.Height = int(math.Round(float64(p.Height) * f))
+	for i := range p.Lines {
+		line := &p.Lines[i]
+		line.Box = line.Box.scale(f)
+		for j := range line.Words {
+			line.Words[j].Box = line.Words[j].Box.scale(f)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ocr
+
+import (
+	"strings"
+	"testing"
+)
+
+func layoutWord(text string, box Box, confidence float64, block, par, line int) LayoutWord {
+	return LayoutWord{Word: Word{Text: text, Box: box, Confidence: confidence}, Block: block, Paragraph: par, Line: line}
+}
+
+func samplePage() *Page {
+	return NewPage(200, 100, []LayoutWord{
+		layoutWord("Hello", Box{10, 10, 50, 20}, 90, 1, 1, 1),
+		layoutWord("world", Box{55, 12, 95, 22}, 80, 1, 1, 1),
+		layoutWord(" ", Box{96, 12, 99, 22}, 10, 1, 1, 1),
+		layoutWord("again", Box{10, 30, 50, 40}, 70, 1, 1, 2),
+		layoutWord("R&D", Box{10, 60, 40, 70}, 60, 2, 1, 1),
+	})
+}
+
+func TestNewPage(t *testing.T) {
+	page := samplePage()
+
+	if len(page.Lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(page.Lines))
+	}
+	first := page.Lines[0]
+	if first.Text != "Hello world" || first.Box != (Box{10, 10, 95, 22}) || first.Confidence != 85 {
+		t.Errorf("first line = %+v", first)
+	}
+	if page.Confidence != 75 {
+		t.Errorf("page confidence = %v, want 75", page.Confidence)
+	}
+
+	want := "Hello world\nagain\n\nR&D"
+	if got := page.Text(); got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+}
+
+func TestScale(t *testing.T) {
+	page := samplePage()
+	page.Scale(2)
+
+	if page.Width != 400 || page.Height != 200 {
+		t.Errorf("page is %dx%d", page.Width, page.Height)
+	}
+	if box := page.Lines[0].Words[1].Box; box != (Box{110, 24, 190, 44}) {
+		t.Errorf("word box = %+v", box)
+	}
+	if box := page.Lines[0].Box; box != (Box{20, 20, 190, 44}) {
+		t.Errorf("line box = %+v", box)
+	}
+}
+
+func TestHOCR(t *testing.T) {
+	hocr := samplePage().HOCR()
+
+	for _, want := range []string{
+		"<div class='ocr_page' id='page_1' title='bbox 0 0 200 100'>",
+		"<div class='ocr_carea' id='block_1_2' title='bbox 10 60 40 70'>",
+		"<p class='ocr_par' id='par_1_1_1' title='bbox 10 10 95 40'>",
+		"<span class='ocr_line' id='line_1_2' title='bbox 10 30 50 40'>",
+		"<span class='ocrx_word' id='word_1_2' title='bbox 55 12 95 22; x_wconf 80'>world</span>",
+		">R&amp;D</span>",
+	} {
+		if !strings.Contains(hocr, want) {
+			t.Errorf("hOCR is missing %s\n%s", want, hocr)
+		}
+	}
+}
+
+func TestParseEngineMode(t *testing.T) {
+	for in, want := range map[string]EngineMode{"lstm": OEMLSTM, "0": OEMLegacy, "legacy+lstm": OEMLegacyLSTM, "3": OEMDefault} {
+		if got, err := ParseEngineMode(in); err != nil || got != want {
+			t.Errorf("ParseEngineMode(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseEngineMode("fast"); err == nil {
+		t.Error("ParseEngineMode(fast) succeeded")
+	}
+}
@@ -0,0 +1,330 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+
+	"github.com/pkg/errors"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// maxDecodePixels bounds the images Prepare decodes, a small file can
+// declare a huge canvas.
+const maxDecodePixels = 100_000_000
+
+// maxSkew is the largest rotation, in degrees, Deskew looks for.
+const maxSkew = 10.0
+
+var ErrImageTooLarge = errors.New("image too large to decode")
+
+// Preprocess lists the steps run on an image before recognition.
+type Preprocess struct {
+	Grayscale bool
+	// Threshold turns the image black and white, at the gray level
+	// Otsu's method finds. It implies Grayscale.
+	Threshold bool
+	// Deskew straightens text rotated by up to 10 degrees. It implies
+	// Grayscale.
+	Deskew bool
+	// MaxPixels downscales larger images to that many pixels, 0 keeps
+	// their size.
+	MaxPixels int
+	// MaxBytes downscales images until their encoding fits, 0 keeps
+	// their size.
+	MaxBytes int64
+}
+
+func (p Preprocess) gray() bool {
+	return p.Grayscale || p.Threshold || p.Deskew
+}
+
+// Prepared is an image ready for recognition.
+type Prepared struct {
+	Image []byte
+	// Width and Height are the size of the original image.
+	Width, Height int
+	// Scale is the size of Image relative to the original, positions
+	// found in Image are divided by it.
+	Scale float64
+	// Skew is the rotation in degrees Deskew removed.
+	Skew float64
+}
+
+// Prepare runs the steps of p on the encoded image data and returns the
+// result as PNG. An image no step changes is returned as it is.
+func Prepare(ctx context.Context, data []byte, p Preprocess) (*Prepared, error) {
+	out := &Prepared{Image: data, Scale: 1}
+	oversized := p.MaxBytes > 0 && int64(len(data)) > p.MaxBytes
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if !p.gray() && !oversized {
+			// The engine may know formats we do not
+			return out, nil
+		}
+		return nil, errors.Wrap(err, "failed to decode image")
+	}
+	out.Width, out.Height = cfg.Width, cfg.Height
+
+	pixels := cfg.Width * cfg.Height
+	if pixels > maxDecodePixels {
+		return nil, errors.Wrapf(ErrImageTooLarge, "%dx%d pixels", cfg.Width, cfg.Height)
+	}
+
+	scale := 1.0
+	if p.MaxPixels > 0 && pixels > p.MaxPixels {
+		scale = math.Sqrt(float64(p.MaxPixels) / float64(pixels))
+	}
+	if !p.gray() && scale == 1 && !oversized {
+		return out, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode image")
+	}
+
+	if p.gray() {
+		img = toGray(img)
+	}
+	if scale < 1 {
+		img = resize(img, scale)
+	}
+
+	if p.Deskew {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		gray := img.(*image.Gray)
+		angle, err := detectSkew(ctx, gray)
+		if err != nil {
+			return nil, err
+		}
+		if angle != 0 {
+			img = rotate(gray, angle)
+			out.Skew = angle
+		}
+	}
+
+	if p.Threshold {
+		img = binarize(img.(*image.Gray))
+	}
+
+	// Shrink until the encoding fits, a few rounds at most since every
+	// one aims below the limit
+	for round := 0; ; round++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, errors.Wrap(err, "failed to encode image")
+		}
+		out.Image = buf.Bytes()
+
+		if p.MaxBytes <= 0 || int64(buf.Len()) <= p.MaxBytes || round == 4 {
+			break
+		}
+		img = resize(img, 0.9*math.Sqrt(float64(p.MaxBytes)/float64(buf.Len())))
+		if p.Threshold {
+			img = binarize(img.(*image.Gray))
+		}
+	}
+
+	out.Scale = float64(img.Bounds().Dx()) / float64(cfg.Width)
+	return out, nil
+}
+
+// toGray converts img to gray on a white background, transparent pixels
+// would turn black otherwise.
+func toGray(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+
+	b := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(gray, gray.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(gray, gray.Bounds(), img, b.Min, draw.Over)
+	return gray
+}
+
+func resize(img image.Image, f float64) image.Image {
+	b := img.Bounds()
+	r := image.Rect(0, 0, max(1, int(float64(b.Dx())*f)), max(1, int(float64(b.Dy())*f)))
+
+	var dst draw.Image
+	if _, ok := img.(*image.Gray); ok {
+		dst = image.NewGray(r)
+	} else {
+		dst = image.NewRGBA(r)
+	}
+	draw.BiLinear.Scale(dst, r, img, b, draw.Src, nil)
+	return dst
+}
+
+// otsu returns the gray level that best splits the pixels in two classes,
+// ink and paper.
+func otsu(gray *image.Gray) uint8 {
+	var hist [256]int
+	for _, v := range gray.Pix {
+		hist[v]++
+	}
+
+	total := len(gray.Pix)
+	var sum float64
+	for i, n := range hist {
+		sum += float64(i * n)
+	}
+
+	var sumBack, best float64
+	var back int
+	threshold := 127
+	for t, n := range hist {
+		back += n
+		if back == 0 {
+			continue
+		}
+		front := total - back
+		if front == 0 {
+			break
+		}
+		sumBack += float64(t * n)
+
+		meanBack := sumBack / float64(back)
+		meanFront := (sum - sumBack) / float64(front)
+		between := float64(back) * float64(front) * (meanBack - meanFront) * (meanBack - meanFront)
+		if between > best {
+			best, threshold = between, t
+		}
+	}
+	return uint8(threshold)
+}
+
+func binarize(gray *image.Gray) *image.Gray {
+	t := otsu(gray)
+	out := image.NewGray(gray.Bounds())
+	for i, v := range gray.Pix {
+		if v > t {
+			out.Pix[i] = 255
+		}
+	}
+	return out
+}
+
+// detectSkew returns the angle in degrees text lines rise by from left to
+// right, in image coordinates. It projects the ink on the vertical axis
+// for every candidate angle, the one aligning the lines gives the
+// sharpest profile.
+func detectSkew(ctx context.Context, gray *image.Gray) (float64, error) {
+	b := gray.Bounds()
+	t := otsu(gray)
+
+	// Sample the ink, enough points give the same answer much faster
+	step := max(1, int(math.Sqrt(float64(b.Dx()*b.Dy())/250_000)))
+	var xs, ys []float64
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			if gray.GrayAt(x, y).Y <= t {
+				xs = append(xs, float64(x-b.Min.X))
+				ys = append(ys, float64(y-b.Min.Y))
+			}
+		}
+	}
+	// Blank or solid images have no lines to align
+	if len(xs) == 0 || len(xs)*step*step > b.Dx()*b.Dy()/2 {
+		return 0, nil
+	}
+
+	bins := make([]int, b.Dx()+b.Dy()*2)
+	score := func(angle float64) float64 {
+		clear(bins)
+		tan := math.Tan(angle * math.Pi / 180)
+		offset := float64(b.Dx())
+		for i := range xs {
+			bin := int(ys[i] - xs[i]*tan + offset)
+			if bin >= 0 && bin < len(bins) {
+				bins[bin]++
+			}
+		}
+		var s float64
+		for _, n := range bins {
+			s += float64(n * n)
+		}
+		return s
+	}
+
+	search := func(from, to, by float64) (float64, error) {
+		best, bestScore := 0.0, -1.0
+		for a := from; a <= to+by/2; a += by {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+			if s := score(a); s > bestScore {
+				best, bestScore = a, s
+			}
+		}
+		return best, nil
+	}
+
+	coarse, err := search(-maxSkew, maxSkew, 0.5)
+	if err != nil {
+		return 0, err
+	}
+	fine, err := search(coarse-0.5, coarse+0.5, 0.1)
+	if err != nil {
+		return 0, err
+	}
+
+	fine = math.Round(fine*10) / 10
+	if math.Abs(fine) < 0.2 || score(fine) < 1.05*score(0) {
+		return 0, nil
+	}
+	return fine, nil
+}
+
+// rotate returns gray rotated by -angle degrees around its center, the
+// corners uncovered are white.
+func rotate(gray *image.Gray, angle float64) *image.Gray {
+	b := gray.Bounds()
+	out := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			sx := cx + dx*cos - dy*sin
+			sy := cy + dx*sin + dy*cos
+			out.SetGray(x, y, color.Gray{Y: bilinear(gray, sx, sy)})
+		}
+	}
+	return out
+}
+
+func bilinear(gray *image.Gray, x, y float64) uint8 {
+	b := gray.Bounds()
+	at := func(x, y int) float64 {
+		if x < 0 || y < 0 || x >= b.Dx() || y >= b.Dy() {
+			return 255
+		}
+		return float64(gray.Pix[gray.PixOffset(b.Min.X+x, b.Min.Y+y)])
+	}
+
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	top := at(x0, y0)*(1-fx) + at(x0+1, y0)*fx
+	bottom := at(x0, y0+1)*(1-fx) + at(x0+1, y0+1)*fx
+	return uint8(math.Round(top*(1-fy) + bottom*fy))
+}
@@ -0,0 +1,155 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decodePNG(t *testing.T, data []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+// lines draws dark text-like lines rising by angle degrees on white paper.
+func lines(width, height int, angle float64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	tan := math.Tan(angle * math.Pi / 180)
+	for y0 := 40; y0 < height-40; y0 += 30 {
+		for x := 20; x < width-20; x++ {
+			y := int(float64(y0) + float64(x)*tan)
+			for dy := range 4 {
+				if y+dy >= 0 && y+dy < height {
+					img.SetGray(x, y+dy, color.Gray{Y: 20})
+				}
+			}
+		}
+	}
+	return img
+}
+
+func TestPreparePassthrough(t *testing.T) {
+	data := encodePNG(t, image.NewRGBA(image.Rect(0, 0, 50, 40)))
+
+	out, err := Prepare(context.Background(), data, Preprocess{MaxPixels: 10_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Image, data) || out.Scale != 1 || out.Width != 50 || out.Height != 40 {
+		t.Errorf("image was changed: %+v", out)
+	}
+
+	// Formats we cannot decode go to the engine untouched
+	raw := []byte("\x00\x00\x00\x0cjP  not decodable")
+	if out, err := Prepare(context.Background(), raw, Preprocess{}); err != nil || !bytes.Equal(out.Image, raw) {
+		t.Errorf("Prepare(undecodable) = %v, %v", out, err)
+	}
+	if _, err := Prepare(context.Background(), raw, Preprocess{Grayscale: true}); err == nil {
+		t.Error("Prepare(undecodable, Grayscale) succeeded")
+	}
+}
+
+func TestPrepareDownscale(t *testing.T) {
+	noise := image.NewGray(image.Rect(0, 0, 400, 300))
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := range noise.Pix {
+		noise.Pix[i] = uint8(rng.Uint32())
+	}
+	data := encodePNG(t, noise)
+
+	out, err := Prepare(context.Background(), data, Preprocess{MaxPixels: 30_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := decodePNG(t, out.Image).Bounds()
+	if b.Dx()*b.Dy() > 30_000 || out.Scale > 0.51 || out.Width != 400 || out.Height != 300 {
+		t.Errorf("got %v, scale %v, original %dx%d", b, out.Scale, out.Width, out.Height)
+	}
+
+	out, err = Prepare(context.Background(), data, Preprocess{MaxBytes: 20_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Image) > 20_000 || out.Scale >= 1 {
+		t.Errorf("got %d bytes at scale %v", len(out.Image), out.Scale)
+	}
+}
+
+func TestPrepareThreshold(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 60, 20))
+	for x := range 60 {
+		for y := range 20 {
+			v := uint8(220)
+			if x < 20 {
+				v = 60
+			}
+			img.Set(x, y, color.RGBA{v, v, v / 2, 255})
+		}
+	}
+
+	out, err := Prepare(context.Background(), encodePNG(t, img), Preprocess{Threshold: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gray, ok := decodePNG(t, out.Image).(*image.Gray)
+	if !ok {
+		t.Fatalf("threshold gave %T, want *image.Gray", gray)
+	}
+	if gray.GrayAt(5, 5).Y != 0 || gray.GrayAt(50, 5).Y != 255 {
+		t.Errorf("pixels = %v, %v, want black and white", gray.GrayAt(5, 5), gray.GrayAt(50, 5))
+	}
+}
+
+func TestPrepareDeskew(t *testing.T) {
+	data := encodePNG(t, lines(600, 400, 3))
+
+	out, err := Prepare(context.Background(), data, Preprocess{Deskew: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(out.Skew-3) > 0.3 {
+		t.Errorf("skew = %v, want about 3", out.Skew)
+	}
+
+	angle, err := detectSkew(context.Background(), decodePNG(t, out.Image).(*image.Gray))
+	if err != nil || angle != 0 {
+		t.Errorf("straightened image has skew %v, %v", angle, err)
+	}
+
+	out, err = Prepare(context.Background(), encodePNG(t, lines(600, 400, 0)), Preprocess{Deskew: true})
+	if err != nil || out.Skew != 0 {
+		t.Errorf("straight image: skew %v, %v", out.Skew, err)
+	}
+}
+
+func TestPrepareCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Prepare(ctx, encodePNG(t, lines(200, 200, 2)), Preprocess{Deskew: true})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
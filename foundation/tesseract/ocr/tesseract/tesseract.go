@@ -0,0 +1,126 @@
+// Package tesseract is the OCR engine running tesseract in process,
+// through the gosseract bindings.
+package tesseract
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+
+	"github.com/otiai10/gosseract/v2"
+	"github.com/pkg/errors"
+	"github.com/premgowda/98/tesseract/ocr"
+)
+
+// Engine implements extract.OCR. Every call creates its own client,
+// gosseract clients must not be shared between goroutines.
+type Engine struct {
+	opts ocr.Options
+}
+
+func New(opts ocr.Options) *Engine {
+	return &Engine{opts: opts}
+}
+
+// Recognize returns the words found in image with their positions.
+// Tesseract cannot be interrupted once it recognizes a page, so ctx is
+// only checked before it starts.
+func (e *Engine) Recognize(ctx context.Context, img []byte) (*ocr.Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	client := gosseract.NewClient()
+	defer client.Close()
+
+	if err := e.configure(client); err != nil {
+		return nil, err
+	}
+
+	if e.opts.OEM != ocr.OEMDefault {
+		// gosseract has no setter for the engine mode, it can only be
+		// set in a config file read when tesseract starts
+		config, err := engineModeConfig(e.opts.OEM)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(config)
+
+		if err := client.SetConfigFile(config); err != nil {
+			return nil, errors.Wrap(err, "failed to set engine mode")
+		}
+	}
+
+	if err := client.SetImageFromBytes(img); err != nil {
+		return nil, errors.Wrap(err, "failed to read image bytes")
+	}
+
+	boxes, err := client.GetBoundingBoxesVerbose()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to extract image text")
+	}
+
+	words := make([]ocr.LayoutWord, len(boxes))
+	for i, box := range boxes {
+		words[i] = ocr.LayoutWord{
+			Word: ocr.Word{
+				Text:       box.Word,
+				Box:        ocr.Box{X0: box.Box.Min.X, Y0: box.Box.Min.Y, X1: box.Box.Max.X, Y1: box.Box.Max.Y},
+				Confidence: box.Confidence,
+			},
+			Block:     box.BlockNum,
+			Paragraph: box.ParNum,
+			Line:      box.LineNum,
+		}
+	}
+
+	var width, height int
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(img)); err == nil {
+		width, height = cfg.Width, cfg.Height
+	}
+	return ocr.NewPage(width, height, words), nil
+}
+
+func (e *Engine) configure(client *gosseract.Client) error {
+	if len(e.opts.Languages) > 0 {
+		if err := client.SetLanguage(e.opts.Languages...); err != nil {
+			return errors.Wrap(err, "failed to set languages")
+		}
+	}
+
+	if e.opts.PSM > 0 {
+		if err := client.SetPageSegMode(gosseract.PageSegMode(e.opts.PSM)); err != nil {
+			return errors.Wrap(err, "failed to set page segmentation mode")
+		}
+	}
+
+	if e.opts.DPI > 0 {
+		if err := client.SetVariable("user_defined_dpi", strconv.Itoa(e.opts.DPI)); err != nil {
+			return errors.Wrap(err, "failed to set dpi")
+		}
+	}
+
+	if e.opts.Whitelist != "" {
+		if err := client.SetWhitelist(e.opts.Whitelist); err != nil {
+			return errors.Wrap(err, "failed to set whitelist")
+		}
+	}
+	return nil
+}
+
+func engineModeConfig(mode ocr.EngineMode) (string, error) {
+	f, err := os.CreateTemp("", "tesseract-oem-*.config")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "tessedit_ocr_engine_mode %d\n", mode.Tesseract()); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
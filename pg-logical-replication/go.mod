@@ -3,14 +3,15 @@ module github.com/pg-infoblox/pg-logical-replication
 go 1.26
 
 require (
+	github.com/jackc/pglogrepl v0.0.0-20260401131349-e37c41485510
 	github.com/jackc/pgx/v5 v5.9.1
 	github.com/joho/godotenv v1.5.1
+	github.com/premgowda98/signals v0.0.0
 	github.com/segmentio/kafka-go v0.4.47
 )
 
 require (
 	github.com/jackc/pgio v1.0.0 // indirect
-	github.com/jackc/pglogrepl v0.0.0-20260401131349-e37c41485510 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
 	github.com/klauspost/compress v1.15.9 // indirect
@@ -18,3 +19,5 @@ require (
 	golang.org/x/crypto v0.17.0 // indirect
 	golang.org/x/text v0.35.0 // indirect
 )
+
+replace github.com/premgowda98/signals => ../foundation/signals
@@ -6,14 +6,13 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/jackc/pglogrepl"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgproto3"
 	"github.com/joho/godotenv"
+	"github.com/premgowda98/signals/lifecycle"
 	kafka "github.com/segmentio/kafka-go"
 )
 
@@ -37,17 +36,7 @@ type FieldDiff struct {
 }
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle graceful shutdown
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigCh
-		log.Println("Shutting down...")
-		cancel()
-	}()
+	ctx := context.Background()
 
 	_ = godotenv.Load()
 
@@ -89,7 +78,6 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
 	}
-	defer conn.Close(ctx)
 	log.Println("✓ Connected to PostgreSQL (replication mode)")
 
 	// Connect to Kafka
@@ -98,16 +86,24 @@ func main() {
 		Topic:    kafkaTopic,
 		Balancer: &kafka.LeastBytes{},
 	}
-	defer kafkaWriter.Close()
 	log.Println("✓ Connected to Kafka")
 
-	if err := runCDC(ctx, conn, kafkaWriter, slotName, publicationName); err != nil {
-		if ctx.Err() != nil {
-			log.Println("Stopped.")
-		} else {
-			log.Fatalf("CDC error: %v", err)
-		}
+	// Stopped in reverse: the replication stream first, then the Kafka
+	// writer flushes, then the connection closes. SIGINT/SIGTERM stop,
+	// a second one exits right away.
+	app := lifecycle.New(lifecycle.Config{ShutdownTimeout: 15 * time.Second})
+	app.Add(
+		lifecycle.Hook("postgres", nil, conn.Close),
+		lifecycle.Closer("kafka", kafkaWriter.Close),
+		lifecycle.Task("cdc", func(ctx context.Context) error {
+			return runCDC(ctx, conn, kafkaWriter, slotName, publicationName)
+		}),
+	)
+
+	if err := app.Run(ctx); err != nil {
+		log.Fatalf("CDC error: %v", err)
 	}
+	log.Println("Stopped.")
 }
 
 func runCDC(ctx context.Context, conn *pgconn.PgConn, kafkaWriter *kafka.Writer, slotName, publicationName string) error {